@@ -0,0 +1,207 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+upnpgen generates typed Go client proxies and server skeletons from UPnP descriptions.
+
+	NAME
+	upnpgen
+
+	SYNOPSIS
+	upnpgen [OPTIONS] <device description file or URL>
+	upnpgen [OPTIONS] -type <service type> <service description file or URL>
+
+	DESCRIPTION
+	upnpgen reads a device description and the service descriptions of the device and the embedded devices,
+	and generates the typed client methods, the service interfaces and the action handlers.
+	The relative SCPDURLs of a device description file are resolved from the directory of the file.
+	The names are generated from the short types without the versions, so the devices or the services which have the same
+	short type, such as the different versions of a service type, have to be generated into the different packages.
+
+	OPTIONS
+	-pkg : *name* Package name of the generated code (default: main).
+	-o : *file* Output file (default: standard output).
+	-type : *service type* Read a service description of the specified service type.
+	-v [0 | 1] : Enable verbose output.
+
+	EXIT STATUS
+	  Return EXIT_SUCCESS or EXIT_FAILURE
+
+	EXAMPLES
+	  The following is how to generate the code of an InternetGatewayDevice
+	    upnpgen -pkg igd -o igd.go http://192.168.1.1:5000/rootDesc.xml
+*/
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp"
+	"github.com/cybergarage/go-net-upnp/net/upnp/codegen"
+	"github.com/cybergarage/go-net-upnp/net/upnp/http"
+)
+
+const (
+	errorBadResponse = "%s is bad response (%d)"
+)
+
+func isURL(path string) bool {
+	u, err := url.Parse(path)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+func readDescription(path string) ([]byte, error) {
+	if !isURL(path) {
+		return os.ReadFile(path)
+	}
+
+	client, err := http.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GetContext(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(errorBadResponse, path, res.StatusCode)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// loadServiceDescriptionFiles loads the service descriptions of the device and the embedded devices from the files in the specified directory.
+func loadServiceDescriptionFiles(dev *upnp.Device, dir string) error {
+	for _, service := range dev.GetServices() {
+		if isURL(service.SCPDURL) {
+			if err := service.LoadDescriptionFromSCPDURL(); err != nil {
+				return err
+			}
+			continue
+		}
+		descBytes, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(service.SCPDURL, "/"))))
+		if err != nil {
+			return err
+		}
+		if err := service.LoadDescriptionBytes(descBytes); err != nil {
+			return err
+		}
+	}
+
+	for _, embeddedDev := range dev.GetEmbeddedDevices() {
+		if err := loadServiceDescriptionFiles(embeddedDev, dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func generateDevice(gen *codegen.Generator, path string) ([]byte, error) {
+	if isURL(path) {
+		dev, err := upnp.NewDeviceFromDescriptionURL(path)
+		if err != nil {
+			return nil, err
+		}
+		dev.SetLocationURL(path)
+		if err := dev.LoadServiceDescriptions(); err != nil {
+			return nil, err
+		}
+		return gen.GenerateDevice(dev)
+	}
+
+	descBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dev, err := upnp.NewDeviceFromDescription(string(descBytes))
+	if err != nil {
+		return nil, err
+	}
+	if err := loadServiceDescriptionFiles(dev, filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	return gen.GenerateDevice(dev)
+}
+
+func generateService(gen *codegen.Generator, serviceType string, path string) ([]byte, error) {
+	descBytes, err := readDescription(path)
+	if err != nil {
+		return nil, err
+	}
+	service := upnp.NewService()
+	service.ServiceType = serviceType
+	if err := service.LoadDescriptionBytes(descBytes); err != nil {
+		return nil, err
+	}
+	return gen.GenerateServices(service)
+}
+
+func main() {
+	// Set command line options
+
+	pkg := flag.String("pkg", codegen.DefaultPackage, "Package name of the generated code")
+	output := flag.String("o", "", "Output file")
+	serviceType := flag.String("type", "", "Service type of the service description")
+	verbose := flag.Int("v", 0, "Enable verbose mode [0|1]")
+	flag.Usage = func() {
+		cmd := strings.Split(os.Args[0], "/")
+		fmt.Fprintf(os.Stderr, "Usage of %s: [OPTIONS] <description file or URL>\n", cmd[len(cmd)-1])
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	flag.Parse()
+
+	if 0 < *verbose {
+		log.SetDefault(log.NewStdoutLogger(log.LevelTrace))
+	}
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+	}
+
+	// Generate the code
+
+	gen := codegen.NewGenerator()
+	gen.Package = *pkg
+
+	var code []byte
+	var err error
+	if 0 < len(*serviceType) {
+		code, err = generateService(gen, *serviceType, flag.Arg(0))
+	} else {
+		code, err = generateDevice(gen, flag.Arg(0))
+	}
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	// Output the code
+
+	if len(*output) == 0 {
+		os.Stdout.Write(code)
+		os.Exit(0)
+	}
+
+	err = os.WriteFile(*output, code, 0o644)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
@@ -31,7 +31,7 @@ func NewControlPoint() *ControlPoint {
 }
 
 func printMessage(msg string) {
-	log.Tracef(msg)
+	log.Trace(msg)
 }
 
 func GetFromToMessageFromSSDPPacket(req *ssdp.Packet) string {
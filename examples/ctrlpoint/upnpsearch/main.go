@@ -28,14 +28,16 @@ upnpsearch dumps prints all devices in the local network.
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/cybergarage/go-logger/log"
 	"github.com/cybergarage/go-net-upnp/net/upnp"
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
 )
 
 func printDevice(n int, dev *upnp.Device) {
@@ -79,26 +81,28 @@ func main() {
 	}
 	defer ctrlPoint.Stop()
 
-	// Search root devices
+	// Search root devices until all search responses are received
 
-	err = ctrlPoint.SearchRootDevice()
+	devs, err := ctrlPoint.SearchContext(context.Background(), ssdp.RootDevice, nil)
 	if err != nil {
-		log.Error(err)
-		os.Exit(1)
+		var searchErr *upnp.SearchError
+		if !errors.As(err, &searchErr) {
+			log.Error(err)
+			os.Exit(1)
+		}
+		for _, fetchErr := range searchErr.Errors {
+			log.Warnf("%s", fetchErr.Error())
+		}
 	}
 
-	// Sleep until all search responses are received
-
-	time.Sleep(time.Duration(ctrlPoint.SearchMX) * time.Second)
-
 	// Print basic descriptions of found devices
 
-	if len(ctrlPoint.GetRootDevices()) == 0 {
+	if len(devs) == 0 {
 		fmt.Printf("UPnP device is not found !!\n")
 		os.Exit(0)
 	}
 
-	for n, dev := range ctrlPoint.GetRootDevices() {
+	for n, dev := range devs {
 		printDevice(n, dev)
 	}
 
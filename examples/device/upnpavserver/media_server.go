@@ -42,13 +42,50 @@ func NewMediaServer() (*MediaServer, error) {
 		Device: dev,
 	}
 
-	mediaServer.ActionListener = mediaServer
+	mediaServer.Use(upnp.NewLoggingActionMiddleware(), upnp.NewRecoveryActionMiddleware())
+
+	err = mediaServer.SetServiceActionHandler(conDirService.ServiceType, upnp.ActionHandlerFunc(mediaServer.contentDirectoryActionReceived))
+	if err != nil {
+		return nil, err
+	}
+
+	err = mediaServer.SetServiceActionHandler(conMgrService.ServiceType, upnp.ActionHandlerFunc(mediaServer.connectionManagerActionReceived))
+	if err != nil {
+		return nil, err
+	}
 
 	return mediaServer, nil
 }
 
-func (server *MediaServer) ActionRequestReceived(action *upnp.Action) upnp.Error {
+func (server *MediaServer) contentDirectoryActionReceived(req *upnp.ActionRequest) upnp.Error {
+	action := req.Action
+
+	switch action.Name {
+	case "GetSearchCapabilities":
+		action.SetArgumentString("SearchCaps", "")
+		return nil
+	case "GetSortCapabilities":
+		action.SetArgumentString("SortCaps", "")
+		return nil
+	case "GetSystemUpdateID":
+		action.SetArgumentUint("Id", 0)
+		return nil
+	}
+
+	return upnp.NewErrorFromCode(upnp.ErrorOptionalActionNotImplemented)
+}
+
+func (server *MediaServer) connectionManagerActionReceived(req *upnp.ActionRequest) upnp.Error {
+	action := req.Action
+
 	switch action.Name {
+	case "GetProtocolInfo":
+		action.SetArgumentString("Source", "http-get:*:*:*")
+		action.SetArgumentString("Sink", "")
+		return nil
+	case "GetCurrentConnectionIDs":
+		action.SetArgumentString("ConnectionIDs", "0")
+		return nil
 	}
 
 	return upnp.NewErrorFromCode(upnp.ErrorOptionalActionNotImplemented)
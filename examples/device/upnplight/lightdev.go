@@ -5,25 +5,20 @@
 package main
 
 import (
+	"context"
+
 	"github.com/cybergarage/go-net-upnp/net/upnp"
 )
 
 const (
-	SetTarget      = "SetTarget"
-	NewTargetValue = "newTargetValue"
-	GetTarget      = "GetTarget"
-	RetTargetValue = "RetTargetValue"
-	GetStatus      = "GetStatus"
-	ResultStatus   = "ResultStatus"
-
-	DefaultTarget = "Living"
+	DefaultTarget = true
 	DefaultStatus = true
 )
 
 type LightDevice struct {
 	*upnp.Device
 
-	Target string
+	Target bool
 	Status bool
 }
 
@@ -48,34 +43,25 @@ func NewLightDevice() (*LightDevice, error) {
 		Target: DefaultTarget,
 		Status: DefaultStatus,
 	}
-	lightDev.ActionListener = lightDev
+
+	err = lightDev.BindService(service.ServiceType, lightDev)
+	if err != nil {
+		return nil, err
+	}
 
 	return lightDev, nil
 }
 
-func (def *LightDevice) ActionRequestReceived(action *upnp.Action) upnp.Error {
-	switch action.Name {
-	case SetTarget:
-		target, err := action.GetArgumentString(NewTargetValue)
-		if err == nil {
-			def.Target = target
-		} else {
-			return upnp.NewErrorFromCode(upnp.ErrorInvalidArgs)
-		}
-		return nil
-	case GetTarget:
-		err := action.SetArgumentString(RetTargetValue, def.Target)
-		if err != nil {
-			return upnp.NewErrorFromCode(upnp.ErrorInvalidArgs)
-		}
-		return nil
-	case GetStatus:
-		err := action.SetArgumentBool(ResultStatus, def.Status)
-		if err != nil {
-			return upnp.NewErrorFromCode(upnp.ErrorInvalidArgs)
-		}
-		return nil
-	}
+func (def *LightDevice) SetTarget(ctx context.Context, newTargetValue bool) error {
+	def.Target = newTargetValue
+	def.Status = newTargetValue
+	return nil
+}
+
+func (def *LightDevice) GetTarget(ctx context.Context) (bool, error) {
+	return def.Target, nil
+}
 
-	return upnp.NewErrorFromCode(upnp.ErrorOptionalActionNotImplemented)
+func (def *LightDevice) GetStatus(ctx context.Context) (bool, error) {
+	return def.Status, nil
 }
@@ -5,10 +5,12 @@
 package upnp
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/cybergarage/go-logger/log"
 	"github.com/cybergarage/go-net-upnp/net/upnp/control"
@@ -19,6 +21,7 @@ const (
 	errorActionArgumentNotFound   = "argument (%s) is not found"
 	errorActionNameIsInvalid      = "action name of action response (%s) is not equal this action name (%s)"
 	errorActionHasNoParentService = "action (%s) has no parent service"
+	errorActionArgumentNotInput   = "argument (%s) is not an input argument"
 )
 
 // A Action represents a UPnP action.
@@ -41,6 +44,22 @@ func NewAction() *Action {
 	return action
 }
 
+// Copy returns a new action instance which has the copied arguments.
+// The copied action shares the parent service as a read-only description, so that the argument values can be set and
+// read without affecting the other invocations of the same action.
+func (action *Action) Copy() *Action {
+	newAction := &Action{
+		XMLName:       action.XMLName,
+		Name:          action.Name,
+		ParentService: action.ParentService,
+	}
+	newAction.ArgumentList.XMLName = action.ArgumentList.XMLName
+	newAction.ArgumentList.Arguments = make([]Argument, len(action.ArgumentList.Arguments))
+	copy(newAction.ArgumentList.Arguments, action.ArgumentList.Arguments)
+	newAction.reviseParentObject()
+	return newAction
+}
+
 func (action *Action) reviseParentObject() error {
 	for n := range len(action.ArgumentList.Arguments) {
 		arg := &action.ArgumentList.Arguments[n]
@@ -169,6 +188,60 @@ func (action *Action) GetArgumentBool(name string) (bool, error) {
 	return arg.GetBool()
 }
 
+// SetArgumentUint sets a unsigned integer value into the specified argument.
+func (action *Action) SetArgumentUint(name string, value uint64) error {
+	arg, err := action.GetArgumentByName(name)
+	if err != nil {
+		return err
+	}
+	return arg.SetUint(value)
+}
+
+// GetArgumentUint return a unsigned integer value into the specified argument.
+func (action *Action) GetArgumentUint(name string) (uint64, error) {
+	arg, err := action.GetArgumentByName(name)
+	if err != nil {
+		return 0, err
+	}
+	return arg.GetUint()
+}
+
+// SetArgumentTime sets a date or time value into the specified argument.
+func (action *Action) SetArgumentTime(name string, value time.Time) error {
+	arg, err := action.GetArgumentByName(name)
+	if err != nil {
+		return err
+	}
+	return arg.SetTime(value)
+}
+
+// GetArgumentTime return a date or time value into the specified argument.
+func (action *Action) GetArgumentTime(name string) (time.Time, error) {
+	arg, err := action.GetArgumentByName(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return arg.GetTime()
+}
+
+// SetArgumentBytes sets a binary value into the specified argument.
+func (action *Action) SetArgumentBytes(name string, value []byte) error {
+	arg, err := action.GetArgumentByName(name)
+	if err != nil {
+		return err
+	}
+	return arg.SetBytes(value)
+}
+
+// GetArgumentBytes return a binary value into the specified argument.
+func (action *Action) GetArgumentBytes(name string) ([]byte, error) {
+	arg, err := action.GetArgumentByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return arg.GetBytes()
+}
+
 // setArgumentsByActionControl sets control arguments into the specified argument.
 func (action *Action) setArgumentsByActionControl(actionCtrl *control.ActionControl) error {
 	ctrlAction, err := actionCtrl.GetAction()
@@ -192,6 +265,44 @@ func (action *Action) setArgumentsByActionControl(actionCtrl *control.ActionCont
 	return nil
 }
 
+// validateActionRequest returns a UPnP error when the arguments of the specified request don't match the in-arguments of the action
+// in the count and the order, or the values are not valid for the related state variables.
+func (action *Action) validateActionRequest(actionReq *control.ActionRequest, maxLength int) Error {
+	ctrlAction, err := actionReq.GetAction()
+	if err != nil || ctrlAction.Name != action.Name {
+		return NewErrorFromCode(ErrorInvalidAction)
+	}
+
+	inArgs := action.GetInputArguments()
+	if len(ctrlAction.Arguments) != len(inArgs) {
+		return NewErrorFromCode(ErrorInvalidArgs)
+	}
+
+	for n, inArg := range inArgs {
+		ctrlArg := ctrlAction.Arguments[n]
+		if ctrlArg.Name != inArg.Name {
+			return NewErrorFromCode(ErrorInvalidArgs)
+		}
+
+		if 0 < maxLength && maxLength < len(ctrlArg.Value) {
+			return NewErrorFromCode(ErrorStringArgumentTooLong)
+		}
+
+		if action.ParentService == nil {
+			continue
+		}
+		statVar, err := action.ParentService.GetStateVariableByName(inArg.RelatedStateVariable)
+		if err != nil {
+			continue
+		}
+		if upnpErr := statVar.ValidateValue(ctrlArg.Value); upnpErr != nil {
+			return upnpErr
+		}
+	}
+
+	return nil
+}
+
 // SetArgumentsByActionRequest sets request arguments into the specified argument.
 func (action *Action) SetArgumentsByActionRequest(actionReq *control.ActionRequest) error {
 	return action.setArgumentsByActionControl(actionReq.ActionControl)
@@ -202,46 +313,87 @@ func (action *Action) SetArgumentsByActionResponse(actionRes *control.ActionResp
 	return action.setArgumentsByActionControl(actionRes.ActionControl)
 }
 
-// Post sends the specified arguments into the deveice.
+// Post sends the specified arguments into the deveice, and sets the response arguments into the action.
+// Post is not safe for concurrent use because the arguments of the action are shared, use Invoke or Copy instead.
 func (action *Action) Post() error {
-	// post response
+	return action.PostContext(context.Background())
+}
 
+// PostContext sends the specified arguments into the deveice with the specified context, and sets the response arguments into the action.
+func (action *Action) PostContext(ctx context.Context) error {
 	req, err := NewActionRequestFromAction(action)
 	if err != nil {
 		return err
 	}
 
-	soapReqStr, err := req.SOAPContentString()
-	if err != nil {
-		return err
-	}
-
 	service := action.ParentService
 	if service == nil {
 		return fmt.Errorf(errorActionHasNoParentService, action.Name)
 	}
 
-	controlAbsURL, err := service.GetAbsoluteControlURL()
+	actionRes, err := postControlRequest(ctx, service, req)
 	if err != nil {
 		return err
 	}
 
-	soapAction := service.ServiceType + http.SOAPActionDelim + action.Name
-	httpReq, err := http.NewSOAPRequest(controlAbsURL, soapAction, strings.NewReader(soapReqStr))
+	return action.SetArgumentsByActionResponse(actionRes)
+}
+
+// Invoke posts the action with the specified input arguments, and returns the output arguments.
+// Invoke is safe for concurrent use because it posts a copy of the action, and the action is not changed.
+func (action *Action) Invoke(ctx context.Context, args map[string]string) (map[string]string, error) {
+	invokeAction := action.Copy()
+	for name, value := range args {
+		arg, err := invokeAction.GetArgumentByName(name)
+		if err != nil {
+			return nil, err
+		}
+		if !arg.IsInDirection() {
+			return nil, fmt.Errorf(errorActionArgumentNotInput, name)
+		}
+		arg.Value = value
+	}
+
+	err := invokeAction.PostContext(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	log.Tracef("action req = \n%s", soapReqStr)
+	outArgs := map[string]string{}
+	for _, arg := range invokeAction.GetOutputArguments() {
+		outArgs[arg.Name] = arg.Value
+	}
+
+	return outArgs, nil
+}
+
+// postControlRequest posts the specified SOAP request to the control URL of the service, and returns the response.
+// A UPnP error is returned when the device returns an error response.
+func postControlRequest(ctx context.Context, service *Service, req *control.ActionRequest) (*control.ActionResponse, error) {
+	// post request
 
-	httpClient, err := http.NewClient()
+	soapReqStr, err := req.SOAPContentString()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	httpRes, err := httpClient.Do(httpReq)
+	controlAbsURL, err := service.GetAbsoluteControlURL()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	reqAction := &req.Envelope.Body.Action
+	soapAction := reqAction.ServiceType + http.SOAPActionDelim + reqAction.Name
+	httpReq, err := http.NewSOAPRequestWithContext(ctx, controlAbsURL, soapAction, strings.NewReader(soapReqStr))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Tracef("action req = \n%s", soapReqStr)
+
+	httpRes, err := service.getHTTPClient().Do(httpReq)
+	if err != nil {
+		return nil, err
 	}
 
 	// read response
@@ -250,29 +402,20 @@ func (action *Action) Post() error {
 	defer httpRes.Body.Close()
 	soapResBytes, err := io.ReadAll(httpRes.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	log.Tracef("action res [%d] = \n%s", statusCode, string(soapResBytes))
 
 	// parse response
 
-	if statusCode == http.StatusOK {
-		actionRes, err := control.NewActionResponseFromSOAPBytes(soapResBytes)
-		if err != nil {
-			return err
-		}
-		err = action.SetArgumentsByActionResponse(actionRes)
-		if err != nil {
-			return err
-		}
-	} else {
+	if statusCode != http.StatusOK {
 		upnpErrRes, err := control.NewErrorResponseFromSOAPBytes(soapResBytes)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		return &upnpErrRes.Envelope.Body.Fault.Detail.UPnPError
+		return nil, &upnpErrRes.Envelope.Body.Fault.Detail.UPnPError
 	}
 
-	return nil
+	return control.NewActionResponseFromSOAPBytes(soapResBytes)
 }
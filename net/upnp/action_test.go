@@ -5,9 +5,228 @@
 package upnp
 
 import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/control"
+)
+
+const (
+	errorActionInvalidValidation = "validation of %s (%s) = %d : expected %d"
+	errorActionInvalidValue      = "action (%s) argument (%s) = '%s' : expected '%s'"
 )
 
 func TestNewAction(t *testing.T) {
 	NewAction()
 }
+
+func TestActionValidateActionRequest(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	action, err := dev.GetSwitchPowerSetTargetAction()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newArg := func(name string, value string) *control.Argument {
+		arg := control.NewArgument()
+		arg.Name = name
+		arg.Value = value
+		return arg
+	}
+
+	const maxLength = 16
+
+	tests := []struct {
+		name         string
+		args         []*control.Argument
+		expectedCode int
+	}{
+		{"valid", []*control.Argument{newArg(NewTargetValue, "1")}, 0},
+		{"valid yes", []*control.Argument{newArg(NewTargetValue, "yes")}, 0},
+		{"no argument", []*control.Argument{}, ErrorInvalidArgs},
+		{"unknown argument", []*control.Argument{newArg("Unknown", "1")}, ErrorInvalidArgs},
+		{"extra argument", []*control.Argument{newArg(NewTargetValue, "1"), newArg("Unknown", "1")}, ErrorInvalidArgs},
+		{"invalid value", []*control.Argument{newArg(NewTargetValue, "2")}, ErrorArgumentValueInvalid},
+		{"too long value", []*control.Argument{newArg(NewTargetValue, strings.Repeat("1", maxLength+1))}, ErrorStringArgumentTooLong},
+	}
+
+	for _, test := range tests {
+		actionReq, err := NewActionRequestFromAction(action)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actionReq.Envelope.Body.Action.Arguments = test.args
+
+		upnpErr := action.validateActionRequest(actionReq, maxLength)
+		code := 0
+		if upnpErr != nil {
+			code = upnpErr.GetCode()
+		}
+		if code != test.expectedCode {
+			t.Errorf(errorActionInvalidValidation, action.Name, test.name, code, test.expectedCode)
+		}
+	}
+}
+
+const echoServiceDescription = xml.Header +
+	"<scpd>" +
+	"  <serviceStateTable>" +
+	"    <stateVariable sendEvents=\"no\">" +
+	"      <name>Value</name>" +
+	"      <dataType>string</dataType>" +
+	"    </stateVariable>" +
+	"  </serviceStateTable>" +
+	"  <actionList>" +
+	"    <action>" +
+	"    <name>Echo</name>" +
+	"      <argumentList>" +
+	"        <argument>" +
+	"          <name>Value</name>" +
+	"          <direction>in</direction>" +
+	"          <relatedStateVariable>Value</relatedStateVariable>" +
+	"        </argument>" +
+	"        <argument>" +
+	"          <name>Result</name>" +
+	"          <direction>out</direction>" +
+	"          <relatedStateVariable>Value</relatedStateVariable>" +
+	"        </argument>" +
+	"      </argumentList>" +
+	"    </action>" +
+	"  </actionList>" +
+	"</scpd>"
+
+func TestActionCopy(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	action, err := dev.GetSwitchPowerSetTargetAction()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	copyAction := action.Copy()
+	copyAction.SetArgumentBool(NewTargetValue, true)
+
+	if v, _ := copyAction.GetArgumentString(NewTargetValue); v != "1" {
+		t.Errorf(errorActionInvalidValue, copyAction.Name, NewTargetValue, v, "1")
+	}
+	if v, _ := action.GetArgumentString(NewTargetValue); v != "" {
+		t.Errorf(errorActionInvalidValue, action.Name, NewTargetValue, v, "")
+	}
+
+	for _, arg := range copyAction.GetArguments() {
+		if arg.ParentAction != copyAction {
+			t.Errorf(errorTestDeviceInvalidParentObject, arg, arg.ParentAction, copyAction)
+		}
+	}
+	if copyAction.ParentService != action.ParentService {
+		t.Errorf(errorTestDeviceInvalidParentObject, copyAction, copyAction.ParentService, action.ParentService)
+	}
+}
+
+func TestActionInvokeConcurrently(t *testing.T) {
+	dev, err := NewDeviceFromDescription(binaryLightDeviceDescription)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, err := dev.GetServiceByType("urn:schemas-upnp-org:service:SwitchPower:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = service.LoadDescriptionBytes([]byte(echoServiceDescription))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dev.SetActionHandler(service.ServiceType, "Echo", ActionHandlerFunc(func(req *ActionRequest) Error {
+		value, err := req.Action.GetArgumentString("Value")
+		if err != nil {
+			return NewErrorFromCode(ErrorActionFailed)
+		}
+		if err := req.Action.SetArgumentString("Result", value); err != nil {
+			return NewErrorFromCode(ErrorActionFailed)
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err := dev.Stop()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	cpDev, err := NewTestControlPointDevice(dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cpService, err := cpDev.GetServiceByType(service.ServiceType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	action, err := cpService.GetActionByName("Echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// invoke the same action concurrently
+
+	var wg sync.WaitGroup
+	for n := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value := fmt.Sprintf("value-%d", n)
+			outArgs, err := action.Invoke(context.Background(), map[string]string{"Value": value})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if outArgs["Result"] != value {
+				t.Errorf(errorActionInvalidValue, action.Name, "Result", outArgs["Result"], value)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the shared actions are not changed
+
+	for _, sharedAction := range []*Action{action, &service.ActionList.Actions[0]} {
+		for _, arg := range sharedAction.GetArguments() {
+			if 0 < len(arg.Value) {
+				t.Errorf(errorActionInvalidValue, sharedAction.Name, arg.Name, arg.Value, "")
+			}
+		}
+	}
+
+	// invalid arguments
+
+	if _, err := action.Invoke(context.Background(), map[string]string{"Unknown": ""}); err == nil {
+		t.Errorf(errorActionInvalidValue, action.Name, "Unknown", "", "error")
+	}
+	if _, err := action.Invoke(context.Background(), map[string]string{"Result": ""}); err == nil {
+		t.Errorf(errorActionInvalidValue, action.Name, "Result", "", "error")
+	}
+}
@@ -6,6 +6,7 @@ package upnp
 
 import (
 	"encoding/xml"
+	"strings"
 )
 
 // A AllowedValue represents a UPnP allowed value.
@@ -25,3 +26,16 @@ func NewAllowedValue() *AllowedValue {
 	value := &AllowedValue{}
 	return value
 }
+
+// IsAllowed returns true when the specified value is in the list or the list is empty, otherwise false.
+func (valList *AllowedValueList) IsAllowed(value string) bool {
+	if len(valList.AllowedValues) == 0 {
+		return true
+	}
+	for _, allowedValue := range valList.AllowedValues {
+		if strings.TrimSpace(allowedValue.Value) == value {
+			return true
+		}
+	}
+	return false
+}
@@ -6,6 +6,13 @@ package upnp
 
 import (
 	"encoding/xml"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	allowedValueRangeStepEpsilon = 1e-9
 )
 
 // A AllowedValueRange represents a icon.
@@ -24,3 +31,43 @@ func NewAllowedValueRange() *AllowedValueRange {
 	valRange := &AllowedValueRange{}
 	return valRange
 }
+
+// parseRangeValue returns the float value of the specified field, and false when the field is blank or invalid.
+func parseRangeValue(field string) (float64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+// IsInRange returns true when the specified value is between the minimum and the maximum, and on the step from the minimum.
+// The blank fields are not checked.
+func (valRange *AllowedValueRange) IsInRange(value string) bool {
+	minValue, hasMin := parseRangeValue(valRange.Minimum)
+	maxValue, hasMax := parseRangeValue(valRange.Maximum)
+	step, hasStep := parseRangeValue(valRange.Step)
+	if !hasMin && !hasMax && !hasStep {
+		return true
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false
+	}
+
+	if hasMin && f < minValue {
+		return false
+	}
+	if hasMax && maxValue < f {
+		return false
+	}
+	if hasMin && hasStep && 0 < step {
+		steps := (f - minValue) / step
+		if allowedValueRangeStepEpsilon < math.Abs(steps-math.Round(steps)) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -7,7 +7,13 @@ package upnp
 import (
 	"encoding/xml"
 	"fmt"
-	"strconv"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/datatype"
+)
+
+const (
+	errorArgumentStateVariableNotFound = "related state variable (%s) is not found"
 )
 
 // A Argument represents a UPnP argument.
@@ -44,9 +50,30 @@ func (arg *Argument) GetString() (string, error) {
 	return arg.Value, nil
 }
 
+// GetStateVariable returns the related state variable of the argument.
+func (arg *Argument) GetStateVariable() (*StateVariable, error) {
+	if arg.ParentAction == nil || arg.ParentAction.ParentService == nil {
+		return nil, fmt.Errorf(errorArgumentStateVariableNotFound, arg.RelatedStateVariable)
+	}
+	return arg.ParentAction.ParentService.GetStateVariableByName(arg.RelatedStateVariable)
+}
+
+// GetDataType returns the data type of the related state variable, or an empty string when the state variable is not found.
+func (arg *Argument) GetDataType() string {
+	statVar, err := arg.GetStateVariable()
+	if err != nil {
+		return ""
+	}
+	return statVar.DataType
+}
+
 // SetInt sets a integer value into the specified argument.
 func (arg *Argument) SetInt(value int) error {
-	return arg.SetString(strconv.Itoa(value))
+	str, err := datatype.FormatInt(arg.GetDataType(), int64(value))
+	if err != nil {
+		return err
+	}
+	return arg.SetString(str)
 }
 
 // GetInt return a integer value into the specified argument.
@@ -55,34 +82,52 @@ func (arg *Argument) GetInt() (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	return strconv.Atoi(value)
+	i, err := datatype.ParseInt(arg.GetDataType(), value)
+	if err != nil {
+		return 0, err
+	}
+	return int(i), nil
 }
 
-// SetFloat sets a integer value into the specified argument.
-func (arg *Argument) SetFloat(value float64) error {
-	return arg.SetString(fmt.Sprintf("%f", value))
+// SetUint sets a unsigned integer value into the specified argument.
+func (arg *Argument) SetUint(value uint64) error {
+	str, err := datatype.FormatUint(arg.GetDataType(), value)
+	if err != nil {
+		return err
+	}
+	return arg.SetString(str)
 }
 
-// GetFloat return a integer value into the specified argument.
-func (arg *Argument) GetFloat() (float64, error) {
+// GetUint return a unsigned integer value into the specified argument.
+func (arg *Argument) GetUint() (uint64, error) {
 	value, err := arg.GetString()
 	if err != nil {
 		return 0, err
 	}
-	f, err := strconv.ParseFloat(value, 64)
+	return datatype.ParseUint(arg.GetDataType(), value)
+}
+
+// SetFloat sets a float value into the specified argument.
+func (arg *Argument) SetFloat(value float64) error {
+	str, err := datatype.FormatFloat(arg.GetDataType(), value)
+	if err != nil {
+		return err
+	}
+	return arg.SetString(str)
+}
+
+// GetFloat return a float value into the specified argument.
+func (arg *Argument) GetFloat() (float64, error) {
+	value, err := arg.GetString()
 	if err != nil {
 		return 0, err
 	}
-	return f, nil
+	return datatype.ParseFloat(arg.GetDataType(), value)
 }
 
 // SetBool sets a boolean value into the specified argument.
 func (arg *Argument) SetBool(value bool) error {
-	ivalue := 0
-	if value {
-		ivalue = 1
-	}
-	return arg.SetInt(ivalue)
+	return arg.SetString(datatype.FormatBool(value))
 }
 
 // GetBool return a boolean value into the specified argument.
@@ -91,11 +136,43 @@ func (arg *Argument) GetBool() (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	b, err := strconv.ParseBool(value)
+	return datatype.ParseBool(value)
+}
+
+// SetTime sets a date or time value into the specified argument.
+func (arg *Argument) SetTime(value time.Time) error {
+	str, err := datatype.FormatTime(arg.GetDataType(), value)
 	if err != nil {
-		return false, err
+		return err
+	}
+	return arg.SetString(str)
+}
+
+// GetTime return a date or time value into the specified argument.
+func (arg *Argument) GetTime() (time.Time, error) {
+	value, err := arg.GetString()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return datatype.ParseTime(arg.GetDataType(), value)
+}
+
+// SetBytes sets a binary value into the specified argument.
+func (arg *Argument) SetBytes(value []byte) error {
+	str, err := datatype.FormatBytes(arg.GetDataType(), value)
+	if err != nil {
+		return err
+	}
+	return arg.SetString(str)
+}
+
+// GetBytes return a binary value into the specified argument.
+func (arg *Argument) GetBytes() ([]byte, error) {
+	value, err := arg.GetString()
+	if err != nil {
+		return nil, err
 	}
-	return b, nil
+	return datatype.ParseBytes(arg.GetDataType(), value)
 }
 
 // isDirection returns true when the argument direction equals the specified value, otherwise false.
@@ -8,6 +8,62 @@ import (
 	"testing"
 )
 
+const (
+	errorArgumentInvalidTypedValue = "argument (%s) value = %v : expected %v"
+	errorArgumentTypedValueSet     = "argument (%s) value (%v) is set : expected error"
+)
+
 func TestNewArgument(t *testing.T) {
 	NewArgument()
 }
+
+func TestArgumentTypedValues(t *testing.T) {
+	service := NewService()
+	service.ServiceStateTable.StateVariables = []StateVariable{
+		{Name: "A_ARG_TYPE_UI1", DataType: "ui1"},
+		{Name: "A_ARG_TYPE_BOOLEAN", DataType: "boolean"},
+		{Name: "A_ARG_TYPE_R4", DataType: "r4"},
+	}
+	action := NewAction()
+	action.ParentService = service
+	action.ArgumentList.Arguments = []Argument{
+		{Name: "Byte", RelatedStateVariable: "A_ARG_TYPE_UI1"},
+		{Name: "Flag", RelatedStateVariable: "A_ARG_TYPE_BOOLEAN"},
+		{Name: "Ratio", RelatedStateVariable: "A_ARG_TYPE_R4"},
+	}
+	action.reviseParentObject()
+
+	// integer
+
+	arg := &action.ArgumentList.Arguments[0]
+	if arg.GetDataType() != "ui1" {
+		t.Errorf(errorArgumentInvalidTypedValue, arg.Name, arg.GetDataType(), "ui1")
+	}
+	if err := arg.SetInt(255); err != nil {
+		t.Error(err)
+	}
+	if v, err := arg.GetInt(); err != nil || v != 255 {
+		t.Errorf(errorArgumentInvalidTypedValue, arg.Name, v, 255)
+	}
+	if err := arg.SetInt(256); err == nil {
+		t.Errorf(errorArgumentTypedValueSet, arg.Name, 256)
+	}
+
+	// boolean
+
+	arg = &action.ArgumentList.Arguments[1]
+	if err := arg.SetBool(true); err != nil || arg.Value != "1" {
+		t.Errorf(errorArgumentInvalidTypedValue, arg.Name, arg.Value, "1")
+	}
+	arg.Value = "no"
+	if v, err := arg.GetBool(); err != nil || v {
+		t.Errorf(errorArgumentInvalidTypedValue, arg.Name, v, false)
+	}
+
+	// float
+
+	arg = &action.ArgumentList.Arguments[2]
+	if err := arg.SetFloat(0.5); err != nil || arg.Value != "0.5" {
+		t.Errorf(errorArgumentInvalidTypedValue, arg.Name, arg.Value, "0.5")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"text/template"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp"
+)
+
+const (
+	DefaultPackage = "main"
+)
+
+const (
+	errorGeneratorNoServices      = "no services to generate"
+	errorGeneratorBadPackage      = "package name (%s) is invalid"
+	errorGeneratorServiceNoAction = "service (%s) has no actions. Load the service description at first"
+	errorGeneratorDuplicateName   = "%s and %s have the same name (%s). Generate them into the different packages"
+)
+
+// A Generator represents a Go code generator for UPnP services.
+type Generator struct {
+	// Package is the package name of the generated code.
+	Package string
+}
+
+// NewGenerator returns a new Generator.
+func NewGenerator() *Generator {
+	gen := &Generator{
+		Package: DefaultPackage,
+	}
+	return gen
+}
+
+// collectDevices appends the specified device and the embedded devices into the list without duplicated device types.
+func collectDevices(dev *upnp.Device, devs []*upnp.Device) []*upnp.Device {
+	for _, d := range devs {
+		if d.DeviceType == dev.DeviceType {
+			return devs
+		}
+	}
+	devs = append(devs, dev)
+	for _, embeddedDev := range dev.GetEmbeddedDevices() {
+		devs = collectDevices(embeddedDev, devs)
+	}
+	return devs
+}
+
+// GenerateDevice returns the generated code for the device types and the services of the specified device and the embedded devices.
+// The services which have the same service type are generated once.
+func (gen *Generator) GenerateDevice(dev *upnp.Device) ([]byte, error) {
+	devs := collectDevices(dev, []*upnp.Device{})
+
+	services := []*upnp.Service{}
+	serviceTypes := map[string]bool{}
+	for _, d := range devs {
+		for _, service := range d.GetServices() {
+			if serviceTypes[service.ServiceType] {
+				continue
+			}
+			serviceTypes[service.ServiceType] = true
+			services = append(services, service)
+		}
+	}
+
+	return gen.generate(devs, services)
+}
+
+// GenerateServices returns the generated code for the specified services which have the loaded service descriptions.
+func (gen *Generator) GenerateServices(services ...*upnp.Service) ([]byte, error) {
+	return gen.generate([]*upnp.Device{}, services)
+}
+
+func (gen *Generator) generate(devs []*upnp.Device, services []*upnp.Service) ([]byte, error) {
+	if len(services) == 0 {
+		return nil, errors.New(errorGeneratorNoServices)
+	}
+
+	pkg := gen.Package
+	if len(pkg) == 0 {
+		pkg = DefaultPackage
+	}
+	if toIdentifier(pkg) != pkg {
+		return nil, fmt.Errorf(errorGeneratorBadPackage, pkg)
+	}
+
+	file := &fileModel{
+		Package:  pkg,
+		Devices:  []*deviceModel{},
+		Services: []*serviceModel{},
+	}
+
+	// The names are generated from the short types without the versions, so the types which have
+	// the same short type, such as the different versions of a service type, are rejected.
+
+	deviceTypes := map[string]string{}
+	for _, dev := range devs {
+		if len(dev.DeviceType) == 0 {
+			continue
+		}
+		deviceModel := newDeviceModel(dev)
+		if deviceType, ok := deviceTypes[deviceModel.ConstName]; ok {
+			return nil, fmt.Errorf(errorGeneratorDuplicateName, deviceType, dev.DeviceType, deviceModel.ConstName)
+		}
+		deviceTypes[deviceModel.ConstName] = dev.DeviceType
+		file.Devices = append(file.Devices, deviceModel)
+	}
+
+	serviceTypes := map[string]string{}
+	for _, service := range services {
+		if len(service.GetActions()) == 0 {
+			return nil, fmt.Errorf(errorGeneratorServiceNoAction, service.ServiceType)
+		}
+		serviceModel, err := newServiceModel(service)
+		if err != nil {
+			return nil, err
+		}
+		if serviceType, ok := serviceTypes[serviceModel.Name]; ok {
+			return nil, fmt.Errorf(errorGeneratorDuplicateName, serviceType, service.ServiceType, serviceModel.Name)
+		}
+		serviceTypes[serviceModel.Name] = service.ServiceType
+		file.Services = append(file.Services, serviceModel)
+	}
+
+	tmpl, err := template.New("").Parse(fileTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
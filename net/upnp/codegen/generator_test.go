@@ -0,0 +1,223 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp"
+)
+
+const (
+	errorGeneratorCodeNotFound = "generated code doesn't have '%s'"
+	errorGeneratorAccepted     = "generator accepted %s : expected error"
+)
+
+const testDeviceDescription = "" +
+	"<?xml version=\"1.0\"?>\n" +
+	"<root xmlns=\"urn:schemas-upnp-org:device-1-0\">\n" +
+	"  <specVersion><major>1</major><minor>0</minor></specVersion>\n" +
+	"  <device>\n" +
+	"    <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>\n" +
+	"    <deviceList>\n" +
+	"      <device>\n" +
+	"        <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>\n" +
+	"        <serviceList>\n" +
+	"          <service>\n" +
+	"            <serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>\n" +
+	"            <serviceId>urn:upnp-org:serviceId:WANIPConn1</serviceId>\n" +
+	"            <SCPDURL>/WANIPConnection.xml</SCPDURL>\n" +
+	"            <controlURL>/WANIPConnection/control</controlURL>\n" +
+	"            <eventSubURL>/WANIPConnection/event</eventSubURL>\n" +
+	"          </service>\n" +
+	"        </serviceList>\n" +
+	"      </device>\n" +
+	"    </deviceList>\n" +
+	"  </device>\n" +
+	"</root>\n"
+
+const testServiceDescription = "" +
+	"<?xml version=\"1.0\"?>\n" +
+	"<scpd xmlns=\"urn:schemas-upnp-org:service-1-0\">\n" +
+	"  <specVersion><major>1</major><minor>0</minor></specVersion>\n" +
+	"  <actionList>\n" +
+	"    <action>\n" +
+	"      <name>GetExternalIPAddress</name>\n" +
+	"      <argumentList>\n" +
+	"        <argument><name>NewExternalIPAddress</name><direction>out</direction><relatedStateVariable>ExternalIPAddress</relatedStateVariable></argument>\n" +
+	"      </argumentList>\n" +
+	"    </action>\n" +
+	"    <action>\n" +
+	"      <name>GetGenericPortMappingEntry</name>\n" +
+	"      <argumentList>\n" +
+	"        <argument><name>NewPortMappingIndex</name><direction>in</direction><relatedStateVariable>PortMappingNumberOfEntries</relatedStateVariable></argument>\n" +
+	"        <argument><name>NewExternalPort</name><direction>out</direction><relatedStateVariable>ExternalPort</relatedStateVariable></argument>\n" +
+	"        <argument><name>NewEnabled</name><direction>out</direction><relatedStateVariable>PortMappingEnabled</relatedStateVariable></argument>\n" +
+	"        <argument><name>NewLeaseDuration</name><direction>out</direction><relatedStateVariable>PortMappingLeaseDuration</relatedStateVariable></argument>\n" +
+	"      </argumentList>\n" +
+	"    </action>\n" +
+	"    <action>\n" +
+	"      <name>SetConnectionType</name>\n" +
+	"      <argumentList>\n" +
+	"        <argument><name>NewConnectionType</name><direction>in</direction><relatedStateVariable>ConnectionType</relatedStateVariable></argument>\n" +
+	"        <argument><name>NewUpdated</name><direction>in</direction><relatedStateVariable>LastUpdated</relatedStateVariable></argument>\n" +
+	"      </argumentList>\n" +
+	"    </action>\n" +
+	"    <action>\n" +
+	"      <name>GetUptime</name>\n" +
+	"      <argumentList>\n" +
+	"        <argument><name>NewUptime</name><direction>out</direction><relatedStateVariable>Uptime</relatedStateVariable></argument>\n" +
+	"      </argumentList>\n" +
+	"    </action>\n" +
+	"  </actionList>\n" +
+	"  <serviceStateTable>\n" +
+	"    <stateVariable sendEvents=\"no\"><name>ConnectionType</name><dataType>string</dataType></stateVariable>\n" +
+	"    <stateVariable sendEvents=\"no\"><name>LastUpdated</name><dataType>dateTime</dataType></stateVariable>\n" +
+	"    <stateVariable sendEvents=\"no\"><name>Uptime</name><dataType>ui4</dataType></stateVariable>\n" +
+	"    <stateVariable sendEvents=\"no\"><name>ExternalIPAddress</name><dataType>string</dataType></stateVariable>\n" +
+	"    <stateVariable sendEvents=\"yes\"><name>PortMappingNumberOfEntries</name><dataType>ui2</dataType></stateVariable>\n" +
+	"    <stateVariable sendEvents=\"no\"><name>ExternalPort</name><dataType>ui2</dataType></stateVariable>\n" +
+	"    <stateVariable sendEvents=\"no\"><name>PortMappingEnabled</name><dataType>boolean</dataType></stateVariable>\n" +
+	"    <stateVariable sendEvents=\"no\"><name>PortMappingLeaseDuration</name><dataType>ui4</dataType></stateVariable>\n" +
+	"  </serviceStateTable>\n" +
+	"</scpd>\n"
+
+func newTestDevice(t *testing.T) *upnp.Device {
+	t.Helper()
+
+	dev, err := upnp.NewDeviceFromDescription(testDeviceDescription)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conDev := dev.GetEmbeddedDevices()[0]
+	service, err := conDev.GetServiceByType("urn:schemas-upnp-org:service:WANIPConnection:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = service.LoadDescriptionBytes([]byte(testServiceDescription))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dev
+}
+
+func TestGenerateDevice(t *testing.T) {
+	dev := newTestDevice(t)
+
+	gen := NewGenerator()
+	gen.Package = "igd"
+	code, err := gen.GenerateDevice(dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the generated code is type-checked with the upnp package
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "igd.go", code, parser.AllErrors)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check(gen.Package, fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedCodes := []string{
+		"package igd",
+		"\"time\"",
+		"InternetGatewayDeviceDeviceType = \"urn:schemas-upnp-org:device:InternetGatewayDevice:1\"",
+		"WANConnectionDeviceDeviceType",
+		"WANIPConnectionServiceType",
+		"WANIPConnectionGetExternalIPAddressAction = \"GetExternalIPAddress\"",
+		"func (client *WANIPConnectionClient) GetExternalIPAddress(ctx context.Context) (newExternalIPAddress string, err error)",
+		"func (client *WANIPConnectionClient) GetGenericPortMappingEntry(ctx context.Context, newPortMappingIndex uint16) (res *WANIPConnectionGetGenericPortMappingEntryResult, err error)",
+		"NewExternalPort uint16 `upnp:\"NewExternalPort\"`",
+		"func (client *WANIPConnectionClient) SetConnectionType(ctx context.Context, newConnectionType string, newUpdated time.Time) (err error)",
+		"func (client *WANIPConnectionClient) GetUptime(ctx context.Context) (newUptime uint32, err error)",
+		"GetGenericPortMappingEntry(ctx context.Context, newPortMappingIndex uint16) (*WANIPConnectionGetGenericPortMappingEntryResult, error)",
+		"SetConnectionType(ctx context.Context, newConnectionType string, newUpdated time.Time) error",
+		"func BindWANIPConnectionService(dev *upnp.Device, impl WANIPConnectionService) error",
+	}
+	normalize := func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	for _, expectedCode := range expectedCodes {
+		if !strings.Contains(normalize(string(code)), normalize(expectedCode)) {
+			t.Errorf(errorGeneratorCodeNotFound, expectedCode)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	gen := NewGenerator()
+
+	if _, err := gen.GenerateServices(); err == nil {
+		t.Errorf(errorGeneratorAccepted, "no services")
+	}
+
+	dev, err := upnp.NewDeviceFromDescription(testDeviceDescription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gen.GenerateDevice(dev); err == nil {
+		t.Errorf(errorGeneratorAccepted, "services without descriptions")
+	}
+
+	gen.Package = "bad-package"
+	if _, err := gen.GenerateDevice(newTestDevice(t)); err == nil {
+		t.Errorf(errorGeneratorAccepted, gen.Package)
+	}
+	gen.Package = DefaultPackage
+
+	// duplicate names
+
+	services := []*upnp.Service{}
+	for _, serviceType := range []string{"urn:schemas-upnp-org:service:WANIPConnection:1", "urn:schemas-upnp-org:service:WANIPConnection:2"} {
+		service := newTestDevice(t).GetEmbeddedDevices()[0].GetServices()[0]
+		service.ServiceType = serviceType
+		services = append(services, service)
+	}
+	if _, err := gen.GenerateServices(services...); err == nil {
+		t.Errorf(errorGeneratorAccepted, "services of the same short type")
+	}
+
+	dupDev := newTestDevice(t)
+	dupDev.GetEmbeddedDevices()[0].DeviceType = "urn:schemas-upnp-org:device:InternetGatewayDevice:2"
+	if _, err := gen.GenerateDevice(dupDev); err == nil {
+		t.Errorf(errorGeneratorAccepted, "devices of the same short type")
+	}
+}
+
+func TestNames(t *testing.T) {
+	names := map[string][2]string{
+		"NewExternalIPAddress":  {"NewExternalIPAddress", "newExternalIPAddress"},
+		"newTargetValue":        {"NewTargetValue", "newTargetValue"},
+		"URLBase":               {"URLBase", "urlBase"},
+		"ID":                    {"ID", "id"},
+		"Type":                  {"Type", "typeValue"},
+		"A_ARG_TYPE_InstanceID": {"AARGTYPEInstanceID", "aargtypeInstanceID"},
+		"2ndValue":              {"X2ndValue", "x2ndValue"},
+	}
+	for name, expected := range names {
+		if v := toExportedName(name); v != expected[0] {
+			t.Errorf(errorGeneratorCodeNotFound, expected[0]+" != "+v)
+		}
+		if v := toLocalName(name); v != expected[1] {
+			t.Errorf(errorGeneratorCodeNotFound, expected[1]+" != "+v)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp"
+)
+
+const (
+	errorArgumentStateVariableNotFound = "action (%s) argument (%s) : %w"
+)
+
+// A argumentModel represents an argument in the generated code.
+type argumentModel struct {
+	Name      string
+	LocalName string
+	FieldName string
+	Type      goType
+}
+
+// A actionModel represents an action in the generated code.
+type actionModel struct {
+	Name       string
+	MethodName string
+	ConstName  string
+	ResultName string
+	InArgs     []*argumentModel
+	OutArgs    []*argumentModel
+}
+
+// HasResultStruct returns true when the action returns the out-arguments as a struct.
+func (action *actionModel) HasResultStruct() bool {
+	return 1 < len(action.OutArgs)
+}
+
+// A serviceModel represents a service in the generated code.
+type serviceModel struct {
+	ServiceType string
+	Name        string
+	ConstName   string
+	Actions     []*actionModel
+}
+
+// A deviceModel represents a device type in the generated code.
+type deviceModel struct {
+	DeviceType string
+	ConstName  string
+}
+
+// A fileModel represents a generated file.
+type fileModel struct {
+	Package  string
+	Devices  []*deviceModel
+	Services []*serviceModel
+}
+
+// UsesTime returns true when the generated code uses the time package.
+func (file *fileModel) UsesTime() bool {
+	for _, service := range file.Services {
+		for _, action := range service.Actions {
+			for _, arg := range append(action.InArgs, action.OutArgs...) {
+				if arg.Type.Name == "time.Time" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func newArgumentModel(action *upnp.Action, arg *upnp.Argument) (*argumentModel, error) {
+	statVar, err := arg.GetStateVariable()
+	if err != nil {
+		return nil, fmt.Errorf(errorArgumentStateVariableNotFound, action.Name, arg.Name, err)
+	}
+	return &argumentModel{
+		Name:      arg.Name,
+		LocalName: toLocalName(arg.Name),
+		FieldName: toExportedName(arg.Name),
+		Type:      getGoType(statVar.DataType),
+	}, nil
+}
+
+func newActionModel(serviceName string, action *upnp.Action) (*actionModel, error) {
+	methodName := toExportedName(action.Name)
+	model := &actionModel{
+		Name:       action.Name,
+		MethodName: methodName,
+		ConstName:  serviceName + methodName + "Action",
+		ResultName: serviceName + methodName + "Result",
+		InArgs:     []*argumentModel{},
+		OutArgs:    []*argumentModel{},
+	}
+
+	for _, arg := range action.GetInputArguments() {
+		argModel, err := newArgumentModel(action, arg)
+		if err != nil {
+			return nil, err
+		}
+		model.InArgs = append(model.InArgs, argModel)
+	}
+
+	for _, arg := range action.GetOutputArguments() {
+		argModel, err := newArgumentModel(action, arg)
+		if err != nil {
+			return nil, err
+		}
+		model.OutArgs = append(model.OutArgs, argModel)
+	}
+
+	return model, nil
+}
+
+func newServiceModel(service *upnp.Service) (*serviceModel, error) {
+	name := toExportedName(getShortType(service.ServiceType))
+	model := &serviceModel{
+		ServiceType: service.ServiceType,
+		Name:        name,
+		ConstName:   name + "ServiceType",
+		Actions:     []*actionModel{},
+	}
+
+	for _, action := range service.GetActions() {
+		actionModel, err := newActionModel(name, action)
+		if err != nil {
+			return nil, err
+		}
+		model.Actions = append(model.Actions, actionModel)
+	}
+
+	return model, nil
+}
+
+func newDeviceModel(dev *upnp.Device) *deviceModel {
+	return &deviceModel{
+		DeviceType: dev.DeviceType,
+		ConstName:  toExportedName(getShortType(dev.DeviceType)) + "DeviceType",
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+const (
+	urnDelim = ":"
+)
+
+// reservedNames are the local names which are used in the generated code.
+var reservedNames = map[string]bool{
+	"action": true,
+	"client": true,
+	"ctx":    true,
+	"dev":    true,
+	"err":    true,
+	"impl":   true,
+	"req":    true,
+	"res":    true,
+}
+
+// toIdentifier returns a Go identifier which consists of the letters and the digits of the specified name.
+func toIdentifier(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = 0 < b.Len()
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	id := b.String()
+	if len(id) == 0 || unicode.IsDigit([]rune(id)[0]) {
+		id = "X" + id
+	}
+	return id
+}
+
+// toExportedName returns an exported Go identifier for the specified name.
+func toExportedName(name string) string {
+	id := []rune(toIdentifier(name))
+	id[0] = unicode.ToUpper(id[0])
+	return string(id)
+}
+
+// toLocalName returns an unexported Go identifier for the specified name which doesn't conflict with the keywords and the generated names.
+func toLocalName(name string) string {
+	id := []rune(toIdentifier(name))
+	n := 0
+	for n < len(id) && unicode.IsUpper(id[n]) {
+		n++
+	}
+	// Lower the leading acronym such as "URL" in "URLBase", but keep the first letter of the next word.
+	if 1 < n && n < len(id) {
+		n--
+	}
+	for i := 0; i < n || i == 0; i++ {
+		id[i] = unicode.ToLower(id[i])
+	}
+	local := string(id)
+	if token.IsKeyword(local) || reservedNames[local] {
+		local += "Value"
+	}
+	return local
+}
+
+// getShortType returns the short type name such as "SwitchPower" in "urn:schemas-upnp-org:service:SwitchPower:1".
+func getShortType(urn string) string {
+	types := strings.Split(urn, urnDelim)
+	if len(types) <= 1 {
+		return urn
+	}
+	return types[len(types)-2]
+}
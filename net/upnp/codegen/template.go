@@ -0,0 +1,198 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+const fileTemplate = `// Code generated by upnpgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"fmt"
+{{- if .UsesTime}}
+	"time"
+{{- end}}
+
+	"github.com/cybergarage/go-net-upnp/net/upnp"
+)
+
+{{- if .Devices}}
+
+const (
+{{- range .Devices}}
+	{{.ConstName}} = "{{.DeviceType}}"
+{{- end}}
+)
+{{- end}}
+
+{{- range .Services}}
+{{- $service := .}}
+
+// {{.Name}} : {{.ServiceType}}
+
+const (
+	{{.ConstName}} = "{{.ServiceType}}"
+{{- range .Actions}}
+	{{.ConstName}} = "{{.Name}}"
+{{- end}}
+)
+
+{{- range .Actions}}
+{{- if .HasResultStruct}}
+
+// {{.ResultName}} represents the out-arguments of {{.Name}}.
+type {{.ResultName}} struct {
+{{- range .OutArgs}}
+	{{.FieldName}} {{.Type.Name}} ` + "`" + `upnp:"{{.Name}}"` + "`" + `
+{{- end}}
+}
+{{- end}}
+{{- end}}
+
+// {{.Name}}Client represents a typed client of {{.ServiceType}}.
+type {{.Name}}Client struct {
+	Service *upnp.Service
+}
+
+// New{{.Name}}Client returns a client for the first {{.Name}} service of the device or the embedded devices.
+func New{{.Name}}Client(dev *upnp.Device) (*{{.Name}}Client, error) {
+	service, ok := find{{.Name}}Service(dev)
+	if !ok {
+		return nil, fmt.Errorf("service (%s) is not found", {{.ConstName}})
+	}
+	return &{{.Name}}Client{Service: service}, nil
+}
+
+{{- range .Actions}}
+
+// {{.MethodName}} posts the {{.Name}} action. It is safe for concurrent use.
+func (client *{{$service.Name}}Client) {{.MethodName}}(ctx context.Context{{range .InArgs}}, {{.LocalName}} {{.Type.Name}}{{end}}) (
+{{- if .HasResultStruct}}res *{{.ResultName}}, {{else}}{{range .OutArgs}}{{.LocalName}} {{.Type.Name}}, {{end}}{{end}}err error) {
+	serviceAction, err := client.Service.GetActionByName({{.ConstName}})
+	if err != nil {
+		return
+	}
+	action := serviceAction.Copy()
+{{- range .InArgs}}
+	err = action.SetArgument{{.Type.Accessor}}("{{.Name}}", {{if .Type.IsConverted}}{{.Type.Base}}({{.LocalName}}){{else}}{{.LocalName}}{{end}})
+	if err != nil {
+		return
+	}
+{{- end}}
+	err = action.PostContext(ctx)
+{{- if .HasResultStruct}}
+	if err != nil {
+		return
+	}
+{{- range .OutArgs}}
+	{{.LocalName}}Value, err := action.GetArgument{{.Type.Accessor}}("{{.Name}}")
+	if err != nil {
+		return
+	}
+{{- end}}
+	res = &{{.ResultName}}{
+{{- range .OutArgs}}
+		{{.FieldName}}: {{if .Type.IsConverted}}{{.Type.Name}}({{.LocalName}}Value){{else}}{{.LocalName}}Value{{end}},
+{{- end}}
+	}
+{{- else if .OutArgs}}
+	if err != nil {
+		return
+	}
+{{- with index .OutArgs 0}}
+{{- if .Type.IsConverted}}
+	{{.LocalName}}Value, err := action.GetArgument{{.Type.Accessor}}("{{.Name}}")
+	if err != nil {
+		return
+	}
+	{{.LocalName}} = {{.Type.Name}}({{.LocalName}}Value)
+{{- else}}
+	{{.LocalName}}, err = action.GetArgument{{.Type.Accessor}}("{{.Name}}")
+{{- end}}
+{{- end}}
+{{- end}}
+	return
+}
+{{- end}}
+
+// {{.Name}}Service represents an implementation of {{.ServiceType}}.
+type {{.Name}}Service interface {
+{{- range .Actions}}
+	{{.MethodName}}(ctx context.Context{{range .InArgs}}, {{.LocalName}} {{.Type.Name}}{{end}}) {{if .HasResultStruct}}(*{{.ResultName}}, error){{else if .OutArgs}}({{(index .OutArgs 0).Type.Name}}, error){{else}}error{{end}}
+{{- end}}
+}
+
+// New{{.Name}}ActionHandler returns an action handler which calls the specified implementation of {{.ServiceType}}.
+func New{{.Name}}ActionHandler(impl {{.Name}}Service) upnp.ActionHandler {
+	return upnp.ActionHandlerFunc(func(req *upnp.ActionRequest) upnp.Error {
+		switch req.Action.Name {
+{{- range .Actions}}
+		case {{.ConstName}}:
+			return handle{{$service.Name}}{{.MethodName}}(impl, req)
+{{- end}}
+		}
+		return upnp.NewErrorFromCode(upnp.ErrorOptionalActionNotImplemented)
+	})
+}
+
+// Bind{{.Name}}Service sets the implementation for the {{.Name}} services of the device and the embedded devices.
+func Bind{{.Name}}Service(dev *upnp.Device, impl {{.Name}}Service) error {
+	return dev.SetServiceActionHandler({{.ConstName}}, New{{.Name}}ActionHandler(impl))
+}
+
+{{- range .Actions}}
+
+func handle{{$service.Name}}{{.MethodName}}(impl {{$service.Name}}Service, req *upnp.ActionRequest) upnp.Error {
+{{- range .InArgs}}
+	{{.LocalName}}, err := req.Action.GetArgument{{.Type.Accessor}}("{{.Name}}")
+	if err != nil {
+		return upnp.NewErrorFromCode(upnp.ErrorArgumentValueInvalid)
+	}
+{{- end}}
+{{- $call := printf "impl.%s(req.Context()" .MethodName}}
+{{- if .OutArgs}}
+	res, err := {{$call}}{{range .InArgs}}, {{if .Type.IsConverted}}{{.Type.Name}}({{.LocalName}}){{else}}{{.LocalName}}{{end}}{{end}})
+	if err != nil {
+		return upnp.NewErrorFromError(err)
+	}
+{{- if .HasResultStruct}}
+	if res == nil {
+		return upnp.NewErrorFromCode(upnp.ErrorActionFailed)
+	}
+{{- range .OutArgs}}
+	if err := req.Action.SetArgument{{.Type.Accessor}}("{{.Name}}", {{if .Type.IsConverted}}{{.Type.Base}}(res.{{.FieldName}}){{else}}res.{{.FieldName}}{{end}}); err != nil {
+		return upnp.NewErrorFromCode(upnp.ErrorActionFailed)
+	}
+{{- end}}
+{{- else}}
+{{- range .OutArgs}}
+	if err := req.Action.SetArgument{{.Type.Accessor}}("{{.Name}}", {{if .Type.IsConverted}}{{.Type.Base}}(res){{else}}res{{end}}); err != nil {
+		return upnp.NewErrorFromCode(upnp.ErrorActionFailed)
+	}
+{{- end}}
+{{- end}}
+{{- else}}
+	if err := {{$call}}{{range .InArgs}}, {{if .Type.IsConverted}}{{.Type.Name}}({{.LocalName}}){{else}}{{.LocalName}}{{end}}{{end}}); err != nil {
+		return upnp.NewErrorFromError(err)
+	}
+{{- end}}
+	return nil
+}
+{{- end}}
+
+// find{{.Name}}Service returns the first {{.Name}} service of the device or the embedded devices.
+func find{{.Name}}Service(dev *upnp.Device) (*upnp.Service, bool) {
+	if service, err := dev.GetServiceByType({{.ConstName}}); err == nil {
+		return service, true
+	}
+	for _, embeddedDev := range dev.GetEmbeddedDevices() {
+		if service, ok := find{{.Name}}Service(embeddedDev); ok {
+			return service, true
+		}
+	}
+	return nil, false
+}
+{{- end}}
+`
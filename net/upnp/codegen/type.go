@@ -0,0 +1,57 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"github.com/cybergarage/go-net-upnp/net/upnp/datatype"
+)
+
+// A goType represents a Go type for a UPnP data type, and the accessors of upnp.Action to get and set the values.
+type goType struct {
+	Name     string
+	Accessor string
+	Base     string
+}
+
+var goTypes = map[string]goType{
+	datatype.UI1:        {"uint8", "Uint", "uint64"},
+	datatype.UI2:        {"uint16", "Uint", "uint64"},
+	datatype.UI4:        {"uint32", "Uint", "uint64"},
+	datatype.UI8:        {"uint64", "Uint", "uint64"},
+	datatype.I1:         {"int8", "Int", "int"},
+	datatype.I2:         {"int16", "Int", "int"},
+	datatype.I4:         {"int32", "Int", "int"},
+	datatype.I8:         {"int64", "Int", "int"},
+	datatype.Int:        {"int64", "Int", "int"},
+	datatype.R4:         {"float32", "Float", "float64"},
+	datatype.R8:         {"float64", "Float", "float64"},
+	datatype.Number:     {"float64", "Float", "float64"},
+	datatype.Float:      {"float64", "Float", "float64"},
+	datatype.Fixed144:   {"float64", "Float", "float64"},
+	datatype.Boolean:    {"bool", "Bool", "bool"},
+	datatype.Date:       {"time.Time", "Time", "time.Time"},
+	datatype.DateTime:   {"time.Time", "Time", "time.Time"},
+	datatype.DateTimeTZ: {"time.Time", "Time", "time.Time"},
+	datatype.Time:       {"time.Time", "Time", "time.Time"},
+	datatype.TimeTZ:     {"time.Time", "Time", "time.Time"},
+	datatype.BinBase64:  {"[]byte", "Bytes", "[]byte"},
+	datatype.BinHex:     {"[]byte", "Bytes", "[]byte"},
+}
+
+var stringGoType = goType{"string", "String", "string"}
+
+// getGoType returns the Go type for the specified UPnP data type. The string type is used for the string and the unknown data types.
+func getGoType(dataType string) goType {
+	t, ok := goTypes[dataType]
+	if !ok {
+		return stringGoType
+	}
+	return t
+}
+
+// IsConverted returns true when the value needs a conversion between the Go type and the accessor type.
+func (t goType) IsConverted() bool {
+	return t.Name != t.Base
+}
@@ -5,6 +5,8 @@
 package upnp
 
 import (
+	"time"
+
 	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
 )
 
@@ -24,6 +26,15 @@ const (
 	DeviceDefaultPortRange = 1024
 	DeviceDefaultPortMax   = DeviceDefaultPortBase + DeviceDefaultPortRange
 	DeviceUUIDPrefix       = "uuid:"
+	DeviceDefaultLeaseTime = ssdp.DefaultMaxAge
+
+	DeviceDefaultNetworkCheckInterval = 10 * time.Second
+
+	DeviceDefaultSearchRateLimit    = 10
+	DeviceDefaultSearchRateInterval = time.Second
+	DeviceDefaultSearchMaxResponses = 0
+
+	DeviceDefaultMaxArgumentLength = 65536
 
 	DeviceProtocol              = "http"
 	DeviceDefaultDescriptionURL = "/description.xml"
@@ -31,6 +42,9 @@ const (
 	In  = "in"
 	Out = "out"
 
+	Yes = "yes"
+	No  = "no"
+
 	UnknownDirection = 0
 	InDirection      = 1
 	OutDirection     = 2
@@ -0,0 +1,86 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package control
+
+import (
+	"fmt"
+)
+
+const (
+	QueryServiceType       = "urn:schemas-upnp-org:control-1-0"
+	QueryStateVariable     = "QueryStateVariable"
+	QueryStateVariableName = "varName"
+	QueryStateVariableRet  = "return"
+)
+
+const (
+	errorQueryInvalidAction        = "invalid query action (%s)"
+	errorQueryArgumentNotFound     = "query argument (%s) is not found"
+	errorQueryInvalidArgumentCount = "invalid query argument count (%d)"
+)
+
+// NewQueryRequest returns a new QueryStateVariable request for the specified state variable.
+func NewQueryRequest(varName string) *ActionRequest {
+	req := NewActionRequest()
+	action := &req.Envelope.Body.Action
+	action.Name = QueryStateVariable
+	action.ServiceType = QueryServiceType
+	action.Arguments = append(action.Arguments, &Argument{Name: QueryStateVariableName, Value: varName})
+	return req
+}
+
+// NewQueryResponse returns a new QueryStateVariable response for the specified value.
+func NewQueryResponse(value string) *ActionResponse {
+	res := NewActionResponse()
+	action := &res.Envelope.Body.Action
+	action.Name = QueryStateVariable + ResponseSuffix
+	action.ServiceType = QueryServiceType
+	action.Arguments = append(action.Arguments, &Argument{Name: QueryStateVariableRet, Value: value})
+	return res
+}
+
+// IsQueryStateVariable returns true when the action is a QueryStateVariable action, otherwise false.
+func (action *Action) IsQueryStateVariable() bool {
+	return action.Name == QueryStateVariable
+}
+
+// GetArgumentValue returns the value of the specified argument.
+func (action *Action) GetArgumentValue(name string) (string, bool) {
+	for _, arg := range action.Arguments {
+		if arg.Name == name {
+			return arg.Value, true
+		}
+	}
+	return "", false
+}
+
+// GetQueryVariableName returns the state variable name of the QueryStateVariable request.
+func (req *ActionRequest) GetQueryVariableName() (string, error) {
+	action := &req.Envelope.Body.Action
+	if !action.IsQueryStateVariable() {
+		return "", fmt.Errorf(errorQueryInvalidAction, action.Name)
+	}
+	if len(action.Arguments) != 1 {
+		return "", fmt.Errorf(errorQueryInvalidArgumentCount, len(action.Arguments))
+	}
+	varName, ok := action.GetArgumentValue(QueryStateVariableName)
+	if !ok {
+		return "", fmt.Errorf(errorQueryArgumentNotFound, QueryStateVariableName)
+	}
+	return varName, nil
+}
+
+// GetQueryReturnValue returns the state variable value of the QueryStateVariable response.
+func (res *ActionResponse) GetQueryReturnValue() (string, error) {
+	action := &res.Envelope.Body.Action
+	if !action.IsQueryStateVariable() {
+		return "", fmt.Errorf(errorQueryInvalidAction, action.Name)
+	}
+	value, ok := action.GetArgumentValue(QueryStateVariableRet)
+	if !ok {
+		return "", fmt.Errorf(errorQueryArgumentNotFound, QueryStateVariableRet)
+	}
+	return value, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package control
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+const (
+	errorQueryInvalidValue = "invalid query value = '%s': expected '%s'"
+)
+
+func TestQueryRequest(t *testing.T) {
+	const testSoapQueryRequest = xml.Header + "\n" +
+		"<s:Envelope xmlns:s=\"http://schemas.xmlsoap.org/soap/envelope/\" s:encodingStyle=\"http://schemas.xmlsoap.org/soap/encoding/\">" +
+		"  <s:Body>" +
+		"    <u:QueryStateVariable xmlns:u=\"urn:schemas-upnp-org:control-1-0\">" +
+		"      <u:varName>Status</u:varName>" +
+		"    </u:QueryStateVariable>" +
+		"  </s:Body>" +
+		"</s:Envelope>"
+
+	req, err := NewActionRequestFromSOAPBytes([]byte(testSoapQueryRequest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	varName, err := req.GetQueryVariableName()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if varName != "Status" {
+		t.Errorf(errorQueryInvalidValue, varName, "Status")
+	}
+
+	// marshal and parse again
+
+	reqStr, err := NewQueryRequest("Target").SOAPContentString()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err = NewActionRequestFromSOAPBytes([]byte(reqStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	varName, err = req.GetQueryVariableName()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if varName != "Target" {
+		t.Errorf(errorQueryInvalidValue, varName, "Target")
+	}
+}
+
+func TestQueryResponse(t *testing.T) {
+	resStr, err := NewQueryResponse("1").SOAPContentString()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := NewActionResponseFromSOAPBytes([]byte(resStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := res.GetQueryReturnValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "1" {
+		t.Errorf(errorQueryInvalidValue, value, "1")
+	}
+
+	// not a query response
+
+	res = NewActionResponse()
+	res.Envelope.Body.Action.Name = "GetStatus"
+	if _, err := res.GetQueryReturnValue(); err == nil {
+		t.Errorf(errorQueryInvalidValue, res.Envelope.Body.Action.Name, QueryStateVariable)
+	}
+}
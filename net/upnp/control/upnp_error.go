@@ -16,6 +16,7 @@ const (
 const (
 	ErrorInvalidAction                = 401
 	ErrorInvalidArgs                  = 402
+	ErrorInvalidVar                   = 404
 	ErrorActionFailed                 = 501
 	ErrorArgumentValueInvalid         = 600
 	ErrorArgumentValueOutOfRange      = 601
@@ -23,6 +24,7 @@ const (
 	ErrorOutOfMemory                  = 603
 	ErrorHumanInterventionRequired    = 604
 	ErrorStringArgumentTooLong        = 605
+	ErrorActionNotAuthorized          = 606
 )
 
 const (
@@ -37,6 +39,7 @@ func errorCodeToString(code int) string {
 	errMsgs := map[int]string{
 		ErrorInvalidAction:                "Invalid Action",
 		ErrorInvalidArgs:                  "Invalid Args",
+		ErrorInvalidVar:                   "Invalid Var",
 		ErrorActionFailed:                 "Action Failed",
 		ErrorArgumentValueInvalid:         "Argument Value Invalid",
 		ErrorArgumentValueOutOfRange:      "Argument Value Out of Range",
@@ -44,6 +47,7 @@ func errorCodeToString(code int) string {
 		ErrorOutOfMemory:                  "Out of Memory",
 		ErrorHumanInterventionRequired:    "Human Intervention Required",
 		ErrorStringArgumentTooLong:        "String Argument Too Long",
+		ErrorActionNotAuthorized:          "Action not authorized",
 	}
 
 	msg, ok := errMsgs[code]
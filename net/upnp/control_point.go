@@ -5,15 +5,27 @@
 package upnp
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
+	"net/url"
 	"sync"
+	"time"
 
 	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/http"
 	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
 	"github.com/cybergarage/go-net-upnp/net/upnp/util"
 )
 
+const (
+	controlPointExpirationCheckInterval = time.Second
+)
+
+const (
+	errorControlPointBadDevice = "device (%s, %s) is invalid"
+)
+
 // A ControlPointListener represents a listener for ControlPoint.
 type ControlPointListener interface {
 	ssdp.MulticastListener
@@ -24,13 +36,36 @@ type ControlPointListener interface {
 type ControlPoint struct {
 	*sync.Mutex
 
-	Port     int
-	SearchMX int
-
-	rootDeviceMap       *DeviceMap
-	ssdpMcastServerList *ssdp.MulticastServerList
-	ssdpUcastServerList *ssdp.UnicastServerList
-	Listener            ControlPointListener
+	Port         int
+	SearchMX     int
+	FetchOptions *FetchOptions
+
+	// InterfaceSelector selects the interfaces and the addresses to bind. The default interfaces are used when it is nil.
+	InterfaceSelector *util.InterfaceSelector
+
+	// HTTPClient is used for all HTTP requests of the control point, such as the description fetches, the subscriptions,
+	// and the actions of the found devices. The default client is used when it is nil.
+	HTTPClient *http.Client
+
+	rootDeviceMap        *DeviceMap
+	ssdpMcastServerList  *ssdp.MulticastServerList
+	ssdpUcastServerList  *ssdp.UnicastServerList
+	eventMcastServerList *ssdp.MulticastServerList
+	httpServer           *http.Server
+	subscriptions        *SubscriptionList
+	Listener             ControlPointListener
+	DeviceListener       ControlPointDeviceListener
+	EventListener        EventListener
+
+	watcherLock    *sync.Mutex
+	watchers       map[*deviceWatcher]struct{}
+	collectorLock  *sync.Mutex
+	collectors     map[*searchCollector]struct{}
+	mcastEventLock *sync.Mutex
+	mcastEventSEQs map[string]uint32
+	fetcher        *deviceFetcher
+	stopCh         chan struct{}
+	stopWaitGroup  *sync.WaitGroup
 }
 
 // NewControlPoint returns a new ControlPoint.
@@ -41,15 +76,30 @@ func NewControlPoint() *ControlPoint {
 	cp.rootDeviceMap = NewDeviceMap()
 	cp.ssdpMcastServerList = ssdp.NewMulticastServerList()
 	cp.ssdpUcastServerList = ssdp.NewUnicastServerList()
+	cp.subscriptions = NewSubscriptionList()
+	cp.watcherLock = &sync.Mutex{}
+	cp.watchers = make(map[*deviceWatcher]struct{})
+	cp.collectorLock = &sync.Mutex{}
+	cp.collectors = make(map[*searchCollector]struct{})
+	cp.mcastEventLock = &sync.Mutex{}
+	cp.mcastEventSEQs = make(map[string]uint32)
 
 	cp.SearchMX = ControlPointDefaultSearchMX
+	cp.FetchOptions = NewFetchOptions()
+	cp.HTTPClient = http.NewClientWithConfig(http.NewClientConfig())
+	cp.resetFetcher()
 
 	return cp
 }
 
 // StartWithPort starts this control point using the specified port.
 func (ctrl *ControlPoint) StartWithPort(port int) error {
+	ctrl.stopCh = make(chan struct{})
+	ctrl.stopWaitGroup = &sync.WaitGroup{}
+	ctrl.resetFetcher()
+
 	ctrl.ssdpMcastServerList.Listener = ctrl
+	ctrl.ssdpMcastServerList.Selector = ctrl.InterfaceSelector
 	err := ctrl.ssdpMcastServerList.Start()
 	if err != nil {
 		ctrl.Stop()
@@ -57,6 +107,7 @@ func (ctrl *ControlPoint) StartWithPort(port int) error {
 	}
 
 	ctrl.ssdpUcastServerList.Listener = ctrl
+	ctrl.ssdpUcastServerList.Selector = ctrl.InterfaceSelector
 	err = ctrl.ssdpUcastServerList.Start(port)
 	if err != nil {
 		ctrl.Stop()
@@ -65,9 +116,38 @@ func (ctrl *ControlPoint) StartWithPort(port int) error {
 
 	ctrl.Port = port
 
+	ctrl.httpServer = http.NewServer()
+	ctrl.httpServer.Listener = ctrl
+	ctrl.httpServer.Addresses = ctrl.InterfaceSelector.GetExplicitAddresses()
+	err = ctrl.httpServer.Start(port)
+	if err != nil {
+		ctrl.Stop()
+		return err
+	}
+
+	ctrl.stopWaitGroup.Go(func() {
+		ctrl.expirationLoop(ctrl.stopCh, controlPointExpirationCheckInterval)
+	})
+
 	return nil
 }
 
+// resetFetcher stops the current fetcher, and replaces it with a new fetcher of the current FetchOptions and HTTP client.
+func (ctrl *ControlPoint) resetFetcher() {
+	if ctrl.fetcher != nil {
+		ctrl.fetcher.stop()
+	}
+	ctrl.fetcher = newDeviceFetcher(ctrl.FetchOptions, ctrl.getHTTPClient())
+}
+
+// getHTTPClient returns the HTTP client of the control point, or the default client when it is not set.
+func (ctrl *ControlPoint) getHTTPClient() *http.Client {
+	if ctrl.HTTPClient == nil {
+		return http.GetDefaultClient()
+	}
+	return ctrl.HTTPClient
+}
+
 // Start starts this control point.
 func (ctrl *ControlPoint) Start() error {
 	port := rand.Intn(ControlPointDefaultPortRange) + ControlPointDefaultPortBase
@@ -76,11 +156,57 @@ func (ctrl *ControlPoint) Start() error {
 
 // Stop stops this control point.
 func (ctrl *ControlPoint) Stop() error {
+	var lastErr error
+
+	if ctrl.isRunning() {
+		close(ctrl.stopCh)
+		ctrl.stopWaitGroup.Wait()
+	}
+
+	if ctrl.httpServer != nil {
+		err := ctrl.unsubscribeAll()
+		if err != nil {
+			lastErr = err
+		}
+
+		err = ctrl.httpServer.Stop()
+		if err != nil {
+			lastErr = err
+		}
+		ctrl.httpServer = nil
+	}
+
 	err := ctrl.ssdpMcastServerList.Stop()
 	if err != nil {
-		return err
+		lastErr = err
+	}
+
+	err = ctrl.ssdpUcastServerList.Stop()
+	if err != nil {
+		lastErr = err
+	}
+
+	err = ctrl.LeaveMulticastEvents()
+	if err != nil {
+		lastErr = err
+	}
+
+	ctrl.fetcher.stop()
+
+	return lastErr
+}
+
+// isRunning returns true when the control point is started and not stopped yet, otherwise false.
+func (ctrl *ControlPoint) isRunning() bool {
+	if ctrl.stopCh == nil {
+		return false
+	}
+	select {
+	case <-ctrl.stopCh:
+		return false
+	default:
+		return true
 	}
-	return nil
 }
 
 // Search sends a M-SEARCH request of the specified ST.
@@ -126,28 +252,271 @@ func (ctrl *ControlPoint) FindDeviceByTypeAndUDN(deviceType string, udn string)
 	return dev, ok
 }
 
-// AddDevice adds a specified device.
-func (ctrl *ControlPoint) addDevice(dev *Device) (bool, error) {
+// findDeviceByLocation returns a found root device of the specified description URL.
+func (ctrl *ControlPoint) findDeviceByLocation(location string) (*Device, bool) {
 	ctrl.Lock()
 	defer ctrl.Unlock()
 
-	if ctrl.rootDeviceMap.HasDevice(dev) {
+	for _, dev := range ctrl.rootDeviceMap.GetAllDevices() {
+		if dev.LocationURL == location {
+			return dev, true
+		}
+	}
+
+	return nil, false
+}
+
+// GetDevice returns the root device of the specified description URL. When the device is not found yet, the descriptions
+// are fetched from the URL directly, and the device is added to the found devices without SSDP. The device which is added
+// by GetDevice doesn't expire because it has no advertisement.
+func (ctrl *ControlPoint) GetDevice(ctx context.Context, location string) (*Device, error) {
+	dev, ok := ctrl.findDeviceByLocation(location)
+	if ok {
+		return dev, nil
+	}
+
+	type fetchResult struct {
+		dev *Device
+		err error
+	}
+
+	resultCh := make(chan fetchResult, 1)
+	ctrl.fetcher.fetch(location, []string{location}, func(dev *Device, err error) {
+		if err == nil {
+			var e *DeviceEvent
+			dev, e, err = ctrl.addDevice(dev, nil)
+			ctrl.postDeviceEvent(e)
+		}
+		resultCh <- fetchResult{dev: dev, err: err}
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-resultCh:
+		return res.dev, res.err
+	}
+}
+
+// isSameDeviceLocation returns true when the specified locations are the same, or the same description
+// on the IPv4 and IPv6 addresses of a dual-stack device.
+func isSameDeviceLocation(location1 string, location2 string) bool {
+	if location1 == location2 {
+		return true
+	}
+
+	url1, err := url.Parse(location1)
+	if err != nil {
+		return false
+	}
+	url2, err := url.Parse(location2)
+	if err != nil {
+		return false
+	}
+
+	if url1.Port() != url2.Port() || url1.Path != url2.Path {
+		return false
+	}
+
+	return util.IsIPv6Address(url1.Hostname()) != util.IsIPv6Address(url2.Hostname())
+}
+
+// addDevice adds the specified device which is found by the specified SSDP packet. The packet is nil
+// when the device is added by the description URL. It returns the stored root device, and an added or updated event when the root devices are changed.
+func (ctrl *ControlPoint) addDevice(dev *Device, pkt *ssdp.Packet) (*Device, *DeviceEvent, error) {
+	ctrl.Lock()
+	defer ctrl.Unlock()
+
+	foundDev, hasDev := ctrl.rootDeviceMap.FindDeviceByUDN(dev.UDN)
+	if hasDev && isSameDeviceLocation(foundDev.LocationURL, dev.LocationURL) && !isDeviceConfigChanged(foundDev, pkt) {
+		if pkt != nil {
+			foundDev.updateAdvertisement(pkt)
+		}
 		log.Tracef("device (%s, %s) is already added", dev.DeviceType, dev.UDN)
-		return false, nil
+		return foundDev, nil, nil
+	}
+
+	if pkt != nil {
+		dev.updateAdvertisement(pkt)
+	}
+
+	if hasDev {
+		ctrl.rootDeviceMap.RemoveDevice(foundDev)
+	}
+
+	if !ctrl.rootDeviceMap.AddDevice(dev) {
+		return nil, nil, fmt.Errorf(errorControlPointBadDevice, dev.DeviceType, dev.UDN)
 	}
 
-	err := dev.LoadServiceDescriptions()
+	if hasDev {
+		log.Tracef("device (%s, %s) is updated", dev.DeviceType, dev.UDN)
+		return dev, newDeviceEvent(DeviceUpdatedEvent, dev), nil
+	}
+
+	log.Tracef("device (%s, %s) is added", dev.DeviceType, dev.UDN)
+
+	return dev, newDeviceEvent(DeviceAddedEvent, dev), nil
+}
+
+// fetchDevice fetches the descriptions of the device of the specified SSDP packet outside of the lock,
+// and adds the device. The specified callback is called with the stored root device.
+func (ctrl *ControlPoint) fetchDevice(pkt *ssdp.Packet, callback func(*Device, error)) {
+	location, err := pkt.GetLocation()
+	if err != nil {
+		callback(nil, err)
+		return
+	}
+
+	keys := []string{location}
+	udn, err := pkt.GetUDN()
+	if err == nil && 0 < len(udn) {
+		keys = append(keys, udn)
+	}
+
+	ctrl.fetcher.fetch(location, keys, func(dev *Device, err error) {
+		if err != nil {
+			callback(nil, err)
+			return
+		}
+		dev, e, err := ctrl.addDevice(dev, pkt)
+		ctrl.postDeviceEvent(e)
+		callback(dev, err)
+	})
+}
+
+// isDeviceConfigChanged returns true when the CONFIGID of the specified packet is different from the device's one.
+func isDeviceConfigChanged(dev *Device, pkt *ssdp.Packet) bool {
+	if pkt == nil {
+		return false
+	}
+	configID, _ := pkt.GetConfigIDUPnPOrg()
+	return configID != dev.GetConfigID()
+}
+
+// refreshDevice updates the last advertisement of the known device of the specified SSDP packet.
+// It returns false when the device is unknown, or the location or the CONFIGID is changed,
+// and returns an updated event when the BOOTID is changed.
+func (ctrl *ControlPoint) refreshDevice(pkt *ssdp.Packet) (*Device, *DeviceEvent, bool) {
+	udn, err := pkt.GetUDN()
+	if err != nil {
+		return nil, nil, false
+	}
+
+	location, err := pkt.GetLocation()
 	if err != nil {
-		return false, err
+		return nil, nil, false
+	}
+
+	ctrl.Lock()
+	defer ctrl.Unlock()
+
+	dev, ok := ctrl.rootDeviceMap.FindDeviceByUDN(udn)
+	if !ok || !isSameDeviceLocation(dev.LocationURL, location) || isDeviceConfigChanged(dev, pkt) {
+		return nil, nil, false
+	}
+
+	bootID, _ := pkt.GetBootIDUPnPOrg()
+	isRebooted := bootID != dev.GetBootID()
+
+	dev.updateAdvertisement(pkt)
+
+	if isRebooted {
+		log.Tracef("device (%s, %s) is rebooted", dev.DeviceType, dev.UDN)
+		ctrl.renewDeviceSubscriptions(dev.UDN)
+		return dev, newDeviceEvent(DeviceUpdatedEvent, dev), true
+	}
+
+	return dev, nil, true
+}
+
+// updateDevice applies the specified ssdp:update message to the known device, which changes the boot ID to NEXTBOOTID.UPNP.ORG.
+// It returns false when the device is unknown, and returns an updated event when the previous update messages were missed.
+func (ctrl *ControlPoint) updateDevice(pkt *ssdp.Packet) (*Device, *DeviceEvent, bool) {
+	udn, err := pkt.GetUDN()
+	if err != nil {
+		return nil, nil, false
+	}
+
+	nextBootID, err := pkt.GetNextBootIDUPnPOrg()
+	if err != nil {
+		return nil, nil, false
+	}
+	bootID, _ := pkt.GetBootIDUPnPOrg()
+
+	ctrl.Lock()
+	defer ctrl.Unlock()
+
+	dev, ok := ctrl.rootDeviceMap.FindDeviceByUDN(udn)
+	if !ok {
+		return nil, nil, false
+	}
+
+	currentBootID := dev.GetBootID()
+	if currentBootID == nextBootID {
+		return dev, nil, true
 	}
 
-	ok := ctrl.rootDeviceMap.AddDevice(dev)
+	dev.modifyAdvertisement(func(adv *deviceAdvertisement) {
+		adv.bootID = nextBootID
+	})
+
+	if currentBootID != bootID {
+		log.Tracef("device (%s, %s) is rebooted", dev.DeviceType, dev.UDN)
+		ctrl.renewDeviceSubscriptions(dev.UDN)
+		return dev, newDeviceEvent(DeviceUpdatedEvent, dev), true
+	}
+
+	log.Tracef("device (%s, %s) is updated : %s", dev.DeviceType, dev.UDN, nextBootID)
+
+	return dev, nil, true
+}
 
+// removeDevice removes a root device of the specified UDN.
+func (ctrl *ControlPoint) removeDevice(udn string) (*Device, bool) {
+	ctrl.Lock()
+	dev, ok := ctrl.rootDeviceMap.FindDeviceByUDN(udn)
 	if ok {
-		log.Tracef("device (%s, %s) is added", dev.DeviceType, dev.UDN)
+		ctrl.rootDeviceMap.RemoveDevice(dev)
 	}
+	ctrl.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	log.Tracef("device (%s, %s) is removed", dev.DeviceType, dev.UDN)
+	ctrl.postDeviceEvent(newDeviceEvent(DeviceRemovedEvent, dev))
 
-	return ok, nil
+	return dev, true
+}
+
+// removeExpiredDevices removes root devices whose advertisements are expired.
+func (ctrl *ControlPoint) removeExpiredDevices() []*Device {
+	ctrl.Lock()
+	devs := ctrl.rootDeviceMap.RemoveExpiredDevices(time.Now())
+	ctrl.Unlock()
+
+	for _, dev := range devs {
+		log.Tracef("device (%s, %s) is expired", dev.DeviceType, dev.UDN)
+		ctrl.postDeviceEvent(newDeviceEvent(DeviceRemovedEvent, dev))
+	}
+
+	return devs
+}
+
+// expirationLoop removes expired root devices periodically.
+func (ctrl *ControlPoint) expirationLoop(stopCh chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			ctrl.removeExpiredDevices()
+		}
+	}
 }
 
 func getFromToMessageFromSSDPPacket(req *ssdp.Packet) string {
@@ -161,18 +530,48 @@ func getFromToMessageFromSSDPPacket(req *ssdp.Packet) string {
 	return fmt.Sprintf("(%s -> %s)", fromAddr, toAddr)
 }
 
+// reviseLocationZone adds the zone of the received interface to the IPv6 link-local LOCATION of the specified packet.
+func reviseLocationZone(pkt *ssdp.Packet) {
+	location, err := pkt.GetLocation()
+	if err != nil {
+		return
+	}
+	pkt.SetLocation(util.ReviseURLZone(location, pkt.Interface.Name))
+}
+
 func (ctrl *ControlPoint) DeviceNotifyReceived(ssdpReq *ssdp.Request) {
+	reviseLocationZone(ssdpReq.Packet)
 	usn, _ := ssdpReq.GetUSN()
 	log.Tracef("notiry req : %s %s", usn, getFromToMessageFromSSDPPacket(ssdpReq.Packet))
 
-	if ssdpReq.IsRootDevice() {
-		newDev, err := NewDeviceFromSSDPRequest(ssdpReq)
+	switch {
+	case ssdpReq.IsByeBye():
+		udn, err := ssdpReq.GetUDN()
 		if err == nil {
-			_, err = ctrl.addDevice(newDev)
+			ctrl.removeDevice(udn)
 		}
-		if err != nil {
-			log.Warnf("%s", err.Error())
+	case ssdpReq.IsUpdate():
+		_, e, ok := ctrl.updateDevice(ssdpReq.Packet)
+		ctrl.postDeviceEvent(e)
+		if ok || !ssdpReq.IsRootDevice() {
+			break
+		}
+		ctrl.fetchDevice(ssdpReq.Packet, func(dev *Device, err error) {
+			if err != nil {
+				log.Warnf("%s", err.Error())
+			}
+		})
+	case ssdpReq.IsAlive():
+		_, e, ok := ctrl.refreshDevice(ssdpReq.Packet)
+		ctrl.postDeviceEvent(e)
+		if ok || !ssdpReq.IsRootDevice() {
+			break
 		}
+		ctrl.fetchDevice(ssdpReq.Packet, func(dev *Device, err error) {
+			if err != nil {
+				log.Warnf("%s", err.Error())
+			}
+		})
 	}
 
 	if ctrl.Listener != nil {
@@ -190,15 +589,27 @@ func (ctrl *ControlPoint) DeviceSearchReceived(ssdpReq *ssdp.Request) {
 }
 
 func (ctrl *ControlPoint) DeviceResponseReceived(ssdpRes *ssdp.Response) {
+	reviseLocationZone(ssdpRes.Packet)
 	url, _ := ssdpRes.GetLocation()
 	log.Tracef("search res : %s %s", url, getFromToMessageFromSSDPPacket(ssdpRes.Packet))
 
-	newDev, err := NewDeviceFromSSDPResponse(ssdpRes)
-	if err == nil {
-		_, err = ctrl.addDevice(newDev)
-	}
-	if err != nil {
-		log.Warnf("%s", err.Error())
+	collectors := ctrl.beginSearchCollectors(ssdpRes)
+
+	dev, e, ok := ctrl.refreshDevice(ssdpRes.Packet)
+	ctrl.postDeviceEvent(e)
+	if ok {
+		for _, collector := range collectors {
+			collector.done(ssdpRes, dev, nil)
+		}
+	} else {
+		ctrl.fetchDevice(ssdpRes.Packet, func(dev *Device, err error) {
+			if err != nil && len(collectors) == 0 {
+				log.Warnf("%s", err.Error())
+			}
+			for _, collector := range collectors {
+				collector.done(ssdpRes, dev, err)
+			}
+		})
 	}
 
 	if ctrl.Listener != nil {
@@ -0,0 +1,164 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"context"
+	"sync"
+)
+
+// A ControlPointDeviceListener represents a listener for the lifecycle of the found root devices.
+type ControlPointDeviceListener interface {
+	DeviceAdded(*Device)
+	DeviceUpdated(*Device)
+	DeviceRemoved(*Device)
+}
+
+// A DeviceEventType represents a lifecycle change of a found root device.
+type DeviceEventType int
+
+const (
+	DeviceAddedEvent DeviceEventType = iota + 1
+	DeviceUpdatedEvent
+	DeviceRemovedEvent
+)
+
+// String returns the name of the event type.
+func (eventType DeviceEventType) String() string {
+	switch eventType {
+	case DeviceAddedEvent:
+		return "added"
+	case DeviceUpdatedEvent:
+		return "updated"
+	case DeviceRemovedEvent:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// A DeviceEvent represents a lifecycle change of a found root device.
+type DeviceEvent struct {
+	Type   DeviceEventType
+	Device *Device
+}
+
+func newDeviceEvent(eventType DeviceEventType, dev *Device) *DeviceEvent {
+	return &DeviceEvent{Type: eventType, Device: dev}
+}
+
+// A deviceWatcher delivers device events to a channel in order without blocking the control point.
+type deviceWatcher struct {
+	*sync.Mutex
+
+	eventCh  chan *DeviceEvent
+	queue    []*DeviceEvent
+	notifyCh chan struct{}
+}
+
+func newDeviceWatcher() *deviceWatcher {
+	watcher := &deviceWatcher{
+		Mutex:    &sync.Mutex{},
+		eventCh:  make(chan *DeviceEvent),
+		queue:    make([]*DeviceEvent, 0),
+		notifyCh: make(chan struct{}, 1),
+	}
+	return watcher
+}
+
+// post queues the specified event.
+func (watcher *deviceWatcher) post(e *DeviceEvent) {
+	watcher.Lock()
+	watcher.queue = append(watcher.queue, e)
+	watcher.Unlock()
+
+	select {
+	case watcher.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+func (watcher *deviceWatcher) nextQueuedEvent() (*DeviceEvent, bool) {
+	watcher.Lock()
+	defer watcher.Unlock()
+
+	if len(watcher.queue) == 0 {
+		return nil, false
+	}
+
+	e := watcher.queue[0]
+	watcher.queue = watcher.queue[1:]
+
+	return e, true
+}
+
+// deliverEvents sends the queued events to the channel until the specified context is done.
+func (watcher *deviceWatcher) deliverEvents(ctx context.Context) {
+	defer close(watcher.eventCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-watcher.notifyCh:
+		}
+
+		for {
+			e, ok := watcher.nextQueuedEvent()
+			if !ok {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case watcher.eventCh <- e:
+			}
+		}
+	}
+}
+
+// WatchDevices returns a channel which receives the lifecycle events of the found root devices.
+// The channel is closed when the specified context is done.
+func (ctrl *ControlPoint) WatchDevices(ctx context.Context) <-chan *DeviceEvent {
+	watcher := newDeviceWatcher()
+
+	ctrl.watcherLock.Lock()
+	ctrl.watchers[watcher] = struct{}{}
+	ctrl.watcherLock.Unlock()
+
+	go func() {
+		watcher.deliverEvents(ctx)
+
+		ctrl.watcherLock.Lock()
+		delete(ctrl.watchers, watcher)
+		ctrl.watcherLock.Unlock()
+	}()
+
+	return watcher.eventCh
+}
+
+// postDeviceEvent notifies the specified event to the listener and the watchers.
+func (ctrl *ControlPoint) postDeviceEvent(e *DeviceEvent) {
+	if e == nil {
+		return
+	}
+
+	if ctrl.DeviceListener != nil {
+		switch e.Type {
+		case DeviceAddedEvent:
+			ctrl.DeviceListener.DeviceAdded(e.Device)
+		case DeviceUpdatedEvent:
+			ctrl.DeviceListener.DeviceUpdated(e.Device)
+		case DeviceRemovedEvent:
+			ctrl.DeviceListener.DeviceRemoved(e.Device)
+		}
+	}
+
+	ctrl.watcherLock.Lock()
+	defer ctrl.watcherLock.Unlock()
+
+	for watcher := range ctrl.watchers {
+		watcher.post(e)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"io"
+	gohttp "net/http"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/event"
+	"github.com/cybergarage/go-net-upnp/net/upnp/http"
+)
+
+// An Event represents a changed state variable which is notified to a subscription, or multicast by the device.
+type Event struct {
+	// Subscription is the subscription of the event. It is nil for the multicast events.
+	Subscription *Subscription
+	// Service is the service of the event. It is nil when the multicast event is received from an unknown device.
+	Service *Service
+	SEQ     uint32
+	Name    string
+	Value   string
+	// Missed is true when the events before the SEQ were not received.
+	Missed bool
+	// Multicast is true when the event is received by JoinMulticastEvents.
+	Multicast bool
+	// USN, ServiceID and Level are the USN, SVCID and LVL headers of the multicast event.
+	USN       string
+	ServiceID string
+	Level     string
+}
+
+// An EventListener represents a listener for the events of the subscriptions.
+type EventListener interface {
+	EventNotifyReceived(*Event)
+}
+
+func responseEventAccepted(httpRes http.ResponseWriter) error {
+	httpRes.Header().Set(http.ContentLength, "0")
+	writeStatusCode(httpRes, http.StatusOK)
+
+	// Flush the response not to block the device while the listener handles the events.
+	// The next event of the subscription is not accepted until the listener returns.
+	if flusher, ok := httpRes.(gohttp.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+// HTTPRequestReceived handles the event notifications of the subscriptions.
+func (ctrl *ControlPoint) HTTPRequestReceived(httpReq *http.Request, httpRes http.ResponseWriter) {
+	if httpReq.Method != http.NOTIFY {
+		responseBadRequest(httpRes)
+		return
+	}
+
+	nt := httpReq.Header.Get(http.NT)
+	nts := httpReq.Header.Get(http.NTS)
+	sid := httpReq.Header.Get(http.SID)
+	if len(nt) == 0 || len(nts) == 0 || len(sid) == 0 {
+		responseBadRequest(httpRes)
+		return
+	}
+
+	if nt != event.NT || nts != event.NTS {
+		responsePreconditionFailed(httpRes)
+		return
+	}
+
+	sub, ok := ctrl.subscriptions.GetSubscriptionByCallbackPath(httpReq.URL.Path)
+	if !ok || !sub.acceptSID(sid) {
+		responsePreconditionFailed(httpRes)
+		return
+	}
+
+	seq, err := event.ParseSEQ(httpReq.Header.Get(http.SEQ))
+	if err != nil {
+		responseBadRequest(httpRes)
+		return
+	}
+
+	defer httpReq.Body.Close()
+	propSetBytes, err := io.ReadAll(httpReq.Body)
+	if err != nil {
+		responseBadRequest(httpRes)
+		return
+	}
+
+	set, err := event.NewPropertySetFromBytes(propSetBytes)
+	if err != nil {
+		responseBadRequest(httpRes)
+		return
+	}
+
+	// The events of the subscription are dispatched in the SEQ order, because the device sends the next event
+	// after this response, and the response of the next event waits for the dispatch of this event.
+
+	sub.eventLock.Lock()
+	defer sub.eventLock.Unlock()
+
+	responseEventAccepted(httpRes)
+
+	missed := sub.updateSEQ(seq)
+	if missed {
+		log.Warnf("event (%s:%d) is received after missing events", sid, seq)
+	}
+
+	log.Tracef("event notify (%s:%d) = \n%s", sid, seq, string(propSetBytes))
+
+	if ctrl.EventListener == nil {
+		return
+	}
+
+	for _, prop := range set.Properties {
+		ctrl.EventListener.EventNotifyReceived(&Event{
+			Subscription: sub,
+			Service:      sub.Service,
+			SEQ:          seq,
+			Name:         prop.Name,
+			Value:        prop.Value,
+			Missed:       missed,
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/http"
+)
+
+const (
+	FetchDefaultConcurrency   = 4
+	FetchDefaultQueueSize     = 64
+	FetchDefaultTimeout       = 10 * time.Second
+	FetchDefaultRetryCount    = 2
+	FetchDefaultRetryInterval = 500 * time.Millisecond
+)
+
+const (
+	errorFetchQueueFull = "description fetch queue is full"
+)
+
+// A FetchOptions represents options to fetch the device and service descriptions of found devices.
+type FetchOptions struct {
+	// Concurrency is the maximum number of devices whose descriptions are fetched at the same time.
+	Concurrency int
+	// QueueSize is the maximum number of devices which wait for fetching.
+	QueueSize int
+	// Timeout is the timeout of each HTTP request.
+	Timeout time.Duration
+	// RetryCount is the number of retries after the first failure.
+	RetryCount int
+	// RetryInterval is the wait time before the first retry, which is doubled for each retry.
+	RetryInterval time.Duration
+}
+
+// NewFetchOptions returns default fetch options.
+func NewFetchOptions() *FetchOptions {
+	opts := &FetchOptions{
+		Concurrency:   FetchDefaultConcurrency,
+		QueueSize:     FetchDefaultQueueSize,
+		Timeout:       FetchDefaultTimeout,
+		RetryCount:    FetchDefaultRetryCount,
+		RetryInterval: FetchDefaultRetryInterval,
+	}
+	return opts
+}
+
+// A deviceFetchCall represents a fetch in progress which is shared by the requests of the same device.
+type deviceFetchCall struct {
+	keys      []string
+	callbacks []func(*Device, error)
+}
+
+// A deviceFetcher fetches the descriptions of found devices in a bounded worker pool.
+type deviceFetcher struct {
+	*sync.Mutex
+
+	opts      FetchOptions
+	client    *http.Client
+	sem       chan struct{}
+	calls     map[string]*deviceFetchCall
+	pending   int
+	ctx       context.Context
+	cancel    context.CancelFunc
+	waitGroup *sync.WaitGroup
+}
+
+func newDeviceFetcher(opts *FetchOptions, client *http.Client) *deviceFetcher {
+	if opts == nil {
+		opts = NewFetchOptions()
+	}
+
+	if client == nil {
+		client = http.GetDefaultClient()
+	}
+
+	fetcher := &deviceFetcher{
+		Mutex:     &sync.Mutex{},
+		opts:      *opts,
+		client:    client,
+		sem:       make(chan struct{}, max(opts.Concurrency, 1)),
+		calls:     make(map[string]*deviceFetchCall),
+		waitGroup: &sync.WaitGroup{},
+	}
+	fetcher.ctx, fetcher.cancel = context.WithCancel(context.Background())
+
+	return fetcher
+}
+
+// fetch fetches the device of the specified location, and calls the specified callback with the result.
+// The requests which have one of the same keys, such as the location and the UDN, are merged into one fetch.
+func (fetcher *deviceFetcher) fetch(location string, keys []string, callback func(*Device, error)) {
+	fetcher.Lock()
+	defer fetcher.Unlock()
+
+	for _, key := range keys {
+		call, ok := fetcher.calls[key]
+		if !ok {
+			continue
+		}
+		call.callbacks = append(call.callbacks, callback)
+		return
+	}
+
+	if max(fetcher.opts.QueueSize, 1)+cap(fetcher.sem) <= fetcher.pending {
+		fetcher.waitGroup.Go(func() {
+			callback(nil, errors.New(errorFetchQueueFull))
+		})
+		return
+	}
+
+	call := &deviceFetchCall{
+		keys:      keys,
+		callbacks: []func(*Device, error){callback},
+	}
+	for _, key := range keys {
+		fetcher.calls[key] = call
+	}
+	fetcher.pending++
+
+	fetcher.waitGroup.Go(func() {
+		dev, err := fetcher.run(location)
+
+		fetcher.Lock()
+		for _, key := range call.keys {
+			delete(fetcher.calls, key)
+		}
+		fetcher.pending--
+		fetcher.Unlock()
+
+		for _, callback := range call.callbacks {
+			callback(dev, err)
+		}
+	})
+}
+
+// run fetches the device of the specified location when a worker is available.
+func (fetcher *deviceFetcher) run(location string) (*Device, error) {
+	select {
+	case <-fetcher.ctx.Done():
+		return nil, fetcher.ctx.Err()
+	case fetcher.sem <- struct{}{}:
+	}
+	defer func() { <-fetcher.sem }()
+
+	client := fetcher.client.WithTimeout(fetcher.opts.Timeout)
+
+	retryInterval := fetcher.opts.RetryInterval
+	for retryCnt := 0; ; retryCnt++ {
+		dev, err := fetchDevice(fetcher.ctx, client, location)
+		if err == nil {
+			dev.HTTPClient = fetcher.client
+			return dev, nil
+		}
+
+		if fetcher.opts.RetryCount <= retryCnt {
+			return nil, err
+		}
+
+		select {
+		case <-fetcher.ctx.Done():
+			return nil, err
+		case <-time.After(retryInterval):
+		}
+		retryInterval *= 2
+	}
+}
+
+// fetchDevice loads the device description and the service descriptions of the specified location.
+func fetchDevice(ctx context.Context, client *http.Client, location string) (*Device, error) {
+	dev, err := newDeviceFromDescriptionURLWithClient(ctx, client, location)
+	if err != nil {
+		return nil, err
+	}
+
+	dev.SetLocationURL(location)
+
+	err = dev.loadServiceDescriptionsWithClient(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return dev, nil
+}
+
+// wait waits until all fetches in progress are done.
+func (fetcher *deviceFetcher) wait() {
+	fetcher.waitGroup.Wait()
+}
+
+// stop cancels all fetches in progress and waits for them.
+func (fetcher *deviceFetcher) stop() {
+	fetcher.cancel()
+	fetcher.wait()
+}
@@ -0,0 +1,219 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	upnphttp "github.com/cybergarage/go-net-upnp/net/upnp/http"
+)
+
+const (
+	errorTestFetchRequestCount  = "description is requested %d times : expected %d"
+	errorTestFetchInvalidDevice = "fetched device is invalid (%v, %v)"
+	errorTestFetchUserAgent     = "request (%s) user agent = '%s' : expected '%s'"
+	errorTestFetchNotCanceled   = "request is not canceled in %v"
+)
+
+func newTestDescriptionServer(failCnt int32, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	reqCnt := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != DeviceDefaultDescriptionURL {
+			w.Write([]byte(switchPowerServiceDescription))
+			return
+		}
+		time.Sleep(delay)
+		if reqCnt.Add(1) <= failCnt {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(binaryLightDeviceDescription))
+	}))
+	return server, reqCnt
+}
+
+func TestDeviceFetcherMerge(t *testing.T) {
+	server, reqCnt := newTestDescriptionServer(0, 100*time.Millisecond)
+	defer server.Close()
+
+	location := server.URL + DeviceDefaultDescriptionURL
+
+	fetcher := newDeviceFetcher(nil, nil)
+	defer fetcher.stop()
+
+	var wg sync.WaitGroup
+	devs := make([]*Device, 3)
+	for n := range devs {
+		wg.Add(1)
+		fetcher.fetch(location, []string{location}, func(dev *Device, err error) {
+			if err != nil {
+				t.Error(err)
+			}
+			devs[n] = dev
+			wg.Done()
+		})
+	}
+	wg.Wait()
+
+	if reqCnt.Load() != 1 {
+		t.Errorf(errorTestFetchRequestCount, reqCnt.Load(), 1)
+	}
+
+	if devs[0] == nil || devs[0] != devs[1] || devs[0] != devs[2] {
+		t.Errorf(errorTestFetchInvalidDevice, devs[0], devs[1])
+	}
+}
+
+func TestDeviceFetcherRetry(t *testing.T) {
+	server, reqCnt := newTestDescriptionServer(2, 0)
+	defer server.Close()
+
+	location := server.URL + DeviceDefaultDescriptionURL
+
+	opts := NewFetchOptions()
+	opts.RetryCount = 2
+	opts.RetryInterval = 10 * time.Millisecond
+	fetcher := newDeviceFetcher(opts, nil)
+	defer fetcher.stop()
+
+	var fetchedDev *Device
+	fetcher.fetch(location, []string{location}, func(dev *Device, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		fetchedDev = dev
+	})
+	fetcher.wait()
+
+	if reqCnt.Load() != 3 {
+		t.Errorf(errorTestFetchRequestCount, reqCnt.Load(), 3)
+	}
+
+	if fetchedDev == nil || fetchedDev.LocationURL != location || len(fetchedDev.GetServices()) == 0 {
+		t.Errorf(errorTestFetchInvalidDevice, fetchedDev, location)
+	}
+
+	// no retries
+
+	server, reqCnt = newTestDescriptionServer(1, 0)
+	defer server.Close()
+
+	location = server.URL + DeviceDefaultDescriptionURL
+
+	opts.RetryCount = 0
+	fetcher = newDeviceFetcher(opts, nil)
+	defer fetcher.stop()
+
+	fetcher.fetch(location, []string{location}, func(dev *Device, err error) {
+		if err == nil {
+			t.Errorf(errorTestFetchInvalidDevice, dev, err)
+		}
+	})
+	fetcher.wait()
+
+	if reqCnt.Load() != 1 {
+		t.Errorf(errorTestFetchRequestCount, reqCnt.Load(), 1)
+	}
+}
+
+func TestDeviceFetcherClient(t *testing.T) {
+	testUA := "upnptest/1.0"
+
+	testDev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDev.reviseParentObject()
+	testDev.reviseDescription()
+	devDesc, err := testDev.DescriptionString()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testDone := make(chan struct{})
+
+	var lock sync.Mutex
+	userAgents := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		userAgents[r.Method+" "+r.URL.Path] = r.UserAgent()
+		lock.Unlock()
+		switch {
+		case r.URL.Path == DeviceDefaultDescriptionURL:
+			w.Write([]byte(devDesc))
+		case r.Method == http.MethodPost:
+			// The device is wedged until the request is canceled or the test is done.
+			io.Copy(io.Discard, r.Body)
+			select {
+			case <-r.Context().Done():
+			case <-testDone:
+			}
+		default:
+			w.Write([]byte(switchPowerServiceDescription))
+		}
+	}))
+	defer server.Close()
+	defer close(testDone)
+
+	location := server.URL + DeviceDefaultDescriptionURL
+
+	config := upnphttp.NewClientConfig()
+	config.UserAgent = testUA
+	client := upnphttp.NewClientWithConfig(config)
+
+	fetcher := newDeviceFetcher(nil, client)
+	defer fetcher.stop()
+
+	var fetchedDev *Device
+	fetcher.fetch(location, []string{location}, func(dev *Device, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		fetchedDev = dev
+	})
+	fetcher.wait()
+
+	if fetchedDev == nil || fetchedDev.GetHTTPClient() != client {
+		t.Fatalf(errorTestFetchInvalidDevice, fetchedDev, client)
+	}
+
+	// actions of the fetched device use the client of the fetcher, and they can be canceled
+
+	service, err := fetchedDev.GetServiceByType("urn:schemas-upnp-org:service:SwitchPower:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	action, err := service.GetActionByName(GetTarget)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := 100 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := action.Invoke(ctx, nil); err == nil || (timeout*10) < time.Since(start) {
+		t.Errorf(errorTestFetchNotCanceled, timeout)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	for req, ua := range userAgents {
+		if ua != testUA {
+			t.Errorf(errorTestFetchUserAgent, req, ua, testUA)
+		}
+	}
+	if len(userAgents) != 3 {
+		t.Errorf(errorTestFetchRequestCount, len(userAgents), 3)
+	}
+}
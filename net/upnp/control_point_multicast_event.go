@@ -0,0 +1,152 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/event"
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+)
+
+// A multicastEventListener receives the multicast events for the control point.
+type multicastEventListener struct {
+	ctrl *ControlPoint
+}
+
+func (l *multicastEventListener) DeviceNotifyReceived(ssdpReq *ssdp.Request) {
+	if !ssdpReq.IsPropChange() {
+		return
+	}
+	l.ctrl.multicastEventReceived(ssdpReq)
+}
+
+func (l *multicastEventListener) DeviceSearchReceived(ssdpReq *ssdp.Request) {
+}
+
+// JoinMulticastEvents joins the multicast eventing groups to receive the multicast events of the devices.
+// The events are notified to EventListener as well as the events of the subscriptions.
+func (ctrl *ControlPoint) JoinMulticastEvents() error {
+	err := ctrl.LeaveMulticastEvents()
+	if err != nil {
+		return err
+	}
+
+	servers := ssdp.NewEventMulticastServerList()
+	servers.Listener = &multicastEventListener{ctrl: ctrl}
+	servers.Selector = ctrl.InterfaceSelector
+	err = servers.Start()
+	if err != nil {
+		servers.Stop()
+		return err
+	}
+
+	ctrl.mcastEventLock.Lock()
+	ctrl.eventMcastServerList = servers
+	ctrl.mcastEventSEQs = map[string]uint32{}
+	ctrl.mcastEventLock.Unlock()
+
+	return nil
+}
+
+// LeaveMulticastEvents leaves the multicast eventing groups.
+func (ctrl *ControlPoint) LeaveMulticastEvents() error {
+	ctrl.mcastEventLock.Lock()
+	servers := ctrl.eventMcastServerList
+	ctrl.eventMcastServerList = nil
+	ctrl.mcastEventLock.Unlock()
+
+	if servers == nil {
+		return nil
+	}
+
+	return servers.Stop()
+}
+
+// updateMulticastSEQ records the specified event key of the service, and returns whether previous events were missed,
+// and whether the event is a duplicate. The same event is received on each interface and address family of the device,
+// and the event which has the same SEQ as the last event is a duplicate, not a gap.
+// The first event of the service is not regarded as missed because the control point may join after the device started.
+func (ctrl *ControlPoint) updateMulticastSEQ(usn string, svcid string, seq uint32) (bool, bool) {
+	ctrl.mcastEventLock.Lock()
+	defer ctrl.mcastEventLock.Unlock()
+
+	key := usn + usnDelim + svcid
+	lastSEQ, ok := ctrl.mcastEventSEQs[key]
+	if ok && seq == lastSEQ {
+		return false, true
+	}
+	ctrl.mcastEventSEQs[key] = seq
+
+	return ok && seq != event.NextSEQ(lastSEQ), false
+}
+
+// findServiceByUDNAndID returns the service of the specified ID in the found device of the specified UDN.
+func (ctrl *ControlPoint) findServiceByUDNAndID(udn string, serviceID string) (*Service, bool) {
+	for _, rootDev := range ctrl.GetRootDevices() {
+		for _, service := range rootDev.getAllServices() {
+			if service.ParentDevice == nil || service.ParentDevice.UDN != udn {
+				continue
+			}
+			if service.ServiceID == serviceID {
+				return service, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (ctrl *ControlPoint) multicastEventReceived(ssdpReq *ssdp.Request) {
+	usn, _ := ssdpReq.GetUSN()
+	svcid, _ := ssdpReq.GetSVCID()
+	lvl, _ := ssdpReq.GetLVL()
+	if len(usn) == 0 || len(svcid) == 0 {
+		return
+	}
+
+	seqValue, _ := ssdpReq.GetSEQ()
+	seq, err := event.ParseSEQ(seqValue)
+	if err != nil {
+		log.Warnf("%s", err.Error())
+		return
+	}
+
+	propSetBytes := ssdpReq.GetBody()
+	set, err := event.NewPropertySetFromBytes(propSetBytes)
+	if err != nil {
+		log.Warnf("%s", err.Error())
+		return
+	}
+
+	missed, duplicate := ctrl.updateMulticastSEQ(usn, svcid, seq)
+	if duplicate {
+		return
+	}
+	if missed {
+		log.Warnf("multicast event (%s:%d) is received after missing events", usn, seq)
+	}
+
+	log.Tracef("multicast event notify (%s:%d) = \n%s", usn, seq, string(propSetBytes))
+
+	if ctrl.EventListener == nil {
+		return
+	}
+
+	udn, _ := ssdpReq.GetUDN()
+	service, _ := ctrl.findServiceByUDNAndID(udn, svcid)
+
+	for _, prop := range set.Properties {
+		ctrl.EventListener.EventNotifyReceived(&Event{
+			Service:   service,
+			SEQ:       seq,
+			Name:      prop.Name,
+			Value:     prop.Value,
+			Missed:    missed,
+			Multicast: true,
+			USN:       usn,
+			ServiceID: svcid,
+			Level:     lvl,
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+)
+
+const (
+	errorTestMulticastEventNotReceived      = "multicast event (%s = '%s') is not received"
+	errorTestMulticastEventInvalidInfo      = "multicast event (%s, %s, %s) : expected (%s, %s, %s)"
+	errorTestMulticastEventInvalidDuplicate = "SEQ (%d) duplicate = %t : expected %t"
+	errorTestMulticastEventInvalidCount     = "multicast event (%s = '%s') is received %d times : expected once"
+	errorTestMulticastEventMissed           = "multicast event (%s = '%s') is regarded as missed"
+)
+
+const multicastServiceDescription = xml.Header +
+	"<scpd>" +
+	"  <serviceStateTable>" +
+	"    <stateVariable sendEvents=\"no\" multicast=\"yes\">" +
+	"      <name>Level</name>" +
+	"      <dataType>ui4</dataType>" +
+	"      <defaultValue>0</defaultValue>" +
+	"    </stateVariable>" +
+	"  </serviceStateTable>" +
+	"</scpd>"
+
+func TestControlPointMulticastSEQ(t *testing.T) {
+	cp := NewControlPoint()
+
+	seqs := []struct {
+		seq       uint32
+		missed    bool
+		duplicate bool
+	}{
+		{5, false, false},
+		{5, false, true},
+		{6, false, false},
+		{8, true, false},
+		{8, false, true},
+		{9, false, false},
+	}
+
+	for _, s := range seqs {
+		missed, duplicate := cp.updateMulticastSEQ("uuid:test", "urn:upnp-org:serviceId:test", s.seq)
+		if missed != s.missed {
+			t.Errorf(errorTestSubscriptionInvalidSEQ, s.seq, missed, s.missed)
+		}
+		if duplicate != s.duplicate {
+			t.Errorf(errorTestMulticastEventInvalidDuplicate, s.seq, duplicate, s.duplicate)
+		}
+	}
+}
+
+func TestControlPointMulticastEvent(t *testing.T) {
+	dev, err := NewDeviceFromDescription(binaryLightDeviceDescription)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, err := dev.GetServiceByType("urn:schemas-upnp-org:service:SwitchPower:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = service.LoadDescriptionBytes([]byte(multicastServiceDescription))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statVar, err := service.GetStateVariableByName("Level")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statVar.EventLevel = ssdp.EventLevelInfo
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	listener := &testEventListener{eventCh: make(chan *Event, 16)}
+	cp := NewControlPoint()
+	cp.EventListener = listener
+	err = cp.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Stop()
+
+	err = cp.JoinMulticastEvents()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	value := "10"
+	err = statVar.SetValue(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The event is sent on all interfaces and address families, and it is delivered only once.
+
+	receivedCnt := 0
+	timer := time.After(2 * time.Second)
+	for {
+		select {
+		case e := <-listener.eventCh:
+			if !e.Multicast || e.Name != statVar.Name || e.Value != value {
+				continue
+			}
+			receivedCnt++
+			usn := dev.UDN + usnDelim + service.ServiceType
+			if e.USN != usn || e.ServiceID != service.ServiceID || e.Level != ssdp.EventLevelInfo {
+				t.Errorf(errorTestMulticastEventInvalidInfo, e.USN, e.ServiceID, e.Level, usn, service.ServiceID, ssdp.EventLevelInfo)
+			}
+			if e.Missed {
+				t.Errorf(errorTestMulticastEventMissed, statVar.Name, value)
+			}
+			continue
+		case <-timer:
+		}
+		break
+	}
+
+	switch receivedCnt {
+	case 0:
+		t.Errorf(errorTestMulticastEventNotReceived, statVar.Name, value)
+	case 1:
+	default:
+		t.Errorf(errorTestMulticastEventInvalidCount, statVar.Name, value, receivedCnt)
+	}
+}
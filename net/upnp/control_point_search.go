@@ -0,0 +1,362 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+)
+
+const (
+	SearchDefaultRepeatCount    = 1
+	SearchDefaultRepeatInterval = 100 * time.Millisecond
+	SearchDefaultGracePeriod    = 500 * time.Millisecond
+	SearchHostDefaultWaitTime   = time.Second
+)
+
+const (
+	errorDeviceFetchFailed = "device (%s) at %s couldn't be fetched : %s"
+	errorSearchFailed      = "search (%s) couldn't fetch %d devices"
+)
+
+// A SearchOptions represents options of SearchContext.
+type SearchOptions struct {
+	// MX is the maximum wait seconds of the devices. Zero uses SearchMX of the control point.
+	MX int
+	// RepeatCount is the number of M-SEARCH requests to send for lost UDP packets.
+	RepeatCount int
+	// RepeatInterval is the interval between the repeated M-SEARCH requests.
+	RepeatInterval time.Duration
+	// GracePeriod is the additional wait time for the responses after MX.
+	GracePeriod time.Duration
+}
+
+// NewSearchOptions returns default search options.
+func NewSearchOptions() *SearchOptions {
+	opts := &SearchOptions{
+		RepeatCount:    SearchDefaultRepeatCount,
+		RepeatInterval: SearchDefaultRepeatInterval,
+		GracePeriod:    SearchDefaultGracePeriod,
+	}
+	return opts
+}
+
+// A DeviceFetchError represents an error to fetch the description of a device which responds to a search.
+type DeviceFetchError struct {
+	USN      string
+	Location string
+	Err      error
+}
+
+func (e *DeviceFetchError) Error() string {
+	return fmt.Sprintf(errorDeviceFetchFailed, e.USN, e.Location, e.Err.Error())
+}
+
+func (e *DeviceFetchError) Unwrap() error {
+	return e.Err
+}
+
+// A SearchError represents errors of the devices which respond to a search but couldn't be fetched.
+type SearchError struct {
+	ST     string
+	Errors []*DeviceFetchError
+}
+
+func (e *SearchError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for n, err := range e.Errors {
+		msgs[n] = err.Error()
+	}
+	return fmt.Sprintf(errorSearchFailed, e.ST, len(e.Errors)) + "\n" + strings.Join(msgs, "\n")
+}
+
+func (e *SearchError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for n, err := range e.Errors {
+		errs[n] = err
+	}
+	return errs
+}
+
+// A searchCollector collects the devices which respond to a search.
+type searchCollector struct {
+	*sync.Mutex
+
+	st      string
+	host    string
+	closed  bool
+	pending int
+	idleCh  chan struct{}
+	devs    []*Device
+	errs    []*DeviceFetchError
+	udns    map[string]bool
+	locs    map[string]bool
+}
+
+func newSearchCollector(st string) *searchCollector {
+	collector := &searchCollector{
+		Mutex:  &sync.Mutex{},
+		st:     st,
+		idleCh: make(chan struct{}, 1),
+		devs:   make([]*Device, 0),
+		errs:   make([]*DeviceFetchError, 0),
+		udns:   make(map[string]bool),
+		locs:   make(map[string]bool),
+	}
+	return collector
+}
+
+// newHostSearchCollector returns a collector of the responses from the specified host.
+func newHostSearchCollector(st string, host string) *searchCollector {
+	collector := newSearchCollector(st)
+	collector.host = host
+	return collector
+}
+
+// isTarget returns true when the specified response is for the search, otherwise false.
+func (collector *searchCollector) isTarget(ssdpRes *ssdp.Response) bool {
+	if 0 < len(collector.host) && !collector.isHostResponse(ssdpRes) {
+		return false
+	}
+	if collector.st == ssdp.All {
+		return true
+	}
+	st, err := ssdpRes.GetST()
+	if err != nil {
+		return false
+	}
+	return st == collector.st
+}
+
+// isHostResponse returns true when the specified response is sent from the host of the collector,
+// or its LOCATION is on the host.
+func (collector *searchCollector) isHostResponse(ssdpRes *ssdp.Response) bool {
+	if isSameHostAddress(collector.host, ssdpRes.GetFromAddress()) {
+		return true
+	}
+	location, err := ssdpRes.GetLocation()
+	if err != nil {
+		return false
+	}
+	locationURL, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+	return isSameHostAddress(collector.host, locationURL.Hostname())
+}
+
+// begin starts to collect a response, and returns false when the collection is closed.
+func (collector *searchCollector) begin() bool {
+	collector.Lock()
+	defer collector.Unlock()
+
+	if collector.closed {
+		return false
+	}
+	collector.pending++
+
+	return true
+}
+
+// done records the specified result of a response which is begun.
+func (collector *searchCollector) done(ssdpRes *ssdp.Response, dev *Device, err error) {
+	collector.Lock()
+	defer collector.Unlock()
+
+	switch {
+	case err != nil:
+		location, _ := ssdpRes.GetLocation()
+		if !collector.locs[location] {
+			usn, _ := ssdpRes.GetUSN()
+			collector.errs = append(collector.errs, &DeviceFetchError{USN: usn, Location: location, Err: err})
+			collector.locs[location] = true
+		}
+	case dev != nil && !collector.udns[dev.UDN]:
+		collector.devs = append(collector.devs, dev)
+		collector.udns[dev.UDN] = true
+	}
+
+	collector.pending--
+	if collector.pending == 0 {
+		select {
+		case collector.idleCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// close stops collecting new responses.
+func (collector *searchCollector) close() {
+	collector.Lock()
+	defer collector.Unlock()
+	collector.closed = true
+}
+
+// isIdle returns true when there is no response in progress.
+func (collector *searchCollector) isIdle() bool {
+	collector.Lock()
+	defer collector.Unlock()
+	return collector.pending == 0
+}
+
+// wait waits until the responses in progress are done or the specified context is done.
+func (collector *searchCollector) wait(ctx context.Context) {
+	for !collector.isIdle() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-collector.idleCh:
+		}
+	}
+}
+
+// result returns the collected devices and the fetch errors.
+func (collector *searchCollector) result() ([]*Device, error) {
+	collector.Lock()
+	defer collector.Unlock()
+
+	devs := make([]*Device, len(collector.devs))
+	copy(devs, collector.devs)
+
+	if len(collector.errs) == 0 {
+		return devs, nil
+	}
+
+	errs := make([]*DeviceFetchError, len(collector.errs))
+	copy(errs, collector.errs)
+
+	return devs, &SearchError{ST: collector.st, Errors: errs}
+}
+
+// isSameHostAddress returns true when the specified addresses are the same IP address regardless of the zones.
+func isSameHostAddress(addr1 string, addr2 string) bool {
+	ip1, err := netip.ParseAddr(addr1)
+	if err != nil {
+		return false
+	}
+	ip2, err := netip.ParseAddr(addr2)
+	if err != nil {
+		return false
+	}
+	return ip1.WithZone("").Unmap() == ip2.WithZone("").Unmap()
+}
+
+// beginSearchCollectors returns the running collectors which collect the specified response.
+func (ctrl *ControlPoint) beginSearchCollectors(ssdpRes *ssdp.Response) []*searchCollector {
+	ctrl.collectorLock.Lock()
+	defer ctrl.collectorLock.Unlock()
+
+	collectors := make([]*searchCollector, 0)
+	for collector := range ctrl.collectors {
+		if !collector.isTarget(ssdpRes) || !collector.begin() {
+			continue
+		}
+		collectors = append(collectors, collector)
+	}
+
+	return collectors
+}
+
+// sendSearchRequests sends the M-SEARCH requests of the specified options.
+func (ctrl *ControlPoint) sendSearchRequests(ctx context.Context, st string, mx int, opts *SearchOptions) error {
+	var lastErr error
+
+	for n := range max(opts.RepeatCount, 1) {
+		if 0 < n {
+			select {
+			case <-ctx.Done():
+				return lastErr
+			case <-time.After(opts.RepeatInterval):
+			}
+		}
+		err := ctrl.ssdpUcastServerList.Search(st, mx)
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// collectSearch registers the specified collector, sends the requests by the specified function,
+// and returns the collected devices when the specified wait time is elapsed or the specified context is done.
+func (ctrl *ControlPoint) collectSearch(ctx context.Context, collector *searchCollector, waitTime time.Duration, send func(ctx context.Context) error) ([]*Device, error) {
+	ctrl.collectorLock.Lock()
+	ctrl.collectors[collector] = struct{}{}
+	ctrl.collectorLock.Unlock()
+
+	defer func() {
+		ctrl.collectorLock.Lock()
+		delete(ctrl.collectors, collector)
+		ctrl.collectorLock.Unlock()
+	}()
+
+	searchCtx, cancel := context.WithTimeout(ctx, waitTime)
+	defer cancel()
+
+	err := send(searchCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	<-searchCtx.Done()
+
+	collector.close()
+	collector.wait(ctx)
+
+	return collector.result()
+}
+
+// SearchContext sends M-SEARCH requests of the specified ST, and returns the root devices which respond
+// within the MX and the grace period, or until the specified context is done.
+// The devices are returned after their descriptions are loaded. When the descriptions of some responding
+// devices couldn't be fetched, the other devices are returned with a SearchError which has the errors.
+func (ctrl *ControlPoint) SearchContext(ctx context.Context, st string, opts *SearchOptions) ([]*Device, error) {
+	if opts == nil {
+		opts = NewSearchOptions()
+	}
+
+	mx := opts.MX
+	if mx <= 0 {
+		mx = ctrl.SearchMX
+	}
+
+	waitTime := time.Duration(mx)*time.Second + opts.GracePeriod
+
+	return ctrl.collectSearch(ctx, newSearchCollector(st), waitTime, func(ctx context.Context) error {
+		return ctrl.sendSearchRequests(ctx, st, mx, opts)
+	})
+}
+
+// getSearchPortForHost returns SEARCHPORT.UPNP.ORG advertised by a found root device of the specified host, or ssdp.Port.
+func (ctrl *ControlPoint) getSearchPortForHost(host string) int {
+	for _, dev := range ctrl.GetRootDevices() {
+		locationURL, err := url.Parse(dev.LocationURL)
+		if err != nil || !isSameHostAddress(locationURL.Hostname(), host) {
+			continue
+		}
+		return dev.GetSearchPort()
+	}
+	return ssdp.Port
+}
+
+// SearchHost sends a unicast M-SEARCH request of the specified ST to the specified host address, and returns the root devices
+// of the host which respond within SearchHostDefaultWaitTime, or until the specified context is done. The request is sent to
+// SEARCHPORT.UPNP.ORG advertised by the host, or ssdp.Port. It finds the devices in the routed networks which the multicast
+// requests don't reach.
+func (ctrl *ControlPoint) SearchHost(ctx context.Context, host string, st string) ([]*Device, error) {
+	port := ctrl.getSearchPortForHost(host)
+
+	return ctrl.collectSearch(ctx, newHostSearchCollector(st, host), SearchHostDefaultWaitTime, func(ctx context.Context) error {
+		return ctrl.ssdpUcastServerList.SearchHost(host, port, st)
+	})
+}
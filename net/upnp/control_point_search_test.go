@@ -0,0 +1,284 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
+)
+
+const (
+	errorTestSearchDeviceCount     = "search (%s) found %d devices : expected %d"
+	errorTestSearchInvalidError    = "search error is invalid : %v"
+	errorTestSearchNotCanceled     = "search is not canceled (%s)"
+	errorTestSearchDeviceNotFound  = "search (%s) couldn't find the device (%s)"
+	errorTestSearchInvalidLocation = "search (%s) found the device at %s : expected %s"
+)
+
+func TestSearchCollector(t *testing.T) {
+	const location = "http://192.0.2.1:80/description.xml"
+
+	collector := newSearchCollector(ssdp.RootDevice)
+
+	ssdpRes, _ := ssdp.NewSearchResponse(ssdp.RootDevice, "uuid:bad"+usnDelim+ssdp.RootDevice)
+	ssdpRes.SetLocation(location)
+
+	if !collector.isTarget(ssdpRes) {
+		t.Errorf(errorTestSearchDeviceCount, ssdp.RootDevice, 0, 1)
+	}
+
+	dev := NewDevice()
+	dev.UDN = "uuid:good"
+
+	for range 2 {
+		collector.begin()
+		collector.done(ssdpRes, nil, errors.New(location))
+		collector.begin()
+		collector.done(ssdpRes, dev, nil)
+	}
+
+	collector.close()
+	if collector.begin() {
+		t.Errorf(errorTestSearchNotCanceled, ssdp.RootDevice)
+	}
+
+	devs, err := collector.result()
+	if len(devs) != 1 {
+		t.Errorf(errorTestSearchDeviceCount, ssdp.RootDevice, len(devs), 1)
+	}
+
+	var searchErr *SearchError
+	if !errors.As(err, &searchErr) || len(searchErr.Errors) != 1 {
+		t.Errorf(errorTestSearchInvalidError, err)
+	}
+
+	var fetchErr *DeviceFetchError
+	if !errors.As(err, &fetchErr) || fetchErr.Location != location {
+		t.Errorf(errorTestSearchInvalidError, err)
+	}
+}
+
+func TestControlPointSearchContext(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	cp := NewControlPoint()
+	err = cp.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Stop()
+
+	// canceled search
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	startTime := time.Now()
+	cp.SearchContext(ctx, dev.DeviceType, nil)
+	if time.Second < time.Since(startTime) {
+		t.Errorf(errorTestSearchNotCanceled, dev.DeviceType)
+	}
+
+	// search
+
+	opts := NewSearchOptions()
+	opts.MX = 1
+	opts.RepeatCount = 2
+
+	devs, err := cp.SearchContext(context.Background(), dev.DeviceType, opts)
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, foundDev := range devs {
+		if foundDev.UDN == dev.UDN {
+			return
+		}
+	}
+
+	t.Skipf(errorTestSearchDeviceNotFound, dev.DeviceType, dev.UDN)
+}
+
+func TestControlPointSearchLoopbackInterface(t *testing.T) {
+	const loopbackAddr = "127.0.0.1"
+
+	newSelector := func() *util.InterfaceSelector {
+		sel := util.NewInterfaceSelector()
+		sel.Addresses = []string{loopbackAddr}
+		return sel
+	}
+
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.InterfaceSelector = newSelector()
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	cp := NewControlPoint()
+	cp.InterfaceSelector = newSelector()
+	err = cp.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Stop()
+
+	opts := NewSearchOptions()
+	opts.MX = 1
+
+	devs, err := cp.SearchContext(context.Background(), dev.DeviceType, opts)
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, foundDev := range devs {
+		if foundDev.UDN != dev.UDN {
+			continue
+		}
+		locationURL, err := url.Parse(foundDev.LocationURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if locationURL.Hostname() != loopbackAddr {
+			t.Errorf(errorTestSearchInvalidLocation, dev.DeviceType, locationURL.Hostname(), loopbackAddr)
+		}
+		return
+	}
+
+	t.Skipf(errorTestSearchDeviceNotFound, dev.DeviceType, dev.UDN)
+}
+
+func TestControlPointSearchIPv6(t *testing.T) {
+	newSelector := func() *util.InterfaceSelector {
+		sel := util.NewInterfaceSelector()
+		sel.Includes = []string{"::/0"}
+		return sel
+	}
+
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.InterfaceSelector = newSelector()
+
+	err = dev.Start()
+	if err != nil {
+		t.Skip(err)
+	}
+	defer dev.Stop()
+
+	cp := NewControlPoint()
+	cp.InterfaceSelector = newSelector()
+	err = cp.Start()
+	if err != nil {
+		t.Skip(err)
+	}
+	defer cp.Stop()
+
+	opts := NewSearchOptions()
+	opts.MX = 1
+
+	devs, err := cp.SearchContext(context.Background(), dev.DeviceType, opts)
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, foundDev := range devs {
+		if foundDev.UDN != dev.UDN {
+			continue
+		}
+		locationURL, err := url.Parse(foundDev.LocationURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !util.IsIPv6Address(locationURL.Hostname()) {
+			t.Errorf(errorTestSearchInvalidLocation, dev.DeviceType, locationURL.Hostname(), "IPv6 address")
+		}
+		return
+	}
+
+	t.Skipf(errorTestSearchDeviceNotFound, dev.DeviceType, dev.UDN)
+}
+
+func TestControlPointSearchHost(t *testing.T) {
+	const loopbackAddr = "127.0.0.1"
+
+	newSelector := func() *util.InterfaceSelector {
+		sel := util.NewInterfaceSelector()
+		sel.Addresses = []string{loopbackAddr}
+		return sel
+	}
+
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.InterfaceSelector = newSelector()
+	dev.SearchPort = ControlPointDefaultPortBase + ControlPointDefaultPortRange + 4
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	cp := NewControlPoint()
+	cp.InterfaceSelector = newSelector()
+	err = cp.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Stop()
+
+	// The device is found by the description URL to know the host.
+
+	locationURL, err := dev.createLocationURLForAddress(loopbackAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foundDev, err := cp.GetDevice(context.Background(), locationURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	foundDev.modifyAdvertisement(func(adv *deviceAdvertisement) {
+		adv.searchPort = dev.SearchPort
+	})
+
+	// The unicast M-SEARCH is sent to the advertised SEARCHPORT.
+
+	devs, err := cp.SearchHost(context.Background(), loopbackAddr, dev.DeviceType)
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, foundDev := range devs {
+		if foundDev.UDN == dev.UDN {
+			return
+		}
+	}
+
+	t.Errorf(errorTestSearchDeviceNotFound, dev.DeviceType, dev.UDN)
+}
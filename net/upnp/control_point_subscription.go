@@ -0,0 +1,298 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/event"
+	"github.com/cybergarage/go-net-upnp/net/upnp/http"
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
+)
+
+const (
+	ControlPointEventCallbackPath = "/event/"
+
+	subscriptionRequestTimeout   = 30 * time.Second
+	subscriptionMinRenewInterval = time.Second
+)
+
+const (
+	errorControlPointNotStarted      = "control point is not started"
+	errorSubscriptionBadResponse     = "%s (%s) is bad response (%d)"
+	errorSubscriptionSIDNotFound     = "%s (%s) response has no SID"
+	errorSubscriptionNotSubscribed   = "subscription (%s) is not subscribed"
+	errorSubscriptionServiceNotFound = "service is not specified"
+)
+
+// createCallbackURLForAddress returns a callback URL of the specified path for the event notifications.
+func (ctrl *ControlPoint) createCallbackURLForAddress(addr string, path string) (*url.URL, error) {
+	callbackBase := fmt.Sprintf("%s://%s", DeviceProtocol, util.JoinHostPort(addr, ctrl.Port))
+	return util.GetAbsoluteURLFromBaseAndPath(callbackBase, path)
+}
+
+// createCallbackURL returns a callback URL of the specified path which the device of the event URL can reach.
+func (ctrl *ControlPoint) createCallbackURL(eventURL *url.URL, path string) (*url.URL, error) {
+	ifAddr, err := ctrl.InterfaceSelector.GetAvailableAddressForAddr(eventURL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	return ctrl.createCallbackURLForAddress(ifAddr, path)
+}
+
+// getSubscriptionHTTPClient returns the HTTP client for the subscription requests which has a timeout.
+func (ctrl *ControlPoint) getSubscriptionHTTPClient() *http.Client {
+	client := ctrl.getHTTPClient()
+	if client.Timeout <= 0 {
+		return client.WithTimeout(subscriptionRequestTimeout)
+	}
+	return client
+}
+
+// postSubscriptionRequest sends the specified request, and returns the SID and the timeout of the response.
+func (ctrl *ControlPoint) postSubscriptionRequest(httpReq *http.Request, requestedTimeout int) (string, int, error) {
+	httpRes, err := ctrl.getSubscriptionHTTPClient().Do(httpReq)
+	if err != nil {
+		return "", 0, err
+	}
+	io.Copy(io.Discard, httpRes.Body)
+	httpRes.Body.Close()
+
+	if httpRes.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf(errorSubscriptionBadResponse, httpReq.Method, httpReq.URL, httpRes.StatusCode)
+	}
+
+	sid := httpRes.Header.Get(http.SID)
+	if len(sid) == 0 && httpReq.Method != http.UNSUBSCRIBE {
+		return "", 0, fmt.Errorf(errorSubscriptionSIDNotFound, httpReq.Method, httpReq.URL)
+	}
+
+	timeout, err := event.ParseTimeout(httpRes.Header.Get(http.Timeout))
+	if err != nil {
+		timeout = requestedTimeout
+	}
+
+	return sid, timeout, nil
+}
+
+// subscribe sends a new SUBSCRIBE request of the specified subscription.
+func (ctrl *ControlPoint) subscribe(ctx context.Context, sub *Subscription) error {
+	eventURL, err := sub.Service.GetAbsoluteEventSubURL()
+	if err != nil {
+		return err
+	}
+
+	callbackURL, err := ctrl.createCallbackURL(eventURL, sub.getCallbackPath())
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.SUBSCRIBE, eventURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	http.SetRawHeader(httpReq.Header, http.Callback, event.FormatCallback(callbackURL.String()))
+	http.SetRawHeader(httpReq.Header, http.NT, event.NT)
+	http.SetRawHeader(httpReq.Header, http.Timeout, event.FormatTimeout(sub.requestedTimeout))
+
+	sid, timeout, err := ctrl.postSubscriptionRequest(httpReq, sub.requestedTimeout)
+	if err != nil {
+		return err
+	}
+
+	sub.accept(sid, timeout)
+
+	log.Tracef("subscribed : %s (%s)", sid, eventURL)
+
+	return nil
+}
+
+// renew sends a SUBSCRIBE request with the SID of the specified subscription.
+func (ctrl *ControlPoint) renew(ctx context.Context, sub *Subscription) error {
+	eventURL, err := sub.Service.GetAbsoluteEventSubURL()
+	if err != nil {
+		return err
+	}
+
+	sid := sub.GetSID()
+	if len(sid) == 0 {
+		return fmt.Errorf(errorSubscriptionNotSubscribed, eventURL)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.SUBSCRIBE, eventURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	http.SetRawHeader(httpReq.Header, http.SID, sid)
+	http.SetRawHeader(httpReq.Header, http.Timeout, event.FormatTimeout(sub.requestedTimeout))
+
+	_, timeout, err := ctrl.postSubscriptionRequest(httpReq, sub.requestedTimeout)
+	if err != nil {
+		return err
+	}
+
+	sub.accept(sid, timeout)
+
+	log.Tracef("subscription renewed : %s (%s)", sid, eventURL)
+
+	return nil
+}
+
+// unsubscribe sends an UNSUBSCRIBE request of the specified subscription.
+func (ctrl *ControlPoint) unsubscribe(ctx context.Context, sub *Subscription) error {
+	eventURL, err := sub.Service.GetAbsoluteEventSubURL()
+	if err != nil {
+		return err
+	}
+
+	sid := sub.GetSID()
+	if len(sid) == 0 {
+		return fmt.Errorf(errorSubscriptionNotSubscribed, eventURL)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.UNSUBSCRIBE, eventURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	http.SetRawHeader(httpReq.Header, http.SID, sid)
+
+	_, _, err = ctrl.postSubscriptionRequest(httpReq, 0)
+	if err != nil {
+		return err
+	}
+
+	log.Tracef("unsubscribed : %s (%s)", sid, eventURL)
+
+	return nil
+}
+
+// newCallbackPath returns a unique callback path to identify the subscription of the event notifications.
+func newCallbackPath() string {
+	return ControlPointEventCallbackPath + util.CreateUUID()
+}
+
+// scheduleRenewal renews the specified subscription at the half of the accepted timeout.
+func (ctrl *ControlPoint) scheduleRenewal(sub *Subscription) {
+	timeout := sub.GetTimeout()
+	if timeout <= 0 {
+		return
+	}
+
+	interval := max(time.Duration(timeout)*time.Second/2, subscriptionMinRenewInterval)
+	sub.setRenewTimer(interval, func() {
+		ctrl.renewSubscription(sub)
+	})
+}
+
+// renewSubscription renews the specified subscription, and subscribes again when the renewal is rejected.
+func (ctrl *ControlPoint) renewSubscription(sub *Subscription) {
+	if sub.isStopped() {
+		return
+	}
+
+	err := ctrl.renew(context.Background(), sub)
+	if err != nil {
+		log.Warnf("%s", err.Error())
+
+		if !ctrl.subscriptions.RemoveSubscription(sub) {
+			return
+		}
+		sub.reset(newCallbackPath())
+		ctrl.subscriptions.AddSubscription(sub)
+
+		err = ctrl.subscribe(context.Background(), sub)
+		if err != nil {
+			log.Warnf("%s", err.Error())
+			ctrl.subscriptions.RemoveSubscription(sub)
+			sub.stop()
+			return
+		}
+	}
+
+	ctrl.scheduleRenewal(sub)
+}
+
+// renewDeviceSubscriptions renews the subscriptions of the specified rebooted root device in the background.
+// The subscriptions are subscribed again when the renewals are rejected because the device lost them by the reboot.
+func (ctrl *ControlPoint) renewDeviceSubscriptions(udn string) {
+	for _, sub := range ctrl.subscriptions.GetSubscriptions() {
+		service := sub.Service
+		if service == nil || service.ParentDevice == nil || service.ParentDevice.GetRootDevice().UDN != udn {
+			continue
+		}
+		go ctrl.renewSubscription(sub)
+	}
+}
+
+// Subscribe subscribes to the events of the specified service for the specified timeout seconds.
+// The subscription is renewed automatically until it is unsubscribed or the control point is stopped.
+func (ctrl *ControlPoint) Subscribe(service *Service, timeout int) (*Subscription, error) {
+	return ctrl.SubscribeContext(context.Background(), service, timeout)
+}
+
+// SubscribeContext subscribes to the events of the specified service with the specified context.
+// The context is used only for the first SUBSCRIBE request, and the subscription is renewed automatically.
+func (ctrl *ControlPoint) SubscribeContext(ctx context.Context, service *Service, timeout int) (*Subscription, error) {
+	if service == nil {
+		return nil, errors.New(errorSubscriptionServiceNotFound)
+	}
+
+	if ctrl.httpServer == nil {
+		return nil, errors.New(errorControlPointNotStarted)
+	}
+
+	sub := newSubscription(service, timeout)
+	sub.reset(newCallbackPath())
+	ctrl.subscriptions.AddSubscription(sub)
+
+	err := ctrl.subscribe(ctx, sub)
+	if err != nil {
+		ctrl.subscriptions.RemoveSubscription(sub)
+		sub.stop()
+		return nil, err
+	}
+
+	ctrl.scheduleRenewal(sub)
+
+	return sub, nil
+}
+
+// Unsubscribe cancels the specified subscription.
+func (ctrl *ControlPoint) Unsubscribe(sub *Subscription) error {
+	return ctrl.UnsubscribeContext(context.Background(), sub)
+}
+
+// UnsubscribeContext cancels the specified subscription with the specified context.
+func (ctrl *ControlPoint) UnsubscribeContext(ctx context.Context, sub *Subscription) error {
+	sub.stop()
+	if !ctrl.subscriptions.RemoveSubscription(sub) {
+		return fmt.Errorf(errorSubscriptionNotSubscribed, sub.GetSID())
+	}
+	return ctrl.unsubscribe(ctx, sub)
+}
+
+// GetSubscriptions returns all subscriptions of the control point.
+func (ctrl *ControlPoint) GetSubscriptions() []*Subscription {
+	return ctrl.subscriptions.GetSubscriptions()
+}
+
+// unsubscribeAll cancels all subscriptions of the control point.
+func (ctrl *ControlPoint) unsubscribeAll() error {
+	var lastErr error
+	for _, sub := range ctrl.subscriptions.GetSubscriptions() {
+		err := ctrl.Unsubscribe(sub)
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,284 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/event"
+	"github.com/cybergarage/go-net-upnp/net/upnp/http"
+)
+
+const (
+	errorTestSubscriptionEventNotReceived = "event (%s = '%s', SEQ = %d) is not received"
+	errorTestSubscriptionInvalidSEQ       = "SEQ (%d) missed = %t : expected %t"
+	errorTestSubscriptionInvalidTimeout   = "subscription timeout = %d : expected %d"
+	errorTestSubscriptionInvalidCount     = "%d subscribers remain : expected %d"
+	errorTestSubscriptionInvalidOrder     = "event (%s = '%s', SEQ = %d) is received : expected SEQ = %d"
+)
+
+type testEventListener struct {
+	eventCh chan *Event
+}
+
+func (l *testEventListener) EventNotifyReceived(e *Event) {
+	l.eventCh <- e
+}
+
+// A testSlowEventListener represents a listener which takes time to handle the events of the odd SEQs.
+type testSlowEventListener struct {
+	testEventListener
+	delay time.Duration
+}
+
+func (l *testSlowEventListener) EventNotifyReceived(e *Event) {
+	if e.SEQ%2 == 1 {
+		time.Sleep(l.delay)
+	}
+	l.testEventListener.EventNotifyReceived(e)
+}
+
+func (l *testEventListener) waitEvent(t *testing.T, name string, value string, seq uint32) {
+	t.Helper()
+
+	timer := time.After(3 * time.Second)
+	for {
+		select {
+		case e := <-l.eventCh:
+			if e.Name != name || e.Value != value || e.SEQ != seq {
+				continue
+			}
+			if e.Missed {
+				t.Errorf(errorTestSubscriptionInvalidSEQ, e.SEQ, e.Missed, false)
+			}
+			return
+		case <-timer:
+			t.Fatalf(errorTestSubscriptionEventNotReceived, name, value, seq)
+		}
+	}
+}
+
+func newTestSubscribedService(t *testing.T, dev *TestDevice) *Service {
+	t.Helper()
+
+	locationURL := fmt.Sprintf("http://localhost:%d%s", dev.Port, dev.DescriptionURL)
+	foundDev, err := NewDeviceFromDescriptionURL(locationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foundDev.SetLocationURL(locationURL)
+
+	err = foundDev.LoadServiceDescriptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	devService, _ := dev.GetSwitchPowerService()
+	foundService, err := foundDev.GetServiceByType(devService.ServiceType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return foundService
+}
+
+func TestSubscriptionSEQ(t *testing.T) {
+	sub := newSubscription(nil, 0)
+
+	seqs := []struct {
+		seq    uint32
+		missed bool
+	}{
+		{0, false},
+		{1, false},
+		{3, true},
+		{4, false},
+		{4, true},
+	}
+
+	for _, s := range seqs {
+		missed := sub.updateSEQ(s.seq)
+		if missed != s.missed {
+			t.Errorf(errorTestSubscriptionInvalidSEQ, s.seq, missed, s.missed)
+		}
+	}
+}
+
+func TestControlPointSubscription(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	listener := &testEventListener{eventCh: make(chan *Event, 16)}
+	cp := NewControlPoint()
+	cp.EventListener = listener
+	err = cp.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// subscribe
+
+	service := newTestSubscribedService(t, dev)
+
+	timeout := 300
+	sub, err := cp.Subscribe(service, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sub.GetTimeout() != timeout {
+		t.Errorf(errorTestSubscriptionInvalidTimeout, sub.GetTimeout(), timeout)
+	}
+
+	devService, _ := dev.GetSwitchPowerService()
+	statVar, _ := devService.GetStateVariableByName("Status")
+
+	listener.waitEvent(t, statVar.Name, statVar.GetValue(), 0)
+
+	// changed events
+
+	for n, value := range []string{"1", "0"} {
+		err = statVar.SetValue(value)
+		if err != nil {
+			t.Error(err)
+		}
+		listener.waitEvent(t, statVar.Name, value, uint32(n+1))
+	}
+
+	// renew
+
+	err = cp.renew(context.Background(), sub)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// unsubscribe on stop
+
+	err = cp.Stop()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if n := len(devService.GetSubscribers()); n != 0 {
+		t.Errorf(errorTestSubscriptionInvalidCount, n, 0)
+	}
+}
+
+func TestControlPointSubscriptionRenewal(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	cp := NewControlPoint()
+	err = cp.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Stop()
+
+	service := newTestSubscribedService(t, dev)
+
+	timeout := 2
+	sub, err := cp.Subscribe(service, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Duration(timeout)*time.Second + 500*time.Millisecond)
+
+	devService, _ := dev.GetSwitchPowerService()
+	if n := len(devService.GetSubscribers()); n != 1 {
+		t.Errorf(errorTestSubscriptionInvalidCount, n, 1)
+	}
+
+	err = cp.Unsubscribe(sub)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if n := len(devService.GetSubscribers()); n != 0 {
+		t.Errorf(errorTestSubscriptionInvalidCount, n, 0)
+	}
+}
+
+func newTestNotifyRequest(t *testing.T, path string, sid string, seq uint32, name string, value string) *http.Request {
+	t.Helper()
+
+	set := event.NewPropertySet()
+	set.AddProperty(name, value)
+	content, err := set.XMLContentString()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpReq, err := http.NewNotifyRequest(&url.URL{Scheme: "http", Host: "localhost", Path: path}, strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpReq.Header.Set(http.NT, event.NT)
+	httpReq.Header.Set(http.NTS, event.NTS)
+	httpReq.Header.Set(http.SID, sid)
+	httpReq.Header.Set(http.SEQ, strconv.FormatUint(uint64(seq), 10))
+
+	return httpReq
+}
+
+func TestControlPointSubscriptionEventOrder(t *testing.T) {
+	listener := &testSlowEventListener{
+		testEventListener: testEventListener{eventCh: make(chan *Event, 16)},
+		delay:             100 * time.Millisecond,
+	}
+	cp := NewControlPoint()
+	cp.EventListener = listener
+
+	sub := newSubscription(nil, 0)
+	sub.reset("/test/event")
+	cp.subscriptions.AddSubscription(sub)
+
+	// The events are received before the listener returns for the previous events of the odd SEQs,
+	// and they are dispatched in the SEQ order.
+
+	const eventCount = 4
+
+	var wg sync.WaitGroup
+	for n := range eventCount {
+		httpReq := newTestNotifyRequest(t, sub.getCallbackPath(), "uuid:test", uint32(n), "Status", strconv.Itoa(n))
+		wg.Go(func() {
+			cp.HTTPRequestReceived(httpReq, httptest.NewRecorder())
+		})
+		time.Sleep(20 * time.Millisecond)
+	}
+	wg.Wait()
+
+	for n := range eventCount {
+		e := <-listener.eventCh
+		if e.SEQ != uint32(n) || e.Missed {
+			t.Errorf(errorTestSubscriptionInvalidOrder, e.Name, e.Value, e.SEQ, n)
+		}
+	}
+}
@@ -5,13 +5,18 @@
 package upnp
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
+	"net"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/cybergarage/go-net-upnp/net/upnp/control"
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
 )
 
 const (
@@ -21,6 +26,16 @@ const (
 	errorPostActionSuccess          = "post action (%s) successed : expected failed"
 	errorPostActionInvalidErrorType = "error object is invalid : %#v"
 	errorPostActionInvalidErrorCode = "post action (%s) error code = %d : expected %d"
+
+	errorControlPointInvalidDevice          = "control point returns the invalid device (%s, %s) : expected (%s, %s)"
+	errorControlPointInvalidLocation        = "control point returns a device of the invalid location (%s)"
+	errorControlPointDeviceNotRemoved       = "control point doesn't remove the device (%s, %s)"
+	errorControlPointInvalidAdvertisement   = "invalid advertisement (max-age = %d, from = %s, last seen = %s)"
+	errorControlPointInvalidDeviceEvent     = "invalid device event (%s, %s) : expected (%s, %s)"
+	errorControlPointDeviceEventNotReceived = "device event (%s, %s) is not received"
+	errorControlPointUnexpectedDeviceEvent  = "unexpected device event (%s, %s)"
+	errorControlPointInvalidBootID          = "boot ID = %s : expected %s"
+	errorControlPointFetcherNotStopped      = "fetcher of the control point is not stopped by the restart"
 )
 
 func TestNewControlPoint(t *testing.T) {
@@ -30,11 +45,19 @@ func TestNewControlPoint(t *testing.T) {
 		t.Error(errors.New(errorInvalidControlPoint))
 	}
 
+	prevFetcher := cp.fetcher
+
 	err := cp.Start()
 	if err != nil {
 		t.Error(err)
 	}
 
+	// the fetcher which is created by NewControlPoint is stopped and replaced by Start
+
+	if cp.fetcher == prevFetcher || prevFetcher.ctx.Err() == nil {
+		t.Error(errors.New(errorControlPointFetcherNotStopped))
+	}
+
 	err = cp.SearchRootDevice()
 	if err != nil {
 		t.Error(err)
@@ -111,7 +134,7 @@ func TestControlPointSearchDevice(t *testing.T) {
 
 	// post action (set)
 
-	postValue := fmt.Sprintf("target%d", rand.Int())
+	postValue := strconv.Itoa(rand.Intn(2))
 
 	devSetAction, _ := dev.GetSwitchPowerSetTargetAction()
 
@@ -178,3 +201,276 @@ func TestControlPointSearchDevice(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestControlPointDeviceExpiration(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	cp := NewControlPoint()
+
+	newNotifyRequest := func(nts string) *ssdp.Request {
+		req, _ := ssdp.NewNotifyRequest(ssdp.RootDevice, nts, dev.UDN+usnDelim+ssdp.RootDevice)
+		req.SetLocation(fmt.Sprintf("http://localhost:%d%s", dev.Port, dev.DescriptionURL))
+		req.SetMaxAge(1)
+		req.From = net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: ssdp.Port}
+		return req
+	}
+
+	// ssdp:alive
+
+	cp.DeviceNotifyReceived(newNotifyRequest(ssdp.NTSAlive))
+	cp.fetcher.wait()
+
+	foundDev, ok := cp.FindDeviceByTypeAndUDN(dev.DeviceType, dev.UDN)
+	if !ok {
+		t.Fatalf(errorControlPointDeviceNotFound, dev.DeviceType, dev.UDN)
+	}
+
+	if foundDev.GetMaxAge() != 1 || foundDev.GetSourceAddress() != "127.0.0.1" || foundDev.GetLastSeen().IsZero() {
+		t.Errorf(errorControlPointInvalidAdvertisement, foundDev.GetMaxAge(), foundDev.GetSourceAddress(), foundDev.GetLastSeen())
+	}
+
+	// expiration
+
+	if devs := cp.removeExpiredDevices(); len(devs) != 0 {
+		t.Errorf(errorControlPointDeviceNotRemoved, dev.DeviceType, dev.UDN)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if devs := cp.removeExpiredDevices(); len(devs) != 1 {
+		t.Errorf(errorControlPointDeviceNotRemoved, dev.DeviceType, dev.UDN)
+	}
+
+	// ssdp:byebye
+
+	cp.DeviceNotifyReceived(newNotifyRequest(ssdp.NTSAlive))
+	cp.fetcher.wait()
+	if _, ok := cp.FindDeviceByTypeAndUDN(dev.DeviceType, dev.UDN); !ok {
+		t.Fatalf(errorControlPointDeviceNotFound, dev.DeviceType, dev.UDN)
+	}
+
+	cp.DeviceNotifyReceived(newNotifyRequest(ssdp.NTSByeBye))
+	if _, ok := cp.FindDeviceByTypeAndUDN(dev.DeviceType, dev.UDN); ok {
+		t.Errorf(errorControlPointDeviceNotRemoved, dev.DeviceType, dev.UDN)
+	}
+}
+
+type testDeviceListener struct {
+	eventCh chan *DeviceEvent
+}
+
+func (l *testDeviceListener) DeviceAdded(dev *Device) {
+	l.eventCh <- newDeviceEvent(DeviceAddedEvent, dev)
+}
+
+func (l *testDeviceListener) DeviceUpdated(dev *Device) {
+	l.eventCh <- newDeviceEvent(DeviceUpdatedEvent, dev)
+}
+
+func (l *testDeviceListener) DeviceRemoved(dev *Device) {
+	l.eventCh <- newDeviceEvent(DeviceRemovedEvent, dev)
+}
+
+func TestControlPointDeviceListener(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	listener := &testDeviceListener{eventCh: make(chan *DeviceEvent, 8)}
+	cp := NewControlPoint()
+	cp.DeviceListener = listener
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watchCh := cp.WatchDevices(ctx)
+
+	newNotifyRequest := func(nts string, bootID string) *ssdp.Request {
+		req, _ := ssdp.NewNotifyRequest(ssdp.RootDevice, nts, dev.UDN+usnDelim+ssdp.RootDevice)
+		req.SetLocation(fmt.Sprintf("http://localhost:%d%s", dev.Port, dev.DescriptionURL))
+		req.SetMaxAge(ssdp.DefaultMaxAge)
+		req.SetBootIDUPnPOrg(bootID)
+		return req
+	}
+
+	reqs := []struct {
+		req       *ssdp.Request
+		eventType DeviceEventType
+	}{
+		{newNotifyRequest(ssdp.NTSAlive, "1"), DeviceAddedEvent},
+		{newNotifyRequest(ssdp.NTSAlive, "2"), DeviceUpdatedEvent},
+		{newNotifyRequest(ssdp.NTSByeBye, "2"), DeviceRemovedEvent},
+	}
+
+	for _, r := range reqs {
+		cp.DeviceNotifyReceived(r.req)
+
+		for _, eventCh := range []<-chan *DeviceEvent{listener.eventCh, watchCh} {
+			select {
+			case e := <-eventCh:
+				if e.Type != r.eventType || e.Device.UDN != dev.UDN {
+					t.Errorf(errorControlPointInvalidDeviceEvent, e.Type, e.Device.UDN, r.eventType, dev.UDN)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf(errorControlPointDeviceEventNotReceived, r.eventType, dev.UDN)
+			}
+		}
+	}
+
+	// no events for the known device
+
+	cp.DeviceNotifyReceived(newNotifyRequest(ssdp.NTSByeBye, "2"))
+	select {
+	case e := <-listener.eventCh:
+		t.Errorf(errorControlPointUnexpectedDeviceEvent, e.Type, e.Device.UDN)
+	default:
+	}
+
+	cancel()
+	for range watchCh {
+	}
+}
+
+func TestControlPointDeviceUpdate(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	listener := &testDeviceListener{eventCh: make(chan *DeviceEvent, 8)}
+	cp := NewControlPoint()
+	cp.DeviceListener = listener
+
+	newNotifyRequest := func(nts string, bootID string, nextBootID string) *ssdp.Request {
+		req, _ := ssdp.NewNotifyRequest(ssdp.RootDevice, nts, dev.UDN+usnDelim+ssdp.RootDevice)
+		req.SetLocation(fmt.Sprintf("http://localhost:%d%s", dev.Port, dev.DescriptionURL))
+		req.SetMaxAge(ssdp.DefaultMaxAge)
+		req.SetBootIDUPnPOrg(bootID)
+		if 0 < len(nextBootID) {
+			req.SetNextBootIDUPnPOrg(nextBootID)
+		}
+		return req
+	}
+
+	reqs := []struct {
+		req       *ssdp.Request
+		eventType DeviceEventType
+		bootID    string
+	}{
+		{newNotifyRequest(ssdp.NTSAlive, "1", ""), DeviceAddedEvent, "1"},
+		{newNotifyRequest(ssdp.NTSUpdate, "1", "2"), 0, "2"},
+		{newNotifyRequest(ssdp.NTSUpdate, "1", "2"), 0, "2"},
+		{newNotifyRequest(ssdp.NTSAlive, "2", ""), 0, "2"},
+		// the update from 2 to 5 is missed
+		{newNotifyRequest(ssdp.NTSUpdate, "5", "6"), DeviceUpdatedEvent, "6"},
+		{newNotifyRequest(ssdp.NTSAlive, "6", ""), 0, "6"},
+	}
+
+	for _, r := range reqs {
+		cp.DeviceNotifyReceived(r.req)
+		cp.fetcher.wait()
+
+		if r.eventType == 0 {
+			select {
+			case e := <-listener.eventCh:
+				t.Errorf(errorControlPointUnexpectedDeviceEvent, e.Type, e.Device.UDN)
+			default:
+			}
+		} else {
+			select {
+			case e := <-listener.eventCh:
+				if e.Type != r.eventType || e.Device.UDN != dev.UDN {
+					t.Errorf(errorControlPointInvalidDeviceEvent, e.Type, e.Device.UDN, r.eventType, dev.UDN)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf(errorControlPointDeviceEventNotReceived, r.eventType, dev.UDN)
+			}
+		}
+
+		foundDev, ok := cp.FindDeviceByTypeAndUDN(dev.DeviceType, dev.UDN)
+		if !ok {
+			t.Fatalf(errorControlPointDeviceNotFound, dev.DeviceType, dev.UDN)
+		}
+		if foundDev.GetBootID() != r.bootID {
+			t.Errorf(errorControlPointInvalidBootID, foundDev.GetBootID(), r.bootID)
+		}
+	}
+}
+
+func TestControlPointGetDevice(t *testing.T) {
+	const loopbackAddr = "127.0.0.1"
+
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.InterfaceSelector = util.NewInterfaceSelector()
+	dev.InterfaceSelector.Addresses = []string{loopbackAddr}
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	cp := NewControlPoint()
+
+	locationURL, err := dev.createLocationURLForAddress(loopbackAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foundDev, err := cp.GetDevice(context.Background(), locationURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if foundDev.UDN != dev.UDN {
+		t.Errorf(errorControlPointInvalidDevice, foundDev.DeviceType, foundDev.UDN, dev.DeviceType, dev.UDN)
+	}
+
+	// The added device is returned without fetching, and it never expires.
+
+	sameDev, err := cp.GetDevice(context.Background(), locationURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sameDev != foundDev {
+		t.Errorf(errorControlPointInvalidDevice, sameDev.DeviceType, sameDev.UDN, foundDev.DeviceType, foundDev.UDN)
+	}
+
+	if _, ok := cp.FindDeviceByTypeAndUDN(dev.DeviceType, dev.UDN); !ok {
+		t.Errorf(errorControlPointDeviceNotFound, dev.DeviceType, dev.UDN)
+	}
+
+	if foundDev.IsExpired(time.Now().Add(time.Hour)) {
+		t.Errorf(errorControlPointDeviceNotFound, dev.DeviceType, dev.UDN)
+	}
+
+	// invalid location
+
+	invalidLocation := "http://" + loopbackAddr + ":1/description.xml"
+	_, err = cp.GetDevice(context.Background(), invalidLocation)
+	if err == nil {
+		t.Errorf(errorControlPointInvalidLocation, invalidLocation)
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package datatype
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	kindInteger = "integer"
+	kindFloat   = "float"
+	kindBoolean = "boolean"
+	kindTime    = "time"
+	kindBinary  = "binary"
+)
+
+type integerType struct {
+	signed bool
+	bits   int
+}
+
+var integerTypes = map[string]integerType{
+	UI1: {false, 8},
+	UI2: {false, 16},
+	UI4: {false, 32},
+	UI8: {false, 64},
+	I1:  {true, 8},
+	I2:  {true, 16},
+	I4:  {true, 32},
+	I8:  {true, 64},
+	Int: {true, 64},
+}
+
+var timeLayouts = map[string][]string{
+	Date:       {"2006-01-02"},
+	DateTime:   {"2006-01-02T15:04:05", "2006-01-02"},
+	DateTimeTZ: {"2006-01-02T15:04:05Z07:00", "2006-01-02T15:04:05", "2006-01-02"},
+	Time:       {"15:04:05"},
+	TimeTZ:     {"15:04:05Z07:00", "15:04:05"},
+}
+
+// IsInteger returns true when the specified data type is an integer type, otherwise false.
+func IsInteger(dataType string) bool {
+	_, ok := integerTypes[dataType]
+	return ok
+}
+
+// IsFloat returns true when the specified data type is a floating or fixed point type, otherwise false.
+func IsFloat(dataType string) bool {
+	switch dataType {
+	case R4, R8, Number, Float, Fixed144:
+		return true
+	}
+	return false
+}
+
+// IsTime returns true when the specified data type is a date or time type, otherwise false.
+func IsTime(dataType string) bool {
+	_, ok := timeLayouts[dataType]
+	return ok
+}
+
+// IsBinary returns true when the specified data type is a binary type, otherwise false.
+func IsBinary(dataType string) bool {
+	return dataType == BinBase64 || dataType == BinHex
+}
+
+// isGeneric returns true when the specified data type is not declared or unknown, otherwise false.
+func isGeneric(dataType string) bool {
+	return !IsNumeric(dataType) && !IsString(dataType) && !IsTime(dataType) && !IsBinary(dataType) && dataType != Boolean && dataType != UUID
+}
+
+func newTypeMismatchError(dataType string, kind string) error {
+	return fmt.Errorf(errorTypeMismatch, dataType, kind)
+}
+
+func newOutOfRangeError(dataType string, value any) error {
+	return fmt.Errorf(errorOutOfRange, value, dataType)
+}
+
+// FormatInt returns a string of the specified integer value for the data type.
+func FormatInt(dataType string, v int64) (string, error) {
+	if intType, ok := integerTypes[dataType]; ok {
+		if !intType.signed {
+			if v < 0 {
+				return "", newOutOfRangeError(dataType, v)
+			}
+			return FormatUint(dataType, uint64(v))
+		}
+		if intType.bits < 64 {
+			minValue := -int64(1) << (intType.bits - 1)
+			maxValue := int64(1)<<(intType.bits-1) - 1
+			if v < minValue || maxValue < v {
+				return "", newOutOfRangeError(dataType, v)
+			}
+		}
+		return strconv.FormatInt(v, 10), nil
+	}
+
+	if IsFloat(dataType) {
+		return FormatFloat(dataType, float64(v))
+	}
+
+	if isGeneric(dataType) || IsString(dataType) {
+		return strconv.FormatInt(v, 10), nil
+	}
+
+	return "", newTypeMismatchError(dataType, kindInteger)
+}
+
+// ParseInt returns an integer value of the specified string for the data type.
+// The leading and trailing white spaces are ignored.
+func ParseInt(dataType string, value string) (int64, error) {
+	value = strings.TrimSpace(value)
+
+	if intType, ok := integerTypes[dataType]; ok {
+		if intType.signed {
+			return strconv.ParseInt(value, 10, intType.bits)
+		}
+		u, err := strconv.ParseUint(value, 10, intType.bits)
+		if err != nil {
+			return 0, err
+		}
+		if math.MaxInt64 < u {
+			return 0, newOutOfRangeError(dataType, value)
+		}
+		return int64(u), nil
+	}
+
+	if IsFloat(dataType) {
+		f, err := ParseFloat(dataType, value)
+		if err != nil {
+			return 0, err
+		}
+		if f != math.Trunc(f) || f < math.MinInt64 || math.MaxInt64 <= f {
+			return 0, newOutOfRangeError(dataType, value)
+		}
+		return int64(f), nil
+	}
+
+	if isGeneric(dataType) || IsString(dataType) {
+		return strconv.ParseInt(value, 10, 64)
+	}
+
+	return 0, newTypeMismatchError(dataType, kindInteger)
+}
+
+// FormatUint returns a string of the specified unsigned integer value for the data type.
+func FormatUint(dataType string, v uint64) (string, error) {
+	if intType, ok := integerTypes[dataType]; ok {
+		maxValue := uint64(math.MaxUint64)
+		if intType.signed {
+			maxValue = uint64(1)<<(intType.bits-1) - 1
+		} else if intType.bits < 64 {
+			maxValue = uint64(1)<<intType.bits - 1
+		}
+		if maxValue < v {
+			return "", newOutOfRangeError(dataType, v)
+		}
+		return strconv.FormatUint(v, 10), nil
+	}
+
+	if IsFloat(dataType) {
+		return FormatFloat(dataType, float64(v))
+	}
+
+	if isGeneric(dataType) || IsString(dataType) {
+		return strconv.FormatUint(v, 10), nil
+	}
+
+	return "", newTypeMismatchError(dataType, kindInteger)
+}
+
+// ParseUint returns an unsigned integer value of the specified string for the data type.
+// The leading and trailing white spaces are ignored.
+func ParseUint(dataType string, value string) (uint64, error) {
+	value = strings.TrimSpace(value)
+
+	if intType, ok := integerTypes[dataType]; ok {
+		if !intType.signed {
+			return strconv.ParseUint(value, 10, intType.bits)
+		}
+		i, err := strconv.ParseInt(value, 10, intType.bits)
+		if err != nil {
+			return 0, err
+		}
+		if i < 0 {
+			return 0, newOutOfRangeError(dataType, value)
+		}
+		return uint64(i), nil
+	}
+
+	if IsFloat(dataType) {
+		f, err := ParseFloat(dataType, value)
+		if err != nil {
+			return 0, err
+		}
+		if f != math.Trunc(f) || f < 0 || math.MaxUint64 <= f {
+			return 0, newOutOfRangeError(dataType, value)
+		}
+		return uint64(f), nil
+	}
+
+	if isGeneric(dataType) || IsString(dataType) {
+		return strconv.ParseUint(value, 10, 64)
+	}
+
+	return 0, newTypeMismatchError(dataType, kindInteger)
+}
+
+// formatFixed144 returns a string of the specified value which has no more than 4 digits to the right of the decimal point.
+func formatFixed144(v float64) (string, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) || math.Pow10(fixed144IntegerDigits) <= math.Abs(v) {
+		return "", newOutOfRangeError(Fixed144, v)
+	}
+	s := strconv.FormatFloat(v, 'f', fixed144FractionDigits, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s, nil
+}
+
+// FormatFloat returns a string of the specified floating point value for the data type.
+// The integer types accept only the integral values.
+func FormatFloat(dataType string, v float64) (string, error) {
+	switch dataType {
+	case R4:
+		if math.MaxFloat32 < math.Abs(v) && !math.IsInf(v, 0) {
+			return "", newOutOfRangeError(dataType, v)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 32), nil
+	case Fixed144:
+		return formatFixed144(v)
+	}
+
+	if intType, ok := integerTypes[dataType]; ok {
+		if v != math.Trunc(v) || math.IsInf(v, 0) || math.IsNaN(v) {
+			return "", newOutOfRangeError(dataType, v)
+		}
+		if intType.signed || v < 0 {
+			if v < math.MinInt64 || math.MaxInt64 <= v {
+				return "", newOutOfRangeError(dataType, v)
+			}
+			return FormatInt(dataType, int64(v))
+		}
+		if math.MaxUint64 <= v {
+			return "", newOutOfRangeError(dataType, v)
+		}
+		return FormatUint(dataType, uint64(v))
+	}
+
+	if IsFloat(dataType) || isGeneric(dataType) || IsString(dataType) {
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	}
+
+	return "", newTypeMismatchError(dataType, kindFloat)
+}
+
+// parseFiniteFloat returns a floating point value of the specified string, and rejects NaN and the infinities.
+func parseFiniteFloat(dataType string, value string, bitSize int) (float64, error) {
+	f, err := strconv.ParseFloat(value, bitSize)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, newOutOfRangeError(dataType, value)
+	}
+	return f, nil
+}
+
+// ParseFloat returns a floating point value of the specified string for the data type.
+// The leading and trailing white spaces are ignored, and the non-finite values, such as NaN and Inf, are rejected.
+func ParseFloat(dataType string, value string) (float64, error) {
+	value = strings.TrimSpace(value)
+
+	switch dataType {
+	case R4:
+		return parseFiniteFloat(dataType, value, 32)
+	case Fixed144:
+		return parseFixed144(value)
+	}
+
+	if intType, ok := integerTypes[dataType]; ok {
+		if intType.signed {
+			i, err := ParseInt(dataType, value)
+			return float64(i), err
+		}
+		u, err := ParseUint(dataType, value)
+		return float64(u), err
+	}
+
+	if IsFloat(dataType) || isGeneric(dataType) || IsString(dataType) {
+		return parseFiniteFloat(dataType, value, 64)
+	}
+
+	return 0, newTypeMismatchError(dataType, kindFloat)
+}
+
+// FormatBool returns a boolean string, "1" or "0", of the specified value.
+func FormatBool(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+// ParseBool returns a boolean value of the specified string which is "1", "0", "true", "false", "yes" or "no".
+func ParseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes":
+		return true, nil
+	case "0", "false", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf(errorInvalidBoolean, value)
+}
+
+// FormatTime returns a string of the specified time for the date or time data type.
+func FormatTime(dataType string, t time.Time) (string, error) {
+	layouts, ok := timeLayouts[dataType]
+	if !ok {
+		return "", newTypeMismatchError(dataType, kindTime)
+	}
+	return t.Format(layouts[0]), nil
+}
+
+// ParseTime returns a time of the specified string for the date or time data type.
+func ParseTime(dataType string, value string) (time.Time, error) {
+	layouts, ok := timeLayouts[dataType]
+	if !ok {
+		return time.Time{}, newTypeMismatchError(dataType, kindTime)
+	}
+
+	var lastErr error
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+		lastErr = err
+	}
+
+	return time.Time{}, lastErr
+}
+
+// FormatBytes returns a string of the specified bytes for the binary data type.
+func FormatBytes(dataType string, b []byte) (string, error) {
+	switch dataType {
+	case BinBase64:
+		return base64.StdEncoding.EncodeToString(b), nil
+	case BinHex:
+		return hex.EncodeToString(b), nil
+	}
+	return "", newTypeMismatchError(dataType, kindBinary)
+}
+
+// ParseBytes returns bytes of the specified string for the binary data type.
+func ParseBytes(dataType string, value string) ([]byte, error) {
+	switch dataType {
+	case BinBase64:
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	case BinHex:
+		return hex.DecodeString(strings.TrimSpace(value))
+	}
+	return nil, newTypeMismatchError(dataType, kindBinary)
+}
@@ -0,0 +1,257 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package datatype
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+const (
+	errorInvalidFormattedValue = "%s value (%v) is formatted to '%s' : expected '%s'"
+	errorInvalidParsedValue    = "%s value '%s' is parsed to %v : expected %v"
+	errorOutOfRangeAccepted    = "%s value (%v) out of range is accepted"
+	errorTypeMismatchAccepted  = "%s is accepted as %s"
+)
+
+func TestIntegerCodec(t *testing.T) {
+	intValues := []struct {
+		dataType string
+		value    int64
+		str      string
+	}{
+		{UI1, 255, "255"},
+		{I1, -128, "-128"},
+		{I2, 32767, "32767"},
+		{I4, -2147483648, "-2147483648"},
+		{I8, math.MinInt64, "-9223372036854775808"},
+		{Int, 10, "10"},
+		{UI8, math.MaxInt64, "9223372036854775807"},
+		{R8, 10, "10"},
+		{"", -1, "-1"},
+	}
+
+	for _, v := range intValues {
+		str, err := FormatInt(v.dataType, v.value)
+		if err != nil || str != v.str {
+			t.Errorf(errorInvalidFormattedValue, v.dataType, v.value, str, v.str)
+		}
+		i, err := ParseInt(v.dataType, v.str)
+		if err != nil || i != v.value {
+			t.Errorf(errorInvalidParsedValue, v.dataType, v.str, i, v.value)
+		}
+	}
+
+	outOfRanges := []struct {
+		dataType string
+		value    int64
+	}{
+		{UI1, 256},
+		{UI1, -1},
+		{UI4, -1},
+		{I1, 128},
+		{I2, -32769},
+		{I4, 2147483648},
+	}
+
+	for _, v := range outOfRanges {
+		if _, err := FormatInt(v.dataType, v.value); err == nil {
+			t.Errorf(errorOutOfRangeAccepted, v.dataType, v.value)
+		}
+	}
+
+	u, err := ParseUint(UI8, "18446744073709551615")
+	if err != nil || u != math.MaxUint64 {
+		t.Errorf(errorInvalidParsedValue, UI8, "18446744073709551615", u, uint64(math.MaxUint64))
+	}
+	if _, err := ParseInt(UI8, "18446744073709551615"); err == nil {
+		t.Errorf(errorOutOfRangeAccepted, UI8, "18446744073709551615")
+	}
+	if _, err := FormatUint(I1, 128); err == nil {
+		t.Errorf(errorOutOfRangeAccepted, I1, 128)
+	}
+	if _, err := FormatInt(Boolean, 1); err == nil {
+		t.Errorf(errorTypeMismatchAccepted, Boolean, "integer")
+	}
+
+	// white spaces
+
+	spacedValues := []struct {
+		dataType string
+		str      string
+		value    int64
+	}{
+		{UI1, " 255 ", 255},
+		{I4, "\t-10\n", -10},
+		{Int, " 1", 1},
+		{R8, "2 ", 2},
+	}
+
+	for _, v := range spacedValues {
+		i, err := ParseInt(v.dataType, v.str)
+		if err != nil || i != v.value {
+			t.Errorf(errorInvalidParsedValue, v.dataType, v.str, i, v.value)
+		}
+		if v.value < 0 {
+			continue
+		}
+		u, err := ParseUint(v.dataType, v.str)
+		if err != nil || u != uint64(v.value) {
+			t.Errorf(errorInvalidParsedValue, v.dataType, v.str, u, v.value)
+		}
+	}
+}
+
+func TestFloatCodec(t *testing.T) {
+	floatValues := []struct {
+		dataType string
+		value    float64
+		str      string
+	}{
+		{R4, 1.5, "1.5"},
+		{R8, 0.1, "0.1"},
+		{Number, -2.25, "-2.25"},
+		{Fixed144, 12.5, "12.5"},
+		{Fixed144, 1.23456, "1.2346"},
+		{Fixed144, 3, "3"},
+		{UI2, 100, "100"},
+	}
+
+	for _, v := range floatValues {
+		str, err := FormatFloat(v.dataType, v.value)
+		if err != nil || str != v.str {
+			t.Errorf(errorInvalidFormattedValue, v.dataType, v.value, str, v.str)
+		}
+	}
+
+	outOfRanges := []struct {
+		dataType string
+		value    float64
+	}{
+		{R4, math.MaxFloat64},
+		{Fixed144, 1e15},
+		{UI1, 1.5},
+		{UI1, 256},
+		{I4, -2147483649},
+	}
+
+	for _, v := range outOfRanges {
+		if _, err := FormatFloat(v.dataType, v.value); err == nil {
+			t.Errorf(errorOutOfRangeAccepted, v.dataType, v.value)
+		}
+	}
+
+	f, err := ParseFloat(Fixed144, "12.3456")
+	if err != nil || f != 12.3456 {
+		t.Errorf(errorInvalidParsedValue, Fixed144, "12.3456", f, 12.3456)
+	}
+	if _, err := ParseFloat(Fixed144, "12.34567"); err == nil {
+		t.Errorf(errorOutOfRangeAccepted, Fixed144, "12.34567")
+	}
+
+	// white spaces
+
+	for _, dataType := range []string{R4, R8, Number, Float, Fixed144, ""} {
+		f, err := ParseFloat(dataType, " 1.5\n")
+		if err != nil || f != 1.5 {
+			t.Errorf(errorInvalidParsedValue, dataType, " 1.5\n", f, 1.5)
+		}
+	}
+
+	// non-finite values
+
+	for _, dataType := range []string{R4, R8, Number, Float, Fixed144, String, ""} {
+		for _, str := range []string{"NaN", "Inf", "+Inf", "-Inf", "Infinity", " -infinity "} {
+			if f, err := ParseFloat(dataType, str); err == nil {
+				t.Errorf(errorInvalidParsedValue, dataType, str, f, "error")
+			}
+		}
+	}
+}
+
+func TestBoolCodec(t *testing.T) {
+	boolValues := map[string]bool{
+		"1":     true,
+		"true":  true,
+		"yes":   true,
+		"YES":   true,
+		"0":     false,
+		"false": false,
+		"no":    false,
+	}
+
+	for str, value := range boolValues {
+		b, err := ParseBool(str)
+		if err != nil || b != value {
+			t.Errorf(errorInvalidParsedValue, Boolean, str, b, value)
+		}
+	}
+
+	if _, err := ParseBool("on"); err == nil {
+		t.Errorf(errorOutOfRangeAccepted, Boolean, "on")
+	}
+
+	if FormatBool(true) != "1" || FormatBool(false) != "0" {
+		t.Errorf(errorInvalidFormattedValue, Boolean, true, FormatBool(true), "1")
+	}
+}
+
+func TestTimeCodec(t *testing.T) {
+	loc := time.FixedZone("", 9*60*60)
+	tm := time.Date(2015, 1, 2, 3, 4, 5, 0, loc)
+
+	timeValues := map[string]string{
+		Date:       "2015-01-02",
+		DateTime:   "2015-01-02T03:04:05",
+		DateTimeTZ: "2015-01-02T03:04:05+09:00",
+		Time:       "03:04:05",
+		TimeTZ:     "03:04:05+09:00",
+	}
+
+	for dataType, expected := range timeValues {
+		str, err := FormatTime(dataType, tm)
+		if err != nil || str != expected {
+			t.Errorf(errorInvalidFormattedValue, dataType, tm, str, expected)
+		}
+		if _, err := ParseTime(dataType, str); err != nil {
+			t.Errorf(errorInvalidParsedValue, dataType, str, err, tm)
+		}
+	}
+
+	parsed, err := ParseTime(DateTimeTZ, "2015-01-02T03:04:05+09:00")
+	if err != nil || !parsed.Equal(tm) {
+		t.Errorf(errorInvalidParsedValue, DateTimeTZ, "2015-01-02T03:04:05+09:00", parsed, tm)
+	}
+
+	if _, err := FormatTime(String, tm); err == nil {
+		t.Errorf(errorTypeMismatchAccepted, String, "time")
+	}
+}
+
+func TestBytesCodec(t *testing.T) {
+	b := []byte("go-net-upnp")
+
+	binValues := map[string]string{
+		BinBase64: "Z28tbmV0LXVwbnA=",
+		BinHex:    "676f2d6e65742d75706e70",
+	}
+
+	for dataType, expected := range binValues {
+		str, err := FormatBytes(dataType, b)
+		if err != nil || str != expected {
+			t.Errorf(errorInvalidFormattedValue, dataType, b, str, expected)
+		}
+		parsed, err := ParseBytes(dataType, str)
+		if err != nil || !bytes.Equal(parsed, b) {
+			t.Errorf(errorInvalidParsedValue, dataType, str, parsed, b)
+		}
+	}
+
+	if _, err := FormatBytes(String, b); err == nil {
+		t.Errorf(errorTypeMismatchAccepted, String, "binary")
+	}
+}
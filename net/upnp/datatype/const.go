@@ -0,0 +1,37 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package datatype
+
+const (
+	UI1        = "ui1"
+	UI2        = "ui2"
+	UI4        = "ui4"
+	UI8        = "ui8"
+	I1         = "i1"
+	I2         = "i2"
+	I4         = "i4"
+	I8         = "i8"
+	Int        = "int"
+	R4         = "r4"
+	R8         = "r8"
+	Number     = "number"
+	Float      = "float"
+	Fixed144   = "fixed.14.4"
+	Char       = "char"
+	String     = "string"
+	Date       = "date"
+	DateTime   = "dateTime"
+	DateTimeTZ = "dateTime.tz"
+	Time       = "time"
+	TimeTZ     = "time.tz"
+	Boolean    = "boolean"
+	BinBase64  = "bin.base64"
+	BinHex     = "bin.hex"
+	URI        = "uri"
+	UUID       = "uuid"
+
+	fixed144IntegerDigits  = 14
+	fixed144FractionDigits = 4
+)
@@ -0,0 +1,8 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package datatype implements the data types of UPnP state variables for net-upnp-go.
+*/
+package datatype
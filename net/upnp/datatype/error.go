@@ -0,0 +1,13 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package datatype
+
+const (
+	errorInvalidValue   = "value (%s) is invalid for %s : %w"
+	errorInvalidFormat  = "invalid format"
+	errorOutOfRange     = "value (%v) is out of range for %s"
+	errorTypeMismatch   = "%s is not a %s type"
+	errorInvalidBoolean = "boolean (%s) is invalid"
+)
@@ -0,0 +1,82 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package datatype
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// parseFixed144 parses the specified value which has no more than 14 digits to the left of the decimal point and no more than 4 to the right.
+func parseFixed144(value string) (float64, error) {
+	f, err := parseFiniteFloat(Fixed144, value, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	digits := strings.TrimLeft(value, "+-")
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+	if fixed144IntegerDigits < len(intPart) || fixed144FractionDigits < len(fracPart) {
+		return 0, errors.New(errorInvalidFormat)
+	}
+
+	return f, nil
+}
+
+// IsNumeric returns true when the specified data type is a numeric type, otherwise false.
+func IsNumeric(dataType string) bool {
+	return IsInteger(dataType) || IsFloat(dataType)
+}
+
+// IsString returns true when the specified data type is a string type, otherwise false.
+func IsString(dataType string) bool {
+	switch dataType {
+	case Char, String, URI:
+		return true
+	}
+	return false
+}
+
+// Validate returns an error when the specified value is not valid for the data type.
+// The unknown data types accept any value.
+func Validate(dataType string, value string) error {
+	var err error
+
+	switch {
+	case IsInteger(dataType):
+		if integerTypes[dataType].signed {
+			_, err = ParseInt(dataType, value)
+		} else {
+			_, err = ParseUint(dataType, value)
+		}
+	case IsFloat(dataType):
+		_, err = ParseFloat(dataType, value)
+	case IsTime(dataType):
+		_, err = ParseTime(dataType, value)
+	case IsBinary(dataType):
+		_, err = ParseBytes(dataType, value)
+	case dataType == Boolean:
+		_, err = ParseBool(value)
+	case dataType == Char:
+		if utf8.RuneCountInString(value) != 1 {
+			err = errors.New(errorInvalidFormat)
+		}
+	case dataType == URI:
+		_, err = url.Parse(value)
+	case dataType == UUID:
+		_, err = uuid.Parse(value)
+	}
+
+	if err != nil {
+		return fmt.Errorf(errorInvalidValue, value, dataType, err)
+	}
+
+	return nil
+}
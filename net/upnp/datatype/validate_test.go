@@ -0,0 +1,79 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package datatype
+
+import (
+	"testing"
+)
+
+const (
+	errorValidValueRejected   = "valid value (%s) of %s is rejected : %s"
+	errorInvalidValueAccepted = "invalid value (%s) of %s is accepted"
+)
+
+func TestValidate(t *testing.T) {
+	validValues := map[string][]string{
+		UI1:        {"0", "255"},
+		UI2:        {"65535"},
+		UI4:        {"4294967295"},
+		UI8:        {"18446744073709551615"},
+		I1:         {"-128", "127"},
+		I2:         {"-32768"},
+		I4:         {"2147483647"},
+		I8:         {"-9223372036854775808"},
+		Int:        {"-1"},
+		R4:         {"1.5", "-3.4e38"},
+		R8:         {"1.7e308"},
+		Number:     {"0.5"},
+		Fixed144:   {"12345678901234.1234", "-1.5"},
+		Char:       {"a", "あ"},
+		String:     {"", "any"},
+		Date:       {"2015-01-02"},
+		DateTime:   {"2015-01-02", "2015-01-02T03:04:05"},
+		DateTimeTZ: {"2015-01-02T03:04:05+09:00", "2015-01-02T03:04:05Z"},
+		Time:       {"03:04:05"},
+		TimeTZ:     {"03:04:05", "03:04:05-05:00"},
+		Boolean:    {"0", "1", "true", "false", "yes", "no", "YES"},
+		BinBase64:  {"Z28tbmV0LXVwbnA="},
+		BinHex:     {"0aff"},
+		URI:        {"http://192.168.0.1/"},
+		UUID:       {"2fac1234-31f8-11b4-a222-08002b34c003"},
+		"unknown":  {"any"},
+	}
+
+	for dataType, values := range validValues {
+		for _, value := range values {
+			if err := Validate(dataType, value); err != nil {
+				t.Errorf(errorValidValueRejected, value, dataType, err)
+			}
+		}
+	}
+
+	invalidValues := map[string][]string{
+		UI1:       {"-1", "256", "a"},
+		UI2:       {"65536"},
+		UI4:       {"4294967296"},
+		I1:        {"128"},
+		I4:        {"2147483648", "1.0"},
+		R4:        {"abc"},
+		Fixed144:  {"123456789012345", "1.12345"},
+		Char:      {"", "ab"},
+		Date:      {"2015/01/02", "2015-01-02T03:04:05"},
+		DateTime:  {"2015-01-02T03:04:05Z"},
+		Time:      {"03:04"},
+		Boolean:   {"2", "on"},
+		BinBase64: {"!!"},
+		BinHex:    {"0g"},
+		UUID:      {"not-uuid"},
+	}
+
+	for dataType, values := range invalidValues {
+		for _, value := range values {
+			if err := Validate(dataType, value); err == nil {
+				t.Errorf(errorInvalidValueAccepted, value, dataType)
+			}
+		}
+	}
+}
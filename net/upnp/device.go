@@ -5,12 +5,16 @@
 package upnp
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"math/rand"
 	"net/url"
+	"sync"
+	"time"
 
+	"github.com/cybergarage/go-logger/log"
 	"github.com/cybergarage/go-net-upnp/net/upnp/http"
 	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
 	"github.com/cybergarage/go-net-upnp/net/upnp/util"
@@ -27,6 +31,7 @@ type DeviceSSDPListener interface {
 }
 
 // A DeviceActionListener represents a listener for action request.
+// The received action is a copy of the service action for each request.
 type DeviceActionListener interface {
 	ActionRequestReceived(*Action) Error
 }
@@ -52,9 +57,47 @@ type Device struct {
 	ActionListener DeviceActionListener `xml:"-"`
 	LocationURL    string               `xml:"-"`
 	DescriptionURL string               `xml:"-"`
+	LeaseTime      int                  `xml:"-"`
 
-	ssdpMcastServerList *ssdp.MulticastServerList `xml:"-"`
-	httpServer          *http.Server              `xml:"-"`
+	// UDNStrategy creates the UUID of the root device when the UDN is not specified. A random UUID is created when it is nil.
+	UDNStrategy UDNStrategy `xml:"-"`
+
+	// StateStore persists the UDN, the boot ID and the config ID across the restarts. The state is not persisted when it is nil,
+	// and the boot ID starts from 1 again when the process is restarted.
+	StateStore DeviceStateStore `xml:"-"`
+
+	// SearchPort is the port of the unicast M-SEARCH requests which is advertised by SEARCHPORT.UPNP.ORG. ssdp.Port is used when it is not positive.
+	SearchPort int `xml:"-"`
+
+	// SearchPolicy limits the source addresses, the rates and the responses of the M-SEARCH requests to respond.
+	// NewSearchPolicy is used when it is nil.
+	SearchPolicy *SearchPolicy `xml:"-"`
+
+	// NetworkCheckInterval is the interval to check the network changes. DeviceDefaultNetworkCheckInterval is used when it is not positive.
+	NetworkCheckInterval time.Duration `xml:"-"`
+
+	// MaxArgumentLength is the maximum length of the in-argument values. DeviceDefaultMaxArgumentLength is used when it is not positive.
+	MaxArgumentLength int `xml:"-"`
+
+	// InterfaceSelector selects the interfaces and the addresses to bind. The default interfaces are used when it is nil.
+	InterfaceSelector *util.InterfaceSelector `xml:"-"`
+
+	// HTTPClient is the client for the description, control and event requests to the device. The client of the root device is used,
+	// and the default client is used when it is nil. The control point sets its client to the found devices.
+	HTTPClient *http.Client `xml:"-"`
+
+	actionMiddlewares    []ActionMiddleware        `xml:"-"`
+	ssdpLock             *sync.Mutex               `xml:"-"`
+	ssdpMcastServerList  *ssdp.MulticastServerList `xml:"-"`
+	ssdpUcastServerList  *ssdp.UnicastServerList   `xml:"-"`
+	searchGuard          *searchGuard              `xml:"-"`
+	eventMcastLock       *sync.Mutex               `xml:"-"`
+	eventMcastServerList *ssdp.MulticastServerList `xml:"-"`
+	httpServer           *http.Server              `xml:"-"`
+	stopCh               chan struct{}             `xml:"-"`
+	stopWaitGroup        *sync.WaitGroup           `xml:"-"`
+	advertisementLock    *sync.Mutex               `xml:"-"`
+	advertisement        deviceAdvertisement       `xml:"-"`
 }
 
 const (
@@ -70,6 +113,10 @@ func NewDevice() *Device {
 	dev := &Device{}
 
 	dev.DeviceDescription = &DeviceDescription{}
+	dev.LeaseTime = DeviceDefaultLeaseTime
+	dev.advertisementLock = &sync.Mutex{}
+	dev.ssdpLock = &sync.Mutex{}
+	dev.eventMcastLock = &sync.Mutex{}
 
 	return dev
 }
@@ -82,11 +129,12 @@ func NewDeviceFromSSDPRequest(ssdpReq *ssdp.Request) (*Device, error) {
 	}
 
 	dev, err := NewDeviceFromDescriptionURL(descURL)
-
 	if err != nil {
-		dev.SetLocationURL(descURL)
+		return nil, err
 	}
 
+	dev.SetLocationURL(descURL)
+
 	return dev, err
 }
 
@@ -109,10 +157,21 @@ func NewDeviceFromSSDPResponse(ssdpRes *ssdp.Response) (*Device, error) {
 
 // NewDeviceFromDescriptionURL returns a device from the specified URL.
 func NewDeviceFromDescriptionURL(descURL string) (*Device, error) {
-	res, err := http.Get(descURL)
+	return NewDeviceFromDescriptionURLContext(context.Background(), descURL)
+}
+
+// NewDeviceFromDescriptionURLContext returns a device from the specified URL with the specified context.
+func NewDeviceFromDescriptionURLContext(ctx context.Context, descURL string) (*Device, error) {
+	return newDeviceFromDescriptionURLWithClient(ctx, http.GetDefaultClient(), descURL)
+}
+
+// newDeviceFromDescriptionURLWithClient returns a device from the specified URL using the specified client.
+func newDeviceFromDescriptionURLWithClient(ctx context.Context, client *http.Client, descURL string) (*Device, error) {
+	res, err := client.GetContext(ctx, descURL)
 	if err != nil {
 		return nil, err
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf(errorDeviceBadDescriptionURL, descURL, res.StatusCode)
@@ -123,11 +182,6 @@ func NewDeviceFromDescriptionURL(descURL string) (*Device, error) {
 		return nil, err
 	}
 
-	err = res.Body.Close()
-	if err != nil {
-		return nil, err
-	}
-
 	return NewDeviceFromDescription(string(devDescBytes))
 }
 
@@ -137,7 +191,11 @@ func NewDeviceFromDescription(devDesc string) (*Device, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &root.Device, nil
+	dev := &root.Device
+	dev.advertisementLock = &sync.Mutex{}
+	dev.ssdpLock = &sync.Mutex{}
+	dev.eventMcastLock = &sync.Mutex{}
+	return dev, nil
 }
 
 // SetLocationURL set a location URL of SSDP packet.
@@ -148,7 +206,7 @@ func (dev *Device) SetLocationURL(url string) error {
 
 // CreateLocationURL returns a location URL for SSDP packet.
 func (dev *Device) createLocationURLForAddress(addr string) (*url.URL, error) {
-	locationBase := fmt.Sprintf("%s://%s:%d", DeviceProtocol, addr, dev.Port)
+	locationBase := fmt.Sprintf("%s://%s", DeviceProtocol, util.JoinHostPort(addr, dev.Port))
 	url, err := util.GetAbsoluteURLFromBaseAndPath(locationBase, dev.DescriptionURL)
 	if err != nil {
 		return nil, err
@@ -215,6 +273,7 @@ func (dev *Device) DescriptionString() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	root.ConfigID = dev.GetConfigID()
 
 	descBytes, err := xml.MarshalIndent(root, "", xmlMarshallIndent)
 	if err != nil {
@@ -226,23 +285,48 @@ func (dev *Device) DescriptionString() (string, error) {
 
 // LoadServiceDescriptions loads service descriptions.
 func (dev *Device) LoadServiceDescriptions() error {
+	return dev.LoadServiceDescriptionsContext(context.Background())
+}
+
+// LoadServiceDescriptionsContext loads service descriptions with the specified context.
+func (dev *Device) LoadServiceDescriptionsContext(ctx context.Context) error {
+	return dev.loadServiceDescriptionsWithClient(ctx, dev.GetHTTPClient())
+}
+
+// loadServiceDescriptionsWithClient loads service descriptions using the specified client.
+func (dev *Device) loadServiceDescriptionsWithClient(ctx context.Context, client *http.Client) error {
 	var lastErr error
 
 	for n := range len(dev.ServiceList.Services) {
 		service := &dev.ServiceList.Services[n]
-		lastErr = service.LoadDescriptionFromSCPDURL()
+		err := service.loadDescriptionFromSCPDURLWithClient(ctx, client)
+		if err != nil {
+			lastErr = err
+		}
 	}
 
 	// Embedded devices
 
 	for n := range len(dev.DeviceList.Devices) {
 		dev := &dev.DeviceList.Devices[n]
-		lastErr = dev.LoadServiceDescriptions()
+		err := dev.loadServiceDescriptionsWithClient(ctx, client)
+		if err != nil {
+			lastErr = err
+		}
 	}
 
 	return lastErr
 }
 
+// GetHTTPClient returns the HTTP client of the root device, or the default client when it is not set.
+func (dev *Device) GetHTTPClient() *http.Client {
+	rootDev := dev.GetRootDevice()
+	if rootDev.HTTPClient == nil {
+		return http.GetDefaultClient()
+	}
+	return rootDev.HTTPClient
+}
+
 // SetUDN sets a the specified UUID with a prefix.
 func (dev *Device) SetUDN(uuid string) error {
 	dev.UDN = fmt.Sprintf("%s%s", DeviceUUIDPrefix, uuid)
@@ -281,6 +365,16 @@ func (dev *Device) GetServices() []*Service {
 	return services
 }
 
+// getAllServices returns all services of the device and the embedded devices.
+func (dev *Device) getAllServices() []*Service {
+	services := dev.GetServices()
+	for n := range len(dev.DeviceList.Devices) {
+		embeddedDev := &dev.DeviceList.Devices[n]
+		services = append(services, embeddedDev.getAllServices()...)
+	}
+	return services
+}
+
 // GetServiceByType returns a service by the specified serviceType.
 func (dev *Device) GetServiceByType(serviceType string) (*Service, error) {
 	for n := range len(dev.ServiceList.Services) {
@@ -303,10 +397,9 @@ func (dev *Device) GetServiceByID(serviceID string) (*Service, error) {
 	return nil, fmt.Errorf(errorDeviceServiceNotFound, serviceID)
 }
 
-// GetServiceByControlURL returns a service by the specified control URL.
+// GetServiceByControlURL returns a service of the device or the embedded devices by the specified control URL.
 func (dev *Device) GetServiceByControlURL(ctrlURL string) (*Service, error) {
-	for n := range len(dev.ServiceList.Services) {
-		service := &dev.ServiceList.Services[n]
+	for _, service := range dev.getAllServices() {
 		if service.ControlURL == ctrlURL {
 			return service, nil
 		}
@@ -314,10 +407,9 @@ func (dev *Device) GetServiceByControlURL(ctrlURL string) (*Service, error) {
 	return nil, fmt.Errorf(errorDeviceServiceNotFound, ctrlURL)
 }
 
-// GetServiceByEventSubURL returns a service by the specified event subscription URL.
+// GetServiceByEventSubURL returns a service of the device or the embedded devices by the specified event subscription URL.
 func (dev *Device) GetServiceByEventSubURL(eventURL string) (*Service, error) {
-	for n := range len(dev.ServiceList.Services) {
-		service := &dev.ServiceList.Services[n]
+	for _, service := range dev.getAllServices() {
 		if service.EventSubURL == eventURL {
 			return service, nil
 		}
@@ -325,6 +417,16 @@ func (dev *Device) GetServiceByEventSubURL(eventURL string) (*Service, error) {
 	return nil, fmt.Errorf(errorDeviceServiceNotFound, eventURL)
 }
 
+// getServiceBySCPDURL returns a service of the device or the embedded devices by the specified SCPD URL.
+func (dev *Device) getServiceBySCPDURL(scpdURL string) (*Service, error) {
+	for _, service := range dev.getAllServices() {
+		if service.isDescriptionURL(scpdURL) {
+			return service, nil
+		}
+	}
+	return nil, fmt.Errorf(errorDeviceServiceNotFound, scpdURL)
+}
+
 func (dev *Device) reviseParentObject() error {
 	for n := range len(dev.ServiceList.Services) {
 		service := &dev.ServiceList.Services[n]
@@ -339,48 +441,83 @@ func (dev *Device) reviseParentObject() error {
 	// Embedded devices
 
 	for n := range len(dev.DeviceList.Devices) {
-		dev := &dev.DeviceList.Devices[n]
-		dev.ParentDevice = dev
-		dev.reviseParentObject()
+		embeddedDev := &dev.DeviceList.Devices[n]
+		embeddedDev.ParentDevice = dev
+		embeddedDev.reviseParentObject()
 	}
 
 	return nil
 }
 
-// TODO : Support embedded devices.
 func (dev *Device) reviseDescription() error {
 	// check descriptionURL
 	if len(dev.DescriptionURL) == 0 {
 		dev.DescriptionURL = DeviceDefaultDescriptionURL
 	}
 
+	// check the service URLs which are specified explicitly
+
+	usedURLs := map[string]bool{}
+	for _, service := range dev.getAllServices() {
+		for _, url := range []string{service.SCPDURL, service.ControlURL, service.EventSubURL} {
+			if 0 < len(url) {
+				usedURLs[url] = true
+			}
+		}
+	}
+
+	return dev.reviseEmbeddedDescription(usedURLs)
+}
+
+// reviseEmbeddedDescription revises the UDN and the service URLs of the device and the embedded devices.
+// The default service URLs are unique in the device tree.
+func (dev *Device) reviseEmbeddedDescription(usedURLs map[string]bool) error {
 	// check UUID
 	if len(dev.UDN) == 0 {
-		dev.SetUDN(util.CreateUUID())
+		uuid, err := dev.createUUID()
+		if err != nil {
+			return err
+		}
+		dev.SetUDN(uuid)
 	}
 
 	// check description URLs in the service
 	for n := range len(dev.ServiceList.Services) {
 		service := &dev.ServiceList.Services[n]
-		service.reviseDescription()
+		service.reviseDescription(usedURLs)
+	}
+
+	// Embedded devices
+
+	for n := range len(dev.DeviceList.Devices) {
+		embeddedDev := &dev.DeviceList.Devices[n]
+		err := embeddedDev.reviseEmbeddedDescription(usedURLs)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// selectAvailableInterfaceForAddr return a interface from the specified address.
-func (dev *Device) selectAvailableInterfaceForAddr(fromAddr string) (string, error) {
-	ifi, err := util.GetAvailableInterfaceForAddr(fromAddr)
-	if err != nil {
-		return "", err
+// createUUID returns a new UUID of the device by the UDN strategy of the root device.
+// The UUIDs of the embedded devices are derived from the UDN of the root device.
+func (dev *Device) createUUID() (string, error) {
+	if dev.ParentDevice != nil {
+		return dev.createEmbeddedUDN()
 	}
 
-	ifAddr, err := util.GetInterfaceAddress(ifi)
-	if err != nil {
-		return "", err
+	strategy := dev.UDNStrategy
+	if strategy == nil {
+		strategy = NewRandomUDNStrategy()
 	}
 
-	return ifAddr, nil
+	return strategy.CreateUUID(dev)
+}
+
+// selectAvailableInterfaceForAddr return a interface from the specified address.
+func (dev *Device) selectAvailableInterfaceForAddr(fromAddr string) (string, error) {
+	return dev.InterfaceSelector.GetAvailableAddressForAddr(fromAddr)
 }
 
 // GetAbsoluteURL return a absolute URL of the specified path using URLBase or LocationURL.
@@ -421,14 +558,35 @@ func (dev *Device) StartWithPort(port int) error {
 		return err
 	}
 
+	err = dev.loadState()
+	if err != nil {
+		return err
+	}
+
 	err = dev.reviseDescription()
 	if err != nil {
 		return err
 	}
 
-	dev.ssdpMcastServerList = ssdp.NewMulticastServerList()
-	dev.ssdpMcastServerList.Listener = dev
-	err = dev.ssdpMcastServerList.Start()
+	if dev.LeaseTime <= 0 {
+		dev.LeaseTime = DeviceDefaultLeaseTime
+	}
+
+	err = dev.startSearchGuard()
+	if err != nil {
+		return err
+	}
+
+	dev.stopCh = make(chan struct{})
+	dev.stopWaitGroup = &sync.WaitGroup{}
+	if dev.ssdpLock == nil {
+		dev.ssdpLock = &sync.Mutex{}
+	}
+	if dev.eventMcastLock == nil {
+		dev.eventMcastLock = &sync.Mutex{}
+	}
+
+	err = dev.startSSDPServers()
 	if err != nil {
 		dev.Stop()
 		return err
@@ -436,6 +594,7 @@ func (dev *Device) StartWithPort(port int) error {
 
 	dev.httpServer = http.NewServer()
 	dev.httpServer.Listener = dev
+	dev.httpServer.Addresses = dev.InterfaceSelector.GetExplicitAddresses()
 	err = dev.httpServer.Start(port)
 	if err != nil {
 		dev.Stop()
@@ -444,6 +603,46 @@ func (dev *Device) StartWithPort(port int) error {
 
 	dev.Port = port
 
+	bootID := nextBootID(dev.GetBootID())
+	dev.modifyAdvertisement(func(adv *deviceAdvertisement) {
+		adv.bootID = bootID
+		adv.searchPort = dev.SearchPort
+	})
+
+	// The boot ID is saved before it is advertised not to reuse it after the process is aborted.
+
+	err = dev.saveState()
+	if err != nil {
+		log.Warnf("%s", err.Error())
+	}
+
+	err = dev.startSearchServers()
+	if err != nil {
+		dev.Stop()
+		return err
+	}
+
+	err = dev.startMulticastEventServers()
+	if err != nil {
+		dev.Stop()
+		return err
+	}
+
+	err = dev.Announce()
+	if err != nil {
+		dev.Stop()
+		return err
+	}
+
+	networkCheckInterval := dev.NetworkCheckInterval
+	if networkCheckInterval <= 0 {
+		networkCheckInterval = DeviceDefaultNetworkCheckInterval
+	}
+
+	dev.stopWaitGroup.Go(func() {
+		dev.announceLoop(dev.stopCh, time.Duration(dev.LeaseTime)*time.Second/2, networkCheckInterval)
+	})
+
 	return nil
 }
 
@@ -453,21 +652,57 @@ func (dev *Device) Start() error {
 	return dev.StartWithPort(port)
 }
 
+// isRunning returns true when the device is started and not stopped yet, otherwise false.
+func (dev *Device) isRunning() bool {
+	if dev.stopCh == nil {
+		return false
+	}
+	select {
+	case <-dev.stopCh:
+		return false
+	default:
+		return true
+	}
+}
+
 // Stop stops this control point.
 func (dev *Device) Stop() error {
 	var lastErr error
 
-	err := dev.ssdpMcastServerList.Stop()
+	if dev.isRunning() {
+		close(dev.stopCh)
+		dev.stopWaitGroup.Wait()
+	}
+
+	err := dev.ByeBye()
+	if err != nil {
+		lastErr = err
+	}
+
+	err = dev.stopSSDPServers()
+	if err != nil {
+		lastErr = err
+	}
+
+	err = dev.stopSearchServers()
 	if err != nil {
 		lastErr = err
 	}
-	dev.ssdpMcastServerList = nil
 
-	err = dev.httpServer.Stop()
+	if dev.httpServer != nil {
+		err := dev.httpServer.Stop()
+		if err != nil {
+			lastErr = err
+		}
+		dev.httpServer = nil
+	}
+
+	err = dev.stopMulticastEventServers()
 	if err != nil {
 		lastErr = err
 	}
-	dev.httpServer = nil
+
+	dev.clearSubscribers()
 
 	return lastErr
 }
@@ -0,0 +1,166 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/http"
+)
+
+const (
+	errorDeviceActionHandlerServiceNotFound = "service (%s) is not found in the device and the embedded devices"
+)
+
+// An ActionRequest represents an action request which is received by a device.
+// The Action is a copy of the service action for each request, so the handlers can set and get the arguments concurrently.
+type ActionRequest struct {
+	ctx         context.Context
+	Action      *Action
+	HTTPRequest *http.Request
+}
+
+// newActionRequest returns a new ActionRequest for the specified action.
+func newActionRequest(httpReq *http.Request, action *Action) *ActionRequest {
+	ctx := context.Background()
+	if httpReq != nil && httpReq.Request != nil {
+		ctx = httpReq.Context()
+	}
+	return &ActionRequest{
+		ctx:         ctx,
+		Action:      action,
+		HTTPRequest: httpReq,
+	}
+}
+
+// Context returns the context of the request. The context is canceled when the client connection is closed or the request is timed out.
+func (req *ActionRequest) Context() context.Context {
+	return req.ctx
+}
+
+// WithContext returns a shallow copy of the request with the specified context.
+func (req *ActionRequest) WithContext(ctx context.Context) *ActionRequest {
+	newReq := *req
+	newReq.ctx = ctx
+	return &newReq
+}
+
+// GetService returns the service of the requested action.
+func (req *ActionRequest) GetService() *Service {
+	if req.Action == nil {
+		return nil
+	}
+	return req.Action.ParentService
+}
+
+// GetRemoteAddress returns the remote address of the request, or an empty string when it is unknown.
+func (req *ActionRequest) GetRemoteAddress() string {
+	if req.HTTPRequest == nil || req.HTTPRequest.Request == nil {
+		return ""
+	}
+	return req.HTTPRequest.RemoteAddr
+}
+
+// An ActionHandler represents a handler for action requests.
+type ActionHandler interface {
+	HandleAction(*ActionRequest) Error
+}
+
+// The ActionHandlerFunc type is an adapter to allow the use of ordinary functions as action handlers.
+type ActionHandlerFunc func(*ActionRequest) Error
+
+// HandleAction calls f(req).
+func (f ActionHandlerFunc) HandleAction(req *ActionRequest) Error {
+	return f(req)
+}
+
+// An ActionMiddleware represents a function which wraps an action handler.
+type ActionMiddleware func(ActionHandler) ActionHandler
+
+// newActionListenerHandler returns an action handler which calls the specified action listener.
+func newActionListenerHandler(listener DeviceActionListener) ActionHandler {
+	return ActionHandlerFunc(func(req *ActionRequest) Error {
+		return listener.ActionRequestReceived(req.Action)
+	})
+}
+
+// getServicesByTypeOrID returns the services of the device and the embedded devices which have the specified service type or ID.
+func (dev *Device) getServicesByTypeOrID(service string) []*Service {
+	services := []*Service{}
+	for _, s := range dev.getAllServices() {
+		if s.ServiceType == service || s.ServiceID == service {
+			services = append(services, s)
+		}
+	}
+	return services
+}
+
+// SetServiceActionHandler sets the handler for all actions of the specified service type or ID.
+// The services of the embedded devices are included.
+func (dev *Device) SetServiceActionHandler(service string, handler ActionHandler) error {
+	services := dev.getServicesByTypeOrID(service)
+	if len(services) == 0 {
+		return fmt.Errorf(errorDeviceActionHandlerServiceNotFound, service)
+	}
+	for _, s := range services {
+		s.actionHandler = handler
+	}
+	return nil
+}
+
+// SetActionHandler sets the handler for the specified action of the specified service type or ID.
+// The action handler has priority over the service action handler.
+func (dev *Device) SetActionHandler(service string, actionName string, handler ActionHandler) error {
+	services := dev.getServicesByTypeOrID(service)
+	if len(services) == 0 {
+		return fmt.Errorf(errorDeviceActionHandlerServiceNotFound, service)
+	}
+	for _, s := range services {
+		if _, err := s.GetActionByName(actionName); err != nil {
+			return err
+		}
+	}
+	for _, s := range services {
+		if s.actionHandlers == nil {
+			s.actionHandlers = map[string]ActionHandler{}
+		}
+		s.actionHandlers[actionName] = handler
+	}
+	return nil
+}
+
+// Use appends the specified middlewares to the action handler chain.
+// The first middleware is the outermost, and the middlewares are applied to every action handler and ActionListener.
+func (dev *Device) Use(middlewares ...ActionMiddleware) {
+	dev.actionMiddlewares = append(dev.actionMiddlewares, middlewares...)
+}
+
+// getActionHandler returns the handler for the specified action with the middlewares, or nil when no handler is set.
+func (dev *Device) getActionHandler(action *Action) ActionHandler {
+	var handler ActionHandler
+
+	if service := action.ParentService; service != nil {
+		if h, ok := service.actionHandlers[action.Name]; ok {
+			handler = h
+		} else if service.actionHandler != nil {
+			handler = service.actionHandler
+		}
+	}
+
+	if handler == nil && dev.ActionListener != nil {
+		handler = newActionListenerHandler(dev.ActionListener)
+	}
+
+	if handler == nil {
+		return nil
+	}
+
+	for n := len(dev.actionMiddlewares) - 1; 0 <= n; n-- {
+		handler = dev.actionMiddlewares[n](handler)
+	}
+
+	return handler
+}
@@ -0,0 +1,179 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+const (
+	errorActionHandlerNotFound        = "action handler (%s) is not found"
+	errorActionHandlerInvalidCode     = "action handler (%s) error code = %d : expected %d"
+	errorActionHandlerServiceAccepted = "action handler for the unknown service (%s) is accepted"
+	errorActionHandlerInvalidOrder    = "middleware order = %v : expected %v"
+)
+
+func newTestActionHandler(code int) ActionHandler {
+	return ActionHandlerFunc(func(req *ActionRequest) Error {
+		if code == 0 {
+			return nil
+		}
+		return NewErrorFromCode(code)
+	})
+}
+
+func checkActionHandlerCode(t *testing.T, dev *Device, action *Action, expectedCode int) {
+	t.Helper()
+
+	handler := dev.getActionHandler(action)
+	if handler == nil {
+		t.Errorf(errorActionHandlerNotFound, action.Name)
+		return
+	}
+
+	code := 0
+	if upnpErr := handler.HandleAction(newActionRequest(nil, action)); upnpErr != nil {
+		code = upnpErr.GetCode()
+	}
+	if code != expectedCode {
+		t.Errorf(errorActionHandlerInvalidCode, action.Name, code, expectedCode)
+	}
+}
+
+func TestDeviceActionHandler(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.reviseParentObject()
+
+	service, _ := dev.GetSwitchPowerService()
+	setAction, _ := dev.GetSwitchPowerSetTargetAction()
+	getAction, _ := dev.GetSwitchPowerGetTargetAction()
+	optAction, _ := dev.GetOptionalAction()
+
+	// ActionListener
+
+	checkActionHandlerCode(t, dev.Device, optAction, ErrorOptionalActionNotImplemented)
+
+	// service handler
+
+	err = dev.SetServiceActionHandler(service.ServiceType, newTestActionHandler(ErrorOutOfMemory))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkActionHandlerCode(t, dev.Device, setAction, ErrorOutOfMemory)
+
+	// action handler
+
+	err = dev.SetActionHandler(service.ServiceID, getAction.Name, newTestActionHandler(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkActionHandlerCode(t, dev.Device, getAction, 0)
+	checkActionHandlerCode(t, dev.Device, setAction, ErrorOutOfMemory)
+
+	// unknown service and action
+
+	unknownService := "urn:schemas-upnp-org:service:Unknown:1"
+	if err := dev.SetServiceActionHandler(unknownService, newTestActionHandler(0)); err == nil {
+		t.Errorf(errorActionHandlerServiceAccepted, unknownService)
+	}
+	if err := dev.SetActionHandler(service.ServiceType, "Unknown", newTestActionHandler(0)); err == nil {
+		t.Errorf(errorActionHandlerServiceAccepted, service.ServiceType)
+	}
+}
+
+func TestDeviceEmbeddedActionHandler(t *testing.T) {
+	testDev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dev := NewDevice()
+	dev.DeviceList.Devices = []Device{*testDev.Device}
+	dev.reviseParentObject()
+
+	embeddedDev := &dev.DeviceList.Devices[0]
+	service, err := embeddedDev.GetServiceByType("urn:schemas-upnp-org:service:SwitchPower:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	action, err := service.GetActionByName(SetTarget)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dev.getActionHandler(action) != nil {
+		t.Errorf(errorActionHandlerInvalidCode, action.Name, 0, ErrorOptionalActionNotImplemented)
+	}
+
+	err = dev.SetActionHandler(service.ServiceType, action.Name, newTestActionHandler(ErrorHumanInterventionRequired))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkActionHandlerCode(t, dev, action, ErrorHumanInterventionRequired)
+}
+
+func TestDeviceActionMiddleware(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.reviseParentObject()
+
+	service, _ := dev.GetSwitchPowerService()
+	setAction, _ := dev.GetSwitchPowerSetTargetAction()
+	getAction, _ := dev.GetSwitchPowerGetTargetAction()
+	optAction, _ := dev.GetOptionalAction()
+
+	// order
+
+	order := []string{}
+	newOrderMiddleware := func(name string) ActionMiddleware {
+		return func(next ActionHandler) ActionHandler {
+			return ActionHandlerFunc(func(req *ActionRequest) Error {
+				order = append(order, name)
+				return next.HandleAction(req)
+			})
+		}
+	}
+
+	dev.Use(NewLoggingActionMiddleware(), newOrderMiddleware("first"), newOrderMiddleware("second"))
+	checkActionHandlerCode(t, dev.Device, setAction, 0)
+	if expected := []string{"first", "second"}; !slices.Equal(order, expected) {
+		t.Errorf(errorActionHandlerInvalidOrder, order, expected)
+	}
+
+	// recovery
+
+	dev.Use(NewRecoveryActionMiddleware())
+	dev.SetActionHandler(service.ServiceType, optAction.Name, ActionHandlerFunc(func(req *ActionRequest) Error {
+		panic(req.Action.Name)
+	}))
+	checkActionHandlerCode(t, dev.Device, optAction, ErrorActionFailed)
+
+	// timeout
+
+	dev.Use(NewTimeoutActionMiddleware(0, map[string]time.Duration{getAction.Name: 10 * time.Millisecond}))
+	dev.SetActionHandler(service.ServiceType, getAction.Name, ActionHandlerFunc(func(req *ActionRequest) Error {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+		}
+		return nil
+	}))
+	checkActionHandlerCode(t, dev.Device, getAction, ErrorActionFailed)
+	checkActionHandlerCode(t, dev.Device, setAction, 0)
+
+	// authorization
+
+	dev.Use(NewAuthorizationActionMiddleware(func(req *ActionRequest) bool {
+		return req.Action.Name != SetTarget
+	}))
+	checkActionHandlerCode(t, dev.Device, setAction, ErrorActionNotAuthorized)
+}
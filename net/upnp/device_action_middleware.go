@@ -0,0 +1,112 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+)
+
+// An ActionAuthorizer represents a function which returns true when the specified request is authorized.
+type ActionAuthorizer func(*ActionRequest) bool
+
+// NewLoggingActionMiddleware returns a middleware which logs the action requests and the results.
+func NewLoggingActionMiddleware() ActionMiddleware {
+	return func(next ActionHandler) ActionHandler {
+		return ActionHandlerFunc(func(req *ActionRequest) Error {
+			start := time.Now()
+			upnpErr := next.HandleAction(req)
+			elapsed := time.Since(start)
+			serviceType := ""
+			if service := req.GetService(); service != nil {
+				serviceType = service.ServiceType
+			}
+			if upnpErr != nil {
+				log.Warnf("action %s (%s) from %s failed (%s) : %s", req.Action.Name, serviceType, req.GetRemoteAddress(), elapsed, upnpErr.Error())
+				return upnpErr
+			}
+			log.Infof("action %s (%s) from %s (%s)", req.Action.Name, serviceType, req.GetRemoteAddress(), elapsed)
+			return nil
+		})
+	}
+}
+
+// recoverAction returns an ErrorActionFailed error when the specified value is recovered from a panic, otherwise nil.
+func recoverAction(req *ActionRequest, r any) Error {
+	if r == nil {
+		return nil
+	}
+	log.Warnf("action %s panicked : %v\n%s", req.Action.Name, r, debug.Stack())
+	return NewErrorFromCode(ErrorActionFailed)
+}
+
+// NewRecoveryActionMiddleware returns a middleware which recovers a panic of the handler and returns ErrorActionFailed (501).
+func NewRecoveryActionMiddleware() ActionMiddleware {
+	return func(next ActionHandler) ActionHandler {
+		return ActionHandlerFunc(func(req *ActionRequest) (upnpErr Error) {
+			defer func() {
+				if err := recoverAction(req, recover()); err != nil {
+					upnpErr = err
+				}
+			}()
+			return next.HandleAction(req)
+		})
+	}
+}
+
+// NewAuthorizationActionMiddleware returns a middleware which returns ErrorActionNotAuthorized (606) when the authorizer rejects the request.
+func NewAuthorizationActionMiddleware(authorizer ActionAuthorizer) ActionMiddleware {
+	return func(next ActionHandler) ActionHandler {
+		return ActionHandlerFunc(func(req *ActionRequest) Error {
+			if !authorizer(req) {
+				return NewErrorFromCode(ErrorActionNotAuthorized)
+			}
+			return next.HandleAction(req)
+		})
+	}
+}
+
+// NewTimeoutActionMiddleware returns a middleware which returns ErrorActionFailed (501) when the handler doesn't return within the timeout.
+// The timeouts are specified by the action names, and the default timeout is used for the other actions. No timeout is set when the timeout is not positive.
+// The context of the request is canceled on the timeout, and a panic of the handler is recovered as ErrorActionFailed.
+func NewTimeoutActionMiddleware(defaultTimeout time.Duration, actionTimeouts map[string]time.Duration) ActionMiddleware {
+	return func(next ActionHandler) ActionHandler {
+		return ActionHandlerFunc(func(req *ActionRequest) Error {
+			timeout, ok := actionTimeouts[req.Action.Name]
+			if !ok {
+				timeout = defaultTimeout
+			}
+			if timeout <= 0 {
+				return next.HandleAction(req)
+			}
+
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+
+			resCh := make(chan Error, 1)
+			go func() {
+				var upnpErr Error
+				defer func() {
+					if err := recoverAction(req, recover()); err != nil {
+						upnpErr = err
+					}
+					resCh <- upnpErr
+				}()
+				upnpErr = next.HandleAction(req.WithContext(ctx))
+			}()
+
+			select {
+			case upnpErr := <-resCh:
+				return upnpErr
+			case <-ctx.Done():
+				log.Warnf("action %s is timed out (%s)", req.Action.Name, timeout)
+				return NewErrorFromCode(ErrorActionFailed)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"encoding/xml"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+)
+
+const (
+	// maxBootID is the maximum BOOTID.UPNP.ORG which is a non-negative 31-bit integer.
+	maxBootID = math.MaxInt32
+	// maxConfigID is the maximum CONFIGID.UPNP.ORG. The greater values are reserved.
+	maxConfigID = 16777215
+)
+
+// A deviceAdvertisement represents the last SSDP advertisement of a found device, or the advertisement of a started device.
+type deviceAdvertisement struct {
+	lastSeen   time.Time
+	maxAge     int
+	fromAddr   string
+	bootID     string
+	configID   string
+	searchPort int
+}
+
+// updateAdvertisement records the specified alive message or search response as the last advertisement.
+func (dev *Device) updateAdvertisement(pkt *ssdp.Packet) {
+	maxAge, err := pkt.GetMaxAge()
+	if err != nil || maxAge <= 0 {
+		maxAge = ssdp.DefaultMaxAge
+	}
+
+	fromAddr := ""
+	if pkt.From.IP != nil {
+		fromAddr = pkt.From.IP.String()
+	}
+
+	bootID, _ := pkt.GetBootIDUPnPOrg()
+	configID, _ := pkt.GetConfigIDUPnPOrg()
+
+	// The boot ID is changed to the next one by ssdp:update.
+	if nextBootID, err := pkt.GetNextBootIDUPnPOrg(); err == nil {
+		bootID = nextBootID
+	}
+
+	searchPort, err := pkt.GetSearchPortUPnPOrg()
+	if err != nil || searchPort <= 0 {
+		searchPort = 0
+	}
+
+	if dev.advertisementLock == nil {
+		dev.advertisementLock = &sync.Mutex{}
+	}
+
+	dev.advertisementLock.Lock()
+	defer dev.advertisementLock.Unlock()
+
+	dev.advertisement = deviceAdvertisement{
+		lastSeen:   time.Now(),
+		maxAge:     maxAge,
+		fromAddr:   fromAddr,
+		bootID:     bootID,
+		configID:   configID,
+		searchPort: searchPort,
+	}
+}
+
+// modifyAdvertisement updates the advertisement of the device with the specified function.
+func (dev *Device) modifyAdvertisement(update func(adv *deviceAdvertisement)) {
+	if dev.advertisementLock == nil {
+		dev.advertisementLock = &sync.Mutex{}
+	}
+
+	dev.advertisementLock.Lock()
+	defer dev.advertisementLock.Unlock()
+
+	update(&dev.advertisement)
+}
+
+// getAdvertisement returns the last advertisement of the device.
+func (dev *Device) getAdvertisement() deviceAdvertisement {
+	if dev.advertisementLock == nil {
+		return deviceAdvertisement{}
+	}
+
+	dev.advertisementLock.Lock()
+	defer dev.advertisementLock.Unlock()
+
+	return dev.advertisement
+}
+
+// GetLastSeen returns the time when the last advertisement of the device was received.
+func (dev *Device) GetLastSeen() time.Time {
+	return dev.getAdvertisement().lastSeen
+}
+
+// GetMaxAge returns the max-age seconds of the last advertisement of the device.
+func (dev *Device) GetMaxAge() int {
+	return dev.getAdvertisement().maxAge
+}
+
+// GetSourceAddress returns the address which the last advertisement of the device was received from.
+func (dev *Device) GetSourceAddress() string {
+	return dev.getAdvertisement().fromAddr
+}
+
+// GetBootID returns the BOOTID.UPNP.ORG of the last advertisement of the found device, or the current one of the started device.
+func (dev *Device) GetBootID() string {
+	return dev.getAdvertisement().bootID
+}
+
+// GetConfigID returns the CONFIGID.UPNP.ORG of the last advertisement of the found device, or the current one of the started device.
+func (dev *Device) GetConfigID() string {
+	return dev.getAdvertisement().configID
+}
+
+// GetSearchPort returns the SEARCHPORT.UPNP.ORG of the last advertisement of the found device, or the one of the started device.
+// ssdp.Port is returned when the port is not advertised.
+func (dev *Device) GetSearchPort() int {
+	searchPort := dev.getAdvertisement().searchPort
+	if searchPort <= 0 {
+		return ssdp.Port
+	}
+	return searchPort
+}
+
+// nextBootID returns the boot ID which follows the specified one. The boot ID is a counter which starts from 1,
+// and it wraps to 0 only after maxBootID. It is kept increasing over the restarts of the process by StateStore.
+func nextBootID(bootID string) string {
+	prevID, err := strconv.ParseInt(bootID, 10, 64)
+	if err != nil || prevID < 0 {
+		prevID = 0
+	}
+	if maxBootID <= prevID {
+		return "0"
+	}
+	return strconv.FormatInt(prevID+1, 10)
+}
+
+// newConfigID returns a config ID which is the hash of the device description and the service descriptions.
+func (dev *Device) newConfigID() (string, error) {
+	root, err := NewDeviceDescriptionRootFromDevice(dev)
+	if err != nil {
+		return "", err
+	}
+
+	descBytes, err := xml.Marshal(root)
+	if err != nil {
+		return "", err
+	}
+
+	hash := fnv.New32a()
+	hash.Write(descBytes)
+	for _, service := range dev.getAllServices() {
+		serviceDesc, err := service.DescriptionString()
+		if err != nil {
+			continue
+		}
+		hash.Write([]byte(serviceDesc))
+	}
+
+	return strconv.FormatUint(uint64(hash.Sum32()%(maxConfigID+1)), 10), nil
+}
+
+// updateConfigID updates the config ID of the started device, and returns true when the descriptions are changed.
+func (dev *Device) updateConfigID() (bool, error) {
+	configID, err := dev.newConfigID()
+	if err != nil {
+		return false, err
+	}
+
+	isChanged := false
+	dev.modifyAdvertisement(func(adv *deviceAdvertisement) {
+		isChanged = adv.configID != configID
+		adv.configID = configID
+	})
+
+	return isChanged, nil
+}
+
+// setAdvertisementHeaders sets BOOTID.UPNP.ORG, CONFIGID.UPNP.ORG and SEARCHPORT.UPNP.ORG of the started device to the specified packet.
+func (dev *Device) setAdvertisementHeaders(pkt *ssdp.Packet) {
+	adv := dev.getAdvertisement()
+	if 0 < len(adv.bootID) {
+		pkt.SetBootIDUPnPOrg(adv.bootID)
+	}
+	if 0 < len(adv.configID) {
+		pkt.SetConfigIDUPnPOrg(adv.configID)
+	}
+	if 0 < adv.searchPort && adv.searchPort != ssdp.Port {
+		pkt.SetSearchPortUPnPOrg(adv.searchPort)
+	}
+}
+
+// GetExpirationTime returns the time when the last advertisement of the device expires.
+func (dev *Device) GetExpirationTime() time.Time {
+	adv := dev.getAdvertisement()
+	if adv.lastSeen.IsZero() {
+		return time.Time{}
+	}
+	return adv.lastSeen.Add(time.Duration(adv.maxAge) * time.Second)
+}
+
+// IsExpired returns true when the last advertisement of the device is expired at the specified time.
+// Devices which are not found by SSDP never expire.
+func (dev *Device) IsExpired(now time.Time) bool {
+	expirationTime := dev.GetExpirationTime()
+	if expirationTime.IsZero() {
+		return false
+	}
+	return expirationTime.Before(now)
+}
@@ -16,6 +16,7 @@ const (
 // A DeviceDescriptionRoot represents a root UPnP device description.
 type DeviceDescriptionRoot struct {
 	XMLName     xml.Name    `xml:"root"`
+	ConfigID    string      `xml:"configId,attr,omitempty"`
 	SpecVersion SpecVersion `xml:"specVersion"`
 	URLBase     string      `xml:"URLBase"`
 	Device      Device      `xml:"device"`
@@ -54,12 +55,14 @@ func NewDeviceDescriptionRoot() *DeviceDescriptionRoot {
 	return root
 }
 
+// NewDeviceDescriptionRootFromDevice returns a new description root which shares the description of the specified device.
+// Only the description is copied not to read the runtime states of the started device.
 func NewDeviceDescriptionRootFromDevice(dev *Device) (*DeviceDescriptionRoot, error) {
 	if dev == nil {
 		return nil, errors.New(errorDeviceDescriptionNullDevice)
 	}
 	root := NewDeviceDescriptionRoot()
-	root.Device = (*dev)
+	root.Device.DeviceDescription = dev.DeviceDescription
 	return root, nil
 }
 
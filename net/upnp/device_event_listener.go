@@ -0,0 +1,167 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"net"
+	gohttp "net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/event"
+	"github.com/cybergarage/go-net-upnp/net/upnp/http"
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
+)
+
+func responsePreconditionFailed(httpRes http.ResponseWriter) error {
+	writeServerHeader(httpRes)
+	writeStatusCode(httpRes, http.StatusPreconditionFailed)
+	return nil
+}
+
+func responseSubscriptionAccepted(httpRes http.ResponseWriter, sub *Subscriber) error {
+	header := httpRes.Header()
+	http.SetRawHeader(header, http.SID, sub.SID)
+	http.SetRawHeader(header, http.Timeout, event.FormatTimeout(sub.GetTimeout()))
+	header.Set(http.DateHeader, time.Now().UTC().Format(gohttp.TimeFormat))
+	header.Set(http.ContentLength, "0")
+	writeServerHeader(httpRes)
+	writeStatusCode(httpRes, http.StatusOK)
+
+	// Flush the response to deliver the initial event after the subscription response.
+	if flusher, ok := httpRes.(gohttp.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+// reviseCallbackURLZones adds the zone of the specified remote address to the IPv6 link-local callback URLs.
+func reviseCallbackURLZones(callbackURLs []*url.URL, remoteAddr string) {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return
+	}
+
+	_, zone, ok := strings.Cut(host, "%")
+	if !ok {
+		return
+	}
+
+	for n, callbackURL := range callbackURLs {
+		revisedURL, err := url.Parse(util.ReviseURLZone(callbackURL.String(), zone))
+		if err != nil {
+			continue
+		}
+		callbackURLs[n] = revisedURL
+	}
+}
+
+func getSubscriptionTimeout(httpReq *http.Request) int {
+	timeout, err := event.ParseTimeout(httpReq.Header.Get(http.Timeout))
+	if err != nil {
+		return event.DefaultTimeout
+	}
+	return timeout
+}
+
+func (dev *Device) httpSubscribeRequestReceived(httpReq *http.Request, httpRes http.ResponseWriter) bool {
+	service, err := dev.GetServiceByEventSubURL(httpReq.URL.Path)
+	if err != nil || service.subscribers == nil {
+		return false
+	}
+
+	sid := httpReq.Header.Get(http.SID)
+	nt := httpReq.Header.Get(http.NT)
+	callback := httpReq.Header.Get(http.Callback)
+
+	// Renewal
+
+	if 0 < len(sid) {
+		if 0 < len(nt) || 0 < len(callback) {
+			responseBadRequest(httpRes)
+			return true
+		}
+
+		sub, ok := service.subscribers.GetSubscriber(sid)
+		if !ok {
+			responsePreconditionFailed(httpRes)
+			return true
+		}
+
+		sub.Renew(getSubscriptionTimeout(httpReq))
+		responseSubscriptionAccepted(httpRes, sub)
+
+		log.Tracef("subscription renewed : %s (%s)", sub.SID, service.ServiceType)
+
+		return true
+	}
+
+	// New subscription
+
+	if nt != event.NT {
+		responsePreconditionFailed(httpRes)
+		return true
+	}
+
+	callbackURLs, err := event.ParseCallback(callback)
+	if err != nil {
+		responsePreconditionFailed(httpRes)
+		return true
+	}
+	reviseCallbackURLZones(callbackURLs, httpReq.RemoteAddr)
+
+	sub := NewSubscriber(callbackURLs, getSubscriptionTimeout(httpReq))
+	err = service.addSubscriber(sub)
+	if err != nil {
+		log.Warnf("%s", err.Error())
+		responseInternalServerError(httpRes)
+		return true
+	}
+	responseSubscriptionAccepted(httpRes, sub)
+	sub.start()
+
+	log.Tracef("subscription added : %s (%s)", sub.SID, service.ServiceType)
+
+	return true
+}
+
+func (dev *Device) httpUnsubscribeRequestReceived(httpReq *http.Request, httpRes http.ResponseWriter) bool {
+	service, err := dev.GetServiceByEventSubURL(httpReq.URL.Path)
+	if err != nil || service.subscribers == nil {
+		return false
+	}
+
+	sid := httpReq.Header.Get(http.SID)
+	if len(httpReq.Header.Get(http.NT)) != 0 || len(httpReq.Header.Get(http.Callback)) != 0 {
+		responseBadRequest(httpRes)
+		return true
+	}
+
+	if len(sid) == 0 || !service.subscribers.RemoveSubscriber(sid) {
+		responsePreconditionFailed(httpRes)
+		return true
+	}
+
+	httpRes.Header().Set(http.ContentLength, "0")
+	writeServerHeader(httpRes)
+	writeStatusCode(httpRes, http.StatusOK)
+
+	log.Tracef("subscription removed : %s (%s)", sid, service.ServiceType)
+
+	return true
+}
+
+// clearSubscribers stops all subscriptions of the services.
+func (dev *Device) clearSubscribers() {
+	for _, service := range dev.getAllServices() {
+		if service.subscribers == nil {
+			continue
+		}
+		service.subscribers.Clear()
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/event"
+)
+
+const (
+	errorTestEventInvalidStatusCode = "%s status code = %d : expected %d"
+	errorTestEventInvalidNotify     = "invalid event notify (%s) = '%s' : expected '%s'"
+	errorTestEventNotifyNotReceived = "event notify (SEQ = %d) is not received"
+)
+
+type testEventNotify struct {
+	sid string
+	seq string
+	set *event.PropertySet
+}
+
+func newTestEventCallbackServer(t *testing.T) (*httptest.Server, chan *testEventNotify) {
+	t.Helper()
+
+	notifyCh := make(chan *testEventNotify, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		set, err := event.NewPropertySetFromBytes(body)
+		if err != nil || r.Method != "NOTIFY" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		notifyCh <- &testEventNotify{sid: r.Header.Get("SID"), seq: r.Header.Get("SEQ"), set: set}
+	}))
+
+	return server, notifyCh
+}
+
+func sendTestEventRequest(t *testing.T, method string, url string, headers map[string]string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range headers {
+		req.Header[name] = []string{value}
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	return res
+}
+
+func waitTestEventNotify(t *testing.T, notifyCh chan *testEventNotify, sid string, seq int) *testEventNotify {
+	t.Helper()
+
+	select {
+	case notify := <-notifyCh:
+		if notify.sid != sid {
+			t.Errorf(errorTestEventInvalidNotify, "SID", notify.sid, sid)
+		}
+		if notify.seq != fmt.Sprintf("%d", seq) {
+			t.Errorf(errorTestEventInvalidNotify, "SEQ", notify.seq, fmt.Sprintf("%d", seq))
+		}
+		return notify
+	case <-time.After(3 * time.Second):
+		t.Fatalf(errorTestEventNotifyNotReceived, seq)
+	}
+
+	return nil
+}
+
+func TestDeviceEventSubscription(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	callbackServer, notifyCh := newTestEventCallbackServer(t)
+	defer callbackServer.Close()
+
+	service, _ := dev.GetSwitchPowerService()
+	eventURL := fmt.Sprintf("http://localhost:%d%s", dev.Port, service.EventSubURL)
+
+	// invalid subscriptions
+
+	res := sendTestEventRequest(t, "SUBSCRIBE", eventURL, map[string]string{"NT": "upnp:bad", "CALLBACK": "<" + callbackServer.URL + ">"})
+	if res.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf(errorTestEventInvalidStatusCode, "SUBSCRIBE", res.StatusCode, http.StatusPreconditionFailed)
+	}
+
+	res = sendTestEventRequest(t, "SUBSCRIBE", eventURL, map[string]string{"NT": event.NT})
+	if res.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf(errorTestEventInvalidStatusCode, "SUBSCRIBE", res.StatusCode, http.StatusPreconditionFailed)
+	}
+
+	// subscribe
+
+	res = sendTestEventRequest(t, "SUBSCRIBE", eventURL, map[string]string{"NT": event.NT, "CALLBACK": "<" + callbackServer.URL + "/event>", "TIMEOUT": "Second-300"})
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf(errorTestEventInvalidStatusCode, "SUBSCRIBE", res.StatusCode, http.StatusOK)
+	}
+
+	sid := res.Header.Get("SID")
+	if timeout := res.Header.Get("TIMEOUT"); timeout != "Second-300" {
+		t.Errorf(errorTestEventInvalidNotify, "TIMEOUT", timeout, "Second-300")
+	}
+
+	// initial event
+
+	notify := waitTestEventNotify(t, notifyCh, sid, 0)
+	if len(notify.set.Properties) != len(service.GetStateVariables()) {
+		t.Errorf("initial event has %d properties : expected %d", len(notify.set.Properties), len(service.GetStateVariables()))
+	}
+
+	// changed events
+
+	statVar, err := service.GetStateVariableByName("Status")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n, value := range []string{"1", "0"} {
+		err = statVar.SetValue(value)
+		if err != nil {
+			t.Error(err)
+		}
+		notify = waitTestEventNotify(t, notifyCh, sid, n+1)
+		if len(notify.set.Properties) != 1 || notify.set.Properties[0].Value != value {
+			t.Errorf(errorTestEventInvalidNotify, statVar.Name, notify.set.Properties[0].Value, value)
+		}
+	}
+
+	// renew
+
+	res = sendTestEventRequest(t, "SUBSCRIBE", eventURL, map[string]string{"SID": sid, "TIMEOUT": "Second-600"})
+	if res.StatusCode != http.StatusOK {
+		t.Errorf(errorTestEventInvalidStatusCode, "SUBSCRIBE", res.StatusCode, http.StatusOK)
+	}
+
+	res = sendTestEventRequest(t, "SUBSCRIBE", eventURL, map[string]string{"SID": sid, "NT": event.NT})
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf(errorTestEventInvalidStatusCode, "SUBSCRIBE", res.StatusCode, http.StatusBadRequest)
+	}
+
+	// unsubscribe
+
+	res = sendTestEventRequest(t, "UNSUBSCRIBE", eventURL, map[string]string{"SID": sid})
+	if res.StatusCode != http.StatusOK {
+		t.Errorf(errorTestEventInvalidStatusCode, "UNSUBSCRIBE", res.StatusCode, http.StatusOK)
+	}
+
+	res = sendTestEventRequest(t, "UNSUBSCRIBE", eventURL, map[string]string{"SID": sid})
+	if res.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf(errorTestEventInvalidStatusCode, "UNSUBSCRIBE", res.StatusCode, http.StatusPreconditionFailed)
+	}
+
+	if len(service.GetSubscribers()) != 0 {
+		t.Errorf("%d subscribers remain", len(service.GetSubscribers()))
+	}
+}
+
+func TestSubscriberExpiration(t *testing.T) {
+	list := NewSubscriberList()
+
+	sub := NewSubscriber(nil, 1)
+	list.AddSubscriber(sub)
+
+	if _, ok := list.GetSubscriber(sub.SID); !ok {
+		t.Errorf("subscriber (%s) is not found", sub.SID)
+	}
+
+	sub.expirationTime = time.Now().Add(-time.Second)
+
+	if _, ok := list.GetSubscriber(sub.SID); ok {
+		t.Errorf("subscriber (%s) is not expired", sub.SID)
+	}
+}
@@ -96,28 +96,30 @@ func (dev *Device) httpGetRequestReceived(httpReq *http.Request, httpRes http.Re
 	}
 
 	// Service Description ?
-	for n := range len(dev.ServiceList.Services) {
-		service := &dev.ServiceList.Services[n]
-		if service.isDescriptionURL(path) {
-			err := dev.responseServiceDescription(httpRes, service)
-			if err != nil {
-				responseInternalServerError(httpRes)
-			}
-			return true
+	if service, err := dev.getServiceBySCPDURL(path); err == nil {
+		err := dev.responseServiceDescription(httpRes, service)
+		if err != nil {
+			responseInternalServerError(httpRes)
 		}
+		return true
 	}
 
 	return false
 }
 
-func (dev *Device) httpActionRequestReceived(httpReq *http.Request, httpRes http.ResponseWriter, action *Action) error {
-	// has listener ?
+// httpActionRequestReceived runs the handler with a copy of the specified action for each request,
+// so that the concurrent requests of the same action don't share the argument values.
+func (dev *Device) httpActionRequestReceived(httpReq *http.Request, httpRes http.ResponseWriter, serviceAction *Action) error {
+	// has handler ?
 
-	if dev.ActionListener == nil {
+	handler := dev.getActionHandler(serviceAction)
+	if handler == nil {
 		upnpErr := control.NewUPnPErrorFromCode(control.ErrorOptionalActionNotImplemented)
 		return responseUPnPError(httpRes, upnpErr)
 	}
 
+	action := serviceAction.Copy()
+
 	// read request
 
 	defer httpReq.Body.Close()
@@ -138,26 +140,75 @@ func (dev *Device) httpActionRequestReceived(httpReq *http.Request, httpRes http
 		return responseUPnPError(httpRes, upnpErr)
 	}
 
+	maxLength := dev.MaxArgumentLength
+	if maxLength <= 0 {
+		maxLength = DeviceDefaultMaxArgumentLength
+	}
+
+	if upnpErr := action.validateActionRequest(actionReq, maxLength); upnpErr != nil {
+		return responseUPnPError(httpRes, upnpErr)
+	}
+
 	err = action.SetArgumentsByActionRequest(actionReq)
 	if err != nil {
 		upnpErr := control.NewUPnPErrorFromCode(control.ErrorInvalidArgs)
 		return responseUPnPError(httpRes, upnpErr)
 	}
 
-	// run listener
+	// run handler
 
-	upnpErr := dev.ActionListener.ActionRequestReceived(action)
+	upnpErr := handler.HandleAction(newActionRequest(httpReq, action))
 	if upnpErr != nil {
 		return responseUPnPError(httpRes, upnpErr)
 	}
 
-	// return listener response
+	// return handler response
 
 	actionRes, _ := NewActionResponseFromAction(action)
 	errStr, _ := actionRes.SOAPContentString()
 	return responseSuccessXMLContent(httpRes, errStr)
 }
 
+// httpQueryRequestReceived answers the QueryStateVariable request with the current value of the state variable.
+func (dev *Device) httpQueryRequestReceived(httpReq *http.Request, httpRes http.ResponseWriter, service *Service) error {
+	// read request
+
+	defer httpReq.Body.Close()
+	soapReqBytes, err := io.ReadAll(httpReq.Body)
+	if err != nil {
+		upnpErr := control.NewUPnPErrorFromCode(control.ErrorInvalidAction)
+		return responseUPnPError(httpRes, upnpErr)
+	}
+
+	log.Tracef("query req = \n%s", string(soapReqBytes))
+
+	// parse request
+
+	queryReq, err := control.NewActionRequestFromSOAPBytes(soapReqBytes)
+	if err != nil {
+		upnpErr := control.NewUPnPErrorFromCode(control.ErrorInvalidAction)
+		return responseUPnPError(httpRes, upnpErr)
+	}
+
+	varName, err := queryReq.GetQueryVariableName()
+	if err != nil {
+		upnpErr := control.NewUPnPErrorFromCode(control.ErrorInvalidArgs)
+		return responseUPnPError(httpRes, upnpErr)
+	}
+
+	statVar, err := service.GetStateVariableByName(varName)
+	if err != nil {
+		upnpErr := control.NewUPnPErrorFromCode(control.ErrorInvalidVar)
+		return responseUPnPError(httpRes, upnpErr)
+	}
+
+	// return the current value
+
+	queryRes := control.NewQueryResponse(statVar.GetValue())
+	resStr, _ := queryRes.SOAPContentString()
+	return responseSuccessXMLContent(httpRes, resStr)
+}
+
 func (dev *Device) httpSoapRequestReceived(httpReq *http.Request, httpRes http.ResponseWriter) bool {
 	ctrlURL := httpReq.URL.Path
 	service, err := dev.GetServiceByControlURL(ctrlURL)
@@ -172,7 +223,11 @@ func (dev *Device) httpSoapRequestReceived(httpReq *http.Request, httpRes http.R
 
 	action, err := service.GetActionByName(actionName)
 	if err != nil {
-		return false
+		if actionName != control.QueryStateVariable {
+			return false
+		}
+		err = dev.httpQueryRequestReceived(httpReq, httpRes, service)
+		return err == nil
 	}
 
 	err = dev.httpActionRequestReceived(httpReq, httpRes, action)
@@ -201,6 +256,16 @@ func (dev *Device) HTTPRequestReceived(httpReq *http.Request, httpRes http.Respo
 		if dev.httpPostRequestReceived(httpReq, httpRes) {
 			return
 		}
+
+	case http.SUBSCRIBE:
+		if dev.httpSubscribeRequestReceived(httpReq, httpRes) {
+			return
+		}
+
+	case http.UNSUBSCRIBE:
+		if dev.httpUnsubscribeRequestReceived(httpReq, httpRes) {
+			return
+		}
 	}
 
 	if dev.HTTPListener != nil {
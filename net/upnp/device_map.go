@@ -4,6 +4,10 @@
 
 package upnp
 
+import (
+	"time"
+)
+
 // DeviceMap manages devices by UDN.
 type DeviceMap map[string]map[string]*Device
 
@@ -112,3 +116,58 @@ func (devMap *DeviceMap) HasDevice(dev *Device) bool {
 	}
 	return devMap.HasDeviceByTypeAndUDN(dev.DeviceType, dev.UDN)
 }
+
+// FindDeviceByUDN find a device of the specified udn.
+func (devMap *DeviceMap) FindDeviceByUDN(udn string) (*Device, bool) {
+	if len(udn) == 0 {
+		return nil, false
+	}
+
+	for _, typeDevs := range *devMap {
+		dev, ok := typeDevs[udn]
+		if ok {
+			return dev, true
+		}
+	}
+
+	return nil, false
+}
+
+// RemoveDevice removes a specified device.
+func (devMap *DeviceMap) RemoveDevice(dev *Device) bool {
+	if dev == nil {
+		return false
+	}
+
+	typeDevs, ok := (*devMap)[dev.DeviceType]
+	if !ok {
+		return false
+	}
+
+	if _, ok := typeDevs[dev.UDN]; !ok {
+		return false
+	}
+
+	delete(typeDevs, dev.UDN)
+	if len(typeDevs) == 0 {
+		delete(*devMap, dev.DeviceType)
+	}
+
+	return true
+}
+
+// RemoveExpiredDevices removes devices whose advertisements are expired at the specified time, and returns the removed devices.
+func (devMap *DeviceMap) RemoveExpiredDevices(now time.Time) []*Device {
+	devs := make([]*Device, 0)
+
+	for _, dev := range devMap.GetAllDevices() {
+		if !dev.IsExpired(now) {
+			continue
+		}
+		if devMap.RemoveDevice(dev) {
+			devs = append(devs, dev)
+		}
+	}
+
+	return devs
+}
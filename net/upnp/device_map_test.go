@@ -8,6 +8,9 @@ import (
 	"fmt"
 	"math/rand"
 	"testing"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
 )
 
 const (
@@ -99,3 +102,42 @@ func TestDeviceMapCount(t *testing.T) {
 		}
 	}
 }
+
+func TestDeviceMapRemove(t *testing.T) {
+	devMap := NewDeviceMap()
+
+	dev := NewDevice()
+	dev.DeviceType = "type"
+	dev.UDN = "udn"
+	devMap.AddDevice(dev)
+
+	foundDev, ok := devMap.FindDeviceByUDN(dev.UDN)
+	if !ok || foundDev != dev {
+		t.Errorf(errorDeviceNotFound, dev.DeviceType, dev.UDN)
+	}
+
+	// Devices which are not advertised never expire
+
+	devs := devMap.RemoveExpiredDevices(time.Now().Add(time.Hour))
+	if len(devs) != 0 || devMap.Size() != 1 {
+		t.Errorf(errorDeviceMapSize, devMap.Size(), 1)
+	}
+
+	pkt := ssdp.NewPacket()
+	pkt.SetMaxAge(10)
+	dev.updateAdvertisement(pkt)
+
+	devs = devMap.RemoveExpiredDevices(time.Now())
+	if len(devs) != 0 || devMap.Size() != 1 {
+		t.Errorf(errorDeviceMapSize, devMap.Size(), 1)
+	}
+
+	devs = devMap.RemoveExpiredDevices(time.Now().Add(11 * time.Second))
+	if len(devs) != 1 || devMap.Size() != 0 {
+		t.Errorf(errorDeviceMapSize, devMap.Size(), 0)
+	}
+
+	if devMap.RemoveDevice(dev) {
+		t.Errorf(errorDeviceNotFound, dev.DeviceType, dev.UDN)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/event"
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+)
+
+// hasMulticastStateVariables returns true when the services of the device or the embedded devices have multicast state variables.
+func (dev *Device) hasMulticastStateVariables() bool {
+	for _, service := range dev.getAllServices() {
+		for _, statVar := range service.GetStateVariables() {
+			if statVar.IsMulticast() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// startMulticastEventServers joins the multicast eventing groups to send the multicast events when the device has multicast state variables.
+func (dev *Device) startMulticastEventServers() error {
+	if !dev.hasMulticastStateVariables() {
+		return nil
+	}
+
+	servers := ssdp.NewEventMulticastServerList()
+	servers.Selector = dev.InterfaceSelector
+	err := servers.Start()
+	if err != nil {
+		servers.Stop()
+		return err
+	}
+
+	dev.eventMcastLock.Lock()
+	dev.eventMcastServerList = servers
+	dev.eventMcastLock.Unlock()
+
+	return nil
+}
+
+// stopMulticastEventServers leaves the multicast eventing groups.
+func (dev *Device) stopMulticastEventServers() error {
+	if dev.eventMcastLock == nil {
+		return nil
+	}
+
+	dev.eventMcastLock.Lock()
+	defer dev.eventMcastLock.Unlock()
+
+	if dev.eventMcastServerList == nil {
+		return nil
+	}
+
+	err := dev.eventMcastServerList.Stop()
+	dev.eventMcastServerList = nil
+
+	return err
+}
+
+// restartMulticastEventServers rejoins the multicast eventing groups on the current interfaces.
+func (dev *Device) restartMulticastEventServers() error {
+	err := dev.stopMulticastEventServers()
+	if err != nil {
+		return err
+	}
+	return dev.startMulticastEventServers()
+}
+
+// postMulticastEvent multicasts the specified property set of the service on all joined interfaces.
+// The event is sent once to the default group of each interface address, which is one group for each interface and address family.
+func (dev *Device) postMulticastEvent(service *Service, lvl string, seq uint32, set *event.PropertySet) error {
+	if dev.eventMcastLock == nil {
+		return nil
+	}
+
+	dev.eventMcastLock.Lock()
+	defer dev.eventMcastLock.Unlock()
+
+	if dev.eventMcastServerList == nil {
+		return nil
+	}
+
+	content, err := set.XMLContentString()
+	if err != nil {
+		return err
+	}
+
+	usn := service.ParentDevice.UDN + usnDelim + service.ServiceType
+	ssdpReq, err := ssdp.NewPropChangeRequest(usn, service.ServiceID, lvl, seq, []byte(content))
+	if err != nil {
+		return err
+	}
+
+	log.Tracef("multicast event notify (%s:%d) = \n%s", usn, seq, content)
+
+	var lastErr error
+	for _, server := range dev.eventMcastServerList.Servers {
+		_, err := server.Write(ssdpReq)
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// nextMulticastSEQ returns the SEQ of the next multicast event of the service. It is called with the state lock of the service.
+func (service *Service) nextMulticastSEQ() uint32 {
+	seq := service.mcastSEQ
+	service.mcastSEQ = event.NextSEQ(seq)
+	return seq
+}
+
+// notifyMulticastPropertySet multicasts the specified property set of the event level with the SEQ which is assigned when the value is set.
+func (service *Service) notifyMulticastPropertySet(lvl string, seq uint32, set *event.PropertySet) {
+	if service.ParentDevice == nil {
+		return
+	}
+
+	root := service.ParentDevice.GetRootDevice()
+	err := root.postMulticastEvent(service, lvl, seq, set)
+	if err != nil {
+		log.Warnf("%s", err.Error())
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"fmt"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+)
+
+const (
+	// searchMaxSources is the maximum number of the source addresses whose request counts are kept for the rate limit.
+	searchMaxSources = 4096
+)
+
+const (
+	errorSearchPolicyBadNetwork = "allowed network (%s) is invalid"
+)
+
+// A SearchPolicy represents a policy to respond to the M-SEARCH requests, which prevents the device
+// from being used to reflect and amplify the traffic to spoofed source addresses.
+type SearchPolicy struct {
+	// AllowedNetworks is a list of CIDRs of the source addresses to respond in addition to the directly attached subnets.
+	AllowedNetworks []string
+	// AllowAnySource responds to the requests from any source addresses.
+	AllowAnySource bool
+	// RateLimit is the maximum number of the requests from a source address to respond in RateInterval. Zero means no limit.
+	RateLimit int
+	// RateInterval is the interval of RateLimit.
+	RateInterval time.Duration
+	// MaxResponses is the maximum number of the responses to a request. Zero, the default, means no limit.
+	// The responses over the limit are not sent, and the responses to ssdp:all, which include all the devices
+	// and services of the tree, are truncated in that order, so the later embedded devices and services can't be
+	// discovered by ssdp:all. Set the limit to the size of the tree or larger to respond to ssdp:all completely.
+	MaxResponses int
+}
+
+// NewSearchPolicy returns a default search policy which responds to the directly attached subnets.
+func NewSearchPolicy() *SearchPolicy {
+	policy := &SearchPolicy{
+		AllowedNetworks: []string{},
+		AllowAnySource:  false,
+		RateLimit:       DeviceDefaultSearchRateLimit,
+		RateInterval:    DeviceDefaultSearchRateInterval,
+		MaxResponses:    DeviceDefaultSearchMaxResponses,
+	}
+	return policy
+}
+
+// A SearchStats represents the counters of the M-SEARCH requests which are received by the device.
+type SearchStats struct {
+	// Received is the number of the received M-SEARCH requests.
+	Received uint64
+	// DroppedBadMAN is the number of the dropped requests whose MAN is not "ssdp:discover".
+	DroppedBadMAN uint64
+	// DroppedBadMX is the number of the dropped multicast requests whose MX is missing or invalid.
+	DroppedBadMX uint64
+	// DroppedSource is the number of the dropped requests from the source addresses which are not allowed.
+	DroppedSource uint64
+	// DroppedRateLimit is the number of the dropped requests which exceed the rate limit of the source address.
+	DroppedRateLimit uint64
+	// TruncatedResponses is the number of the responses which are not sent by MaxResponses.
+	TruncatedResponses uint64
+}
+
+// A searchSourceWindow represents the request count of a source address in the current rate interval.
+type searchSourceWindow struct {
+	start time.Time
+	count int
+}
+
+// A searchGuard applies the search policy to the received M-SEARCH requests, and counts the dropped requests.
+type searchGuard struct {
+	*sync.Mutex
+
+	policy           SearchPolicy
+	allowedNetworks  []netip.Prefix
+	attachedNetworks []netip.Prefix
+	sources          map[netip.Addr]*searchSourceWindow
+	stats            SearchStats
+}
+
+func newSearchGuard() *searchGuard {
+	guard := &searchGuard{
+		Mutex:            &sync.Mutex{},
+		policy:           *NewSearchPolicy(),
+		allowedNetworks:  make([]netip.Prefix, 0),
+		attachedNetworks: make([]netip.Prefix, 0),
+		sources:          make(map[netip.Addr]*searchSourceWindow),
+	}
+	return guard
+}
+
+// setPolicy applies the specified policy, and resets the request counts of the source addresses.
+func (guard *searchGuard) setPolicy(policy *SearchPolicy) error {
+	allowedNetworks := make([]netip.Prefix, len(policy.AllowedNetworks))
+	for n, network := range policy.AllowedNetworks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return fmt.Errorf(errorSearchPolicyBadNetwork, network)
+		}
+		allowedNetworks[n] = prefix.Masked()
+	}
+
+	guard.Lock()
+	defer guard.Unlock()
+
+	guard.policy = *policy
+	guard.allowedNetworks = allowedNetworks
+	guard.sources = make(map[netip.Addr]*searchSourceWindow)
+
+	return nil
+}
+
+// setAttachedNetworks sets the directly attached subnets whose source addresses are allowed.
+func (guard *searchGuard) setAttachedNetworks(networks []netip.Prefix) {
+	guard.Lock()
+	defer guard.Unlock()
+	guard.attachedNetworks = networks
+}
+
+// isSourceAllowed returns true when the specified source address is a loopback address, or in the attached or allowed subnets.
+func (guard *searchGuard) isSourceAllowed(addr netip.Addr) bool {
+	if guard.policy.AllowAnySource || addr.IsLoopback() {
+		return true
+	}
+	for _, networks := range [][]netip.Prefix{guard.attachedNetworks, guard.allowedNetworks} {
+		for _, network := range networks {
+			if network.Contains(addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// removeExpiredSources removes the source addresses whose rate intervals are expired.
+func (guard *searchGuard) removeExpiredSources(now time.Time) {
+	for addr, window := range guard.sources {
+		if guard.policy.RateInterval <= now.Sub(window.start) {
+			delete(guard.sources, addr)
+		}
+	}
+}
+
+// isRateLimited counts the request of the specified source address, and returns true when it exceeds the rate limit.
+// The new source addresses are also limited when too many source addresses are counted in the current interval.
+func (guard *searchGuard) isRateLimited(addr netip.Addr, now time.Time) bool {
+	if guard.policy.RateLimit <= 0 {
+		return false
+	}
+
+	window, ok := guard.sources[addr]
+	if ok && guard.policy.RateInterval <= now.Sub(window.start) {
+		window.start = now
+		window.count = 0
+	}
+
+	if !ok {
+		if searchMaxSources <= len(guard.sources) {
+			guard.removeExpiredSources(now)
+			if searchMaxSources <= len(guard.sources) {
+				return true
+			}
+		}
+		window = &searchSourceWindow{start: now}
+		guard.sources[addr] = window
+	}
+
+	window.count++
+
+	return guard.policy.RateLimit < window.count
+}
+
+// accept returns true when the device should respond to the specified M-SEARCH request, otherwise counts the dropped request.
+func (guard *searchGuard) accept(ssdpReq *ssdp.Request) bool {
+	guard.Lock()
+	defer guard.Unlock()
+
+	guard.stats.Received++
+
+	if !ssdpReq.IsDiscover() {
+		guard.stats.DroppedBadMAN++
+		return false
+	}
+
+	// The unicast requests have no MX because they are responded immediately.
+
+	if !ssdpReq.IsUnicastSearch() {
+		mx, err := ssdpReq.GetMX()
+		if err != nil || mx < 1 {
+			guard.stats.DroppedBadMX++
+			return false
+		}
+	}
+
+	addr, ok := netip.AddrFromSlice(ssdpReq.From.IP)
+	addr = addr.Unmap()
+	if !ok || !guard.isSourceAllowed(addr) {
+		guard.stats.DroppedSource++
+		return false
+	}
+
+	if guard.isRateLimited(addr, time.Now()) {
+		guard.stats.DroppedRateLimit++
+		return false
+	}
+
+	return true
+}
+
+// limitResponses returns the specified targets within MaxResponses, and counts the truncated responses.
+func (guard *searchGuard) limitResponses(targets []ssdpTarget) []ssdpTarget {
+	guard.Lock()
+	defer guard.Unlock()
+
+	maxResponses := guard.policy.MaxResponses
+	if maxResponses <= 0 || len(targets) <= maxResponses {
+		return targets
+	}
+
+	guard.stats.TruncatedResponses += uint64(len(targets) - maxResponses)
+
+	return targets[:maxResponses]
+}
+
+// getStats returns the current counters.
+func (guard *searchGuard) getStats() SearchStats {
+	guard.Lock()
+	defer guard.Unlock()
+	return guard.stats
+}
+
+// startSearchGuard applies SearchPolicy, or the default policy when it is nil, and the attached subnets of the device.
+// The counters are kept over the restarts.
+func (dev *Device) startSearchGuard() error {
+	if dev.SearchPolicy == nil {
+		dev.SearchPolicy = NewSearchPolicy()
+	}
+
+	if dev.searchGuard == nil {
+		dev.searchGuard = newSearchGuard()
+	}
+
+	err := dev.searchGuard.setPolicy(dev.SearchPolicy)
+	if err != nil {
+		return err
+	}
+
+	dev.updateSearchNetworks()
+
+	return nil
+}
+
+// updateSearchNetworks updates the attached subnets whose source addresses are allowed to search the device.
+func (dev *Device) updateSearchNetworks() {
+	if dev.searchGuard == nil {
+		return
+	}
+
+	networks, err := dev.InterfaceSelector.GetAvailableNetworks()
+	if err != nil {
+		log.Warnf("%s", err.Error())
+	}
+
+	dev.searchGuard.setAttachedNetworks(networks)
+}
+
+// acceptSearchRequest returns true when the device should respond to the specified M-SEARCH request by the search policy.
+func (dev *Device) acceptSearchRequest(ssdpReq *ssdp.Request) bool {
+	if dev.searchGuard == nil {
+		return ssdpReq.IsDiscover()
+	}
+	return dev.searchGuard.accept(ssdpReq)
+}
+
+// limitSearchResponses returns the specified targets within MaxResponses of the search policy.
+func (dev *Device) limitSearchResponses(targets []ssdpTarget) []ssdpTarget {
+	if dev.searchGuard == nil {
+		return targets
+	}
+	return dev.searchGuard.limitResponses(targets)
+}
+
+// GetSearchStats returns the counters of the received and dropped M-SEARCH requests.
+func (dev *Device) GetSearchStats() SearchStats {
+	if dev.searchGuard == nil {
+		return SearchStats{}
+	}
+	return dev.searchGuard.getStats()
+}
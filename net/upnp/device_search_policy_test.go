@@ -0,0 +1,160 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+)
+
+const (
+	errorTestSearchPolicyInvalidAccept = "search from %s is accepted = %t : expected %t"
+	errorTestSearchPolicyInvalidStats  = "search stats = %+v : expected %+v"
+	errorTestSearchPolicyInvalidCount  = "response count = %d : expected %d"
+	errorTestSearchPolicyBadNetwork    = "invalid network (%s) is accepted"
+)
+
+func newTestSearchRequest(t *testing.T, from string) *ssdp.Request {
+	t.Helper()
+	ssdpReq, err := ssdp.NewSearchRequest(ssdp.RootDevice, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssdpReq.From = net.UDPAddr{IP: net.ParseIP(from), Port: ssdp.Port}
+	return ssdpReq
+}
+
+func TestSearchGuard(t *testing.T) {
+	policy := NewSearchPolicy()
+	policy.AllowedNetworks = []string{"192.0.2.0/24"}
+	policy.RateLimit = 2
+	policy.MaxResponses = 2
+
+	guard := newSearchGuard()
+	err := guard.setPolicy(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard.setAttachedNetworks([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+
+	badMANReq := newTestSearchRequest(t, "10.0.0.1")
+	badMANReq.SetMAN("ssdp:discover")
+
+	badMXReq := newTestSearchRequest(t, "10.0.0.1")
+	badMXReq.SetMX(0)
+
+	unicastReq, _ := ssdp.NewUnicastSearchRequest(ssdp.RootDevice, "10.0.0.2", ssdp.Port)
+	unicastReq.From = net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: ssdp.Port}
+
+	requests := []struct {
+		req      *ssdp.Request
+		expected bool
+	}{
+		{badMANReq, false},
+		{badMXReq, false},
+		{newTestSearchRequest(t, "198.51.100.1"), false},
+		{newTestSearchRequest(t, "10.0.0.1"), true},
+		{newTestSearchRequest(t, "127.0.0.1"), true},
+		{newTestSearchRequest(t, "::ffff:192.0.2.1"), true},
+		{newTestSearchRequest(t, "192.0.2.1"), true},
+		{newTestSearchRequest(t, "192.0.2.1"), false},
+		{unicastReq, true},
+	}
+
+	for _, r := range requests {
+		accepted := guard.accept(r.req)
+		if accepted != r.expected {
+			t.Errorf(errorTestSearchPolicyInvalidAccept, r.req.GetFromAddress(), accepted, r.expected)
+		}
+	}
+
+	targets := guard.limitResponses(make([]ssdpTarget, 5))
+	if len(targets) != policy.MaxResponses {
+		t.Errorf(errorTestSearchPolicyInvalidCount, len(targets), policy.MaxResponses)
+	}
+
+	expectedStats := SearchStats{
+		Received:           uint64(len(requests)),
+		DroppedBadMAN:      1,
+		DroppedBadMX:       1,
+		DroppedSource:      1,
+		DroppedRateLimit:   1,
+		TruncatedResponses: 3,
+	}
+	if stats := guard.getStats(); stats != expectedStats {
+		t.Errorf(errorTestSearchPolicyInvalidStats, stats, expectedStats)
+	}
+
+	// any source
+
+	policy.AllowAnySource = true
+	err = guard.setPolicy(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !guard.accept(newTestSearchRequest(t, "198.51.100.1")) {
+		t.Errorf(errorTestSearchPolicyInvalidAccept, "198.51.100.1", false, true)
+	}
+
+	// invalid network
+
+	policy.AllowedNetworks = []string{"192.0.2.1"}
+	err = guard.setPolicy(policy)
+	if err == nil {
+		t.Errorf(errorTestSearchPolicyBadNetwork, policy.AllowedNetworks[0])
+	}
+}
+
+func TestDeviceSearchPolicy(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dev.SearchPolicy = NewSearchPolicy()
+	dev.SearchPolicy.AllowedNetworks = []string{"invalid"}
+	err = dev.Start()
+	if err == nil {
+		dev.Stop()
+		t.Errorf(errorTestSearchPolicyBadNetwork, dev.SearchPolicy.AllowedNetworks[0])
+	}
+
+	dev.SearchPolicy = nil
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	if dev.SearchPolicy == nil {
+		t.Errorf(errorTestSearchPolicyInvalidStats, dev.SearchPolicy, NewSearchPolicy())
+	}
+
+	dev.DeviceSearchReceived(newTestSearchRequest(t, "198.51.100.1"))
+
+	expectedStats := SearchStats{Received: 1, DroppedSource: 1}
+	if stats := dev.GetSearchStats(); stats != expectedStats {
+		t.Errorf(errorTestSearchPolicyInvalidStats, stats, expectedStats)
+	}
+
+	// The default policy responds to ssdp:all with all the targets of the tree.
+
+	allTargets := dev.getSearchTargets(ssdp.All)
+	if targets := dev.limitSearchResponses(allTargets); len(targets) != len(allTargets) {
+		t.Errorf(errorTestSearchPolicyInvalidCount, len(targets), len(allTargets))
+	}
+
+	largeTargets := make([]ssdpTarget, 256)
+	if targets := dev.limitSearchResponses(largeTargets); len(targets) != len(largeTargets) {
+		t.Errorf(errorTestSearchPolicyInvalidCount, len(targets), len(largeTargets))
+	}
+
+	if stats := dev.GetSearchStats(); stats != expectedStats {
+		t.Errorf(errorTestSearchPolicyInvalidStats, stats, expectedStats)
+	}
+}
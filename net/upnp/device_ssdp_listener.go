@@ -5,57 +5,189 @@
 package upnp
 
 import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
 	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
 )
 
+const (
+	urnPrefix       = "urn:"
+	urnVersionDelim = ":"
+)
+
+// A ssdpSearchResponse represents a delayed response for a M-SEARCH request.
+type ssdpSearchResponse struct {
+	st    string
+	usn   string
+	delay time.Duration
+}
+
 func (dev *Device) DeviceNotifyReceived(ssdpReq *ssdp.Request) {
 	if dev.SSDPListener != nil {
 		dev.SSDPListener.DeviceNotifyReceived(ssdpReq)
 	}
 }
 
-func (dev *Device) postResponseMessge(ssdpReq *ssdp.Request) error {
-	fromAddr := ssdpReq.From.IP.String()
-	fromPort := ssdpReq.From.Port
+// splitURNVersion splits the specified device or service type URN into the type and the version,
+// such as "urn:schemas-upnp-org:device:MediaServer" and 2.
+func splitURNVersion(urn string) (string, int, bool) {
+	if !strings.HasPrefix(urn, urnPrefix) {
+		return "", 0, false
+	}
+	idx := strings.LastIndex(urn, urnVersionDelim)
+	version, err := strconv.Atoi(urn[idx+1:])
+	if err != nil || version < 1 {
+		return "", 0, false
+	}
+	return urn[:idx], version, true
+}
 
-	ifAddr, err := dev.selectAvailableInterfaceForAddr(fromAddr)
-	if err != nil {
-		return err
+// matchSearchTarget returns true when the specified NT matches the ST. The device and service types match
+// the ST of the same type and a lower or equal version because the higher versions are backward compatible.
+func matchSearchTarget(nt string, st string) bool {
+	if nt == st {
+		return true
+	}
+	stType, stVersion, ok := splitURNVersion(st)
+	if !ok {
+		return false
 	}
+	ntType, ntVersion, ok := splitURNVersion(nt)
+	if !ok {
+		return false
+	}
+	return ntType == stType && stVersion <= ntVersion
+}
 
-	locationURL, err := dev.createLocationURLForAddress(ifAddr)
+// getSearchTargets returns NT and USN pairs which match the specified ST.
+// The targets of the higher versions are returned with the requested version as UPnP 1.1 requires for the responses.
+func (dev *Device) getSearchTargets(st string) []ssdpTarget {
+	targets := dev.getSSDPTargets()
+
+	if st == ssdp.All {
+		return targets
+	}
+
+	matchedTargets := make([]ssdpTarget, 0)
+	for _, target := range targets {
+		if !matchSearchTarget(target.nt, st) {
+			continue
+		}
+		if target.nt != st {
+			target = ssdpTarget{
+				nt:  st,
+				usn: strings.TrimSuffix(target.usn, target.nt) + st,
+			}
+		}
+		matchedTargets = append(matchedTargets, target)
+	}
+
+	return matchedTargets
+}
+
+// getSearchMX returns a valid MX of the specified request.
+func getSearchMX(ssdpReq *ssdp.Request) int {
+	mx, err := ssdpReq.GetMX()
+	if err != nil || mx < 1 {
+		return 1
+	}
+	return min(mx, ssdp.MaxMSearchMX)
+}
+
+func (dev *Device) postSearchResponse(ssdpReq *ssdp.Request, locationURL string, st string, usn string) error {
+	ssdpRes, err := ssdp.NewSearchResponse(st, usn)
 	if err != nil {
 		return err
 	}
 
-	ssdpRes := ssdp.NewResponse()
-	ssdpRes.SetLocation(locationURL.String())
+	ssdpRes.SetLocation(locationURL)
+	ssdpRes.SetMaxAge(dev.LeaseTime)
+	dev.setAdvertisementHeaders(ssdpRes.Packet)
 
 	sock := ssdp.NewUnicastSocket()
-	_, err = sock.WriteResponse(fromAddr, fromPort, ssdpRes)
+	_, err = sock.WriteResponse(ssdpReq.GetFromAddress(), ssdpReq.From.Port, ssdpRes)
 
 	return err
 }
 
+// postSearchResponses sends the specified responses after the random delays within the requested MX.
+func (dev *Device) postSearchResponses(stopCh chan struct{}, ssdpReq *ssdp.Request, locationURL string, responses []ssdpSearchResponse) {
+	slices.SortFunc(responses, func(a, b ssdpSearchResponse) int {
+		return cmp.Compare(a.delay, b.delay)
+	})
+
+	startTime := time.Now()
+	for _, res := range responses {
+		timer := time.NewTimer(time.Until(startTime.Add(res.delay)))
+		select {
+		case <-stopCh:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		err := dev.postSearchResponse(ssdpReq, locationURL, res.st, res.usn)
+		if err != nil {
+			log.Warnf("%s", err.Error())
+		}
+	}
+}
+
 func (dev *Device) handleDiscoverRequest(ssdpReq *ssdp.Request) {
-	if ssdpReq.IsRootDevice() {
-		dev.postResponseMessge(ssdpReq)
+	st, err := ssdpReq.GetST()
+	if err != nil {
 		return
 	}
 
-	st, err := ssdpReq.GetST()
+	targets := dev.limitSearchResponses(dev.getSearchTargets(st))
+	if len(targets) == 0 {
+		return
+	}
+
+	ifAddr, err := dev.selectAvailableInterfaceForAddr(ssdpReq.GetFromAddress())
 	if err != nil {
+		log.Warnf("%s", err.Error())
 		return
 	}
 
-	if dev.HasDeviceType(st) || dev.HasServiceType(st) {
-		dev.postResponseMessge(ssdpReq)
+	locationURL, err := dev.createLocationURLForAddress(ifAddr)
+	if err != nil {
+		log.Warnf("%s", err.Error())
 		return
 	}
+
+	// The unicast requests are responded immediately without the random delays of MX.
+
+	isUnicast := ssdpReq.IsUnicastSearch()
+	mx := time.Duration(getSearchMX(ssdpReq)) * time.Second
+	responses := make([]ssdpSearchResponse, len(targets))
+	for n, target := range targets {
+		resST := st
+		if st == ssdp.All {
+			resST = target.nt
+		}
+		var delay time.Duration
+		if !isUnicast {
+			delay = time.Duration(rand.Int63n(int64(mx)))
+		}
+		responses[n] = ssdpSearchResponse{
+			st:    resST,
+			usn:   target.usn,
+			delay: delay,
+		}
+	}
+
+	go dev.postSearchResponses(dev.stopCh, ssdpReq, locationURL.String(), responses)
 }
 
 func (dev *Device) DeviceSearchReceived(ssdpReq *ssdp.Request) {
-	if ssdpReq.IsDiscover() {
+	if dev.acceptSearchRequest(ssdpReq) {
 		dev.handleDiscoverRequest(ssdpReq)
 	}
 
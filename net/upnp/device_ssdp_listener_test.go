@@ -0,0 +1,209 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
+)
+
+const (
+	errorTestDeviceInvalidSearchTargetCount = "invalid search target count (%s) = %d : expected %d"
+	errorTestDeviceSearchResponseNotFound   = "search response (%s, %s) is not received"
+	errorTestDeviceInvalidSearchResponse    = "invalid search response header %s = '%s' : expected '%s'"
+	errorTestDeviceInvalidSearchTarget      = "invalid search target (%s) = (%s, %s) : expected (%s, %s)"
+)
+
+type testSearchResponseListener struct {
+	sync.Mutex
+	responses map[string]*ssdp.Response
+}
+
+func (l *testSearchResponseListener) DeviceResponseReceived(ssdpRes *ssdp.Response) {
+	usn, _ := ssdpRes.GetUSN()
+	l.Lock()
+	l.responses[usn] = ssdpRes
+	l.Unlock()
+}
+
+func (l *testSearchResponseListener) getResponse(usn string) (*ssdp.Response, bool) {
+	l.Lock()
+	defer l.Unlock()
+	res, ok := l.responses[usn]
+	return res, ok
+}
+
+func TestDeviceSearchTargets(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.SetUDN("search-test")
+
+	service, _ := dev.GetSwitchPowerService()
+
+	sts := map[string]int{
+		ssdp.All:            4,
+		ssdp.RootDevice:     1,
+		dev.UDN:             1,
+		dev.DeviceType:      1,
+		service.ServiceType: 1,
+		"uuid:unknown":      0,
+	}
+
+	for st, expectedCnt := range sts {
+		targets := dev.getSearchTargets(st)
+		if len(targets) != expectedCnt {
+			t.Errorf(errorTestDeviceInvalidSearchTargetCount, st, len(targets), expectedCnt)
+		}
+	}
+
+	// versions
+
+	dev.DeviceType = "urn:schemas-upnp-org:device:BinaryLight:2"
+
+	sts = map[string]int{
+		"urn:schemas-upnp-org:device:BinaryLight:1":      1,
+		"urn:schemas-upnp-org:device:BinaryLight:2":      1,
+		"urn:schemas-upnp-org:device:BinaryLight:3":      0,
+		"urn:schemas-upnp-org:device:BinaryLightDim:1":   0,
+		"urn:schemas-upnp-org:device:BinaryLight:bad":    0,
+		"urn:schemas-upnp-org:service:SwitchPower:1":     1,
+		"urn:schemas-upnp-org:service:SwitchPower:2":     0,
+		"urn:schemas-upnp-org:device:BinaryLight:1:next": 0,
+	}
+
+	for st, expectedCnt := range sts {
+		targets := dev.getSearchTargets(st)
+		if len(targets) != expectedCnt {
+			t.Errorf(errorTestDeviceInvalidSearchTargetCount, st, len(targets), expectedCnt)
+			continue
+		}
+		for _, target := range targets {
+			usn := dev.UDN + usnDelim + st
+			if target.nt != st || target.usn != usn {
+				t.Errorf(errorTestDeviceInvalidSearchTarget, st, target.nt, target.usn, st, usn)
+			}
+		}
+	}
+}
+
+func TestDeviceSearchResponse(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.SetUDN("search-response-test")
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	listener := &testSearchResponseListener{responses: map[string]*ssdp.Response{}}
+	servers := ssdp.NewUnicastServerList()
+	servers.Listener = listener
+	err = servers.Start(ControlPointDefaultPortBase + ControlPointDefaultPortRange + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer servers.Stop()
+
+	mx := 1
+	err = servers.Search(ssdp.All, mx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, _ := dev.GetSwitchPowerService()
+	expectedResponses := map[string]string{
+		dev.UDN + usnDelim + ssdp.RootDevice:     ssdp.RootDevice,
+		dev.UDN:                                  dev.UDN,
+		dev.UDN + usnDelim + dev.DeviceType:      dev.DeviceType,
+		dev.UDN + usnDelim + service.ServiceType: service.ServiceType,
+	}
+
+	time.Sleep(time.Duration(mx)*time.Second + 500*time.Millisecond)
+
+	for usn, st := range expectedResponses {
+		res, ok := listener.getResponse(usn)
+		if !ok {
+			t.Skipf(errorTestDeviceSearchResponseNotFound, st, usn)
+		}
+		resST, _ := res.GetST()
+		if resST != st {
+			t.Errorf(errorTestDeviceInvalidSearchResponse, ssdp.ST, resST, st)
+		}
+		maxAge, _ := res.GetMaxAge()
+		if maxAge != dev.LeaseTime {
+			cacheCtrl, _ := res.GetCacheControl()
+			t.Errorf(errorTestDeviceInvalidSearchResponse, ssdp.CacheControl, cacheCtrl, fmt.Sprintf("max-age=%d", dev.LeaseTime))
+		}
+	}
+}
+
+func TestDeviceUnicastSearchResponse(t *testing.T) {
+	const loopbackAddr = "127.0.0.1"
+
+	newSelector := func() *util.InterfaceSelector {
+		sel := util.NewInterfaceSelector()
+		sel.Addresses = []string{loopbackAddr}
+		return sel
+	}
+
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.SetUDN("unicast-search-response-test")
+	dev.InterfaceSelector = newSelector()
+	dev.SearchPort = ControlPointDefaultPortBase + ControlPointDefaultPortRange + 2
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	listener := &testSearchResponseListener{responses: map[string]*ssdp.Response{}}
+	servers := ssdp.NewUnicastServerList()
+	servers.Listener = listener
+	servers.Selector = newSelector()
+	err = servers.Start(ControlPointDefaultPortBase + ControlPointDefaultPortRange + 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer servers.Stop()
+
+	err = servers.SearchHost(loopbackAddr, dev.SearchPort, ssdp.RootDevice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The unicast request is responded immediately without the delay of MX.
+
+	usn := dev.UDN + usnDelim + ssdp.RootDevice
+	for range 10 {
+		time.Sleep(50 * time.Millisecond)
+		res, ok := listener.getResponse(usn)
+		if !ok {
+			continue
+		}
+		searchPort, _ := res.GetSearchPortUPnPOrg()
+		if searchPort != dev.SearchPort {
+			t.Errorf(errorTestDeviceInvalidSearchResponse, ssdp.SearchPortUPnPOrg, strconv.Itoa(searchPort), strconv.Itoa(dev.SearchPort))
+		}
+		return
+	}
+
+	t.Errorf(errorTestDeviceSearchResponseNotFound, ssdp.RootDevice, usn)
+}
@@ -0,0 +1,257 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
+)
+
+const (
+	usnDelim = ssdp.USNDelim
+)
+
+// A ssdpTarget represents a pair of NT (or ST) and USN of the device.
+type ssdpTarget struct {
+	nt  string
+	usn string
+}
+
+// getSSDPTargets returns all NT and USN pairs of the device, the embedded devices and the services.
+func (dev *Device) getSSDPTargets() []ssdpTarget {
+	targets := make([]ssdpTarget, 0)
+
+	if dev.ParentDevice == nil {
+		targets = append(targets, ssdpTarget{nt: ssdp.RootDevice, usn: dev.UDN + usnDelim + ssdp.RootDevice})
+	}
+
+	return dev.appendSSDPTargets(targets)
+}
+
+func (dev *Device) appendSSDPTargets(targets []ssdpTarget) []ssdpTarget {
+	targets = append(targets,
+		ssdpTarget{nt: dev.UDN, usn: dev.UDN},
+		ssdpTarget{nt: dev.DeviceType, usn: dev.UDN + usnDelim + dev.DeviceType},
+	)
+
+	serviceTypes := map[string]bool{}
+	for n := range len(dev.ServiceList.Services) {
+		service := &dev.ServiceList.Services[n]
+		if serviceTypes[service.ServiceType] {
+			continue
+		}
+		serviceTypes[service.ServiceType] = true
+		targets = append(targets, ssdpTarget{nt: service.ServiceType, usn: dev.UDN + usnDelim + service.ServiceType})
+	}
+
+	// Embedded devices
+
+	for n := range len(dev.DeviceList.Devices) {
+		embeddedDev := &dev.DeviceList.Devices[n]
+		targets = embeddedDev.appendSSDPTargets(targets)
+	}
+
+	return targets
+}
+
+// postNotifyRequests multicasts NOTIFY requests of the specified NTS for all targets on all bound interfaces.
+// The specified next boot ID is sent in the ssdp:update messages.
+func (dev *Device) postNotifyRequests(nts string, nextBootID string) error {
+	if dev.ssdpLock == nil {
+		return nil
+	}
+
+	// The servers are not replaced by UpdateNetwork while the requests are sent.
+
+	dev.ssdpLock.Lock()
+	defer dev.ssdpLock.Unlock()
+
+	if dev.ssdpMcastServerList == nil {
+		return nil
+	}
+
+	var lastErr error
+
+	targets := dev.getSSDPTargets()
+	for _, server := range dev.ssdpMcastServerList.Servers {
+		locationURL := ""
+		if nts != ssdp.NTSByeBye {
+			url, err := dev.createLocationURLForAddress(server.Address)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			locationURL = url.String()
+		}
+
+		for _, target := range targets {
+			ssdpReq, err := ssdp.NewNotifyRequest(target.nt, nts, target.usn)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			switch nts {
+			case ssdp.NTSAlive:
+				ssdpReq.SetLocation(locationURL)
+				ssdpReq.SetMaxAge(dev.LeaseTime)
+				ssdpReq.SetServer(util.GetServer())
+			case ssdp.NTSUpdate:
+				ssdpReq.SetLocation(locationURL)
+				ssdpReq.SetNextBootIDUPnPOrg(nextBootID)
+			}
+			dev.setAdvertisementHeaders(ssdpReq.Packet)
+
+			for range ssdp.DefaultAnnounceCount {
+				_, err := server.Write(ssdpReq)
+				if err != nil {
+					lastErr = err
+				}
+			}
+		}
+	}
+
+	return lastErr
+}
+
+// Announce multicasts ssdp:alive messages of the device, the embedded devices and the services.
+// CONFIGID.UPNP.ORG is updated when the device or service descriptions are changed.
+func (dev *Device) Announce() error {
+	isChanged, err := dev.updateConfigID()
+	if err != nil {
+		return err
+	}
+	if isChanged {
+		log.Tracef("device (%s, %s) config is %s", dev.DeviceType, dev.UDN, dev.GetConfigID())
+		err := dev.saveState()
+		if err != nil {
+			log.Warnf("%s", err.Error())
+		}
+	}
+	return dev.postNotifyRequests(ssdp.NTSAlive, "")
+}
+
+// ByeBye multicasts ssdp:byebye messages of the device, the embedded devices and the services.
+func (dev *Device) ByeBye() error {
+	return dev.postNotifyRequests(ssdp.NTSByeBye, "")
+}
+
+// getNetworkKey returns a string which identifies the bound interfaces and addresses to detect the network changes.
+func (dev *Device) getNetworkKey() string {
+	ifis, err := dev.InterfaceSelector.GetAvailableInterfaces()
+	if err != nil {
+		return ""
+	}
+
+	keys := make([]string, 0)
+	for _, ifi := range ifis {
+		addrs, err := dev.InterfaceSelector.GetInterfaceAddresses(ifi)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			keys = append(keys, ifi.Name+"/"+addr)
+		}
+	}
+	slices.Sort(keys)
+
+	return strings.Join(keys, ",")
+}
+
+// UpdateNetwork increases the boot ID, and rejoins the multicast groups on the current interfaces.
+// ssdp:update messages with the next boot ID are sent on the previous interfaces before rejoining,
+// and ssdp:alive messages with the new boot ID are sent after rejoining.
+// The device calls it automatically when the interfaces or the addresses are changed.
+func (dev *Device) UpdateNetwork() error {
+	if !dev.hasSSDPServers() {
+		return nil
+	}
+
+	var lastErr error
+
+	bootID := nextBootID(dev.GetBootID())
+	err := dev.postNotifyRequests(ssdp.NTSUpdate, bootID)
+	if err != nil {
+		lastErr = err
+	}
+
+	dev.modifyAdvertisement(func(adv *deviceAdvertisement) {
+		adv.bootID = bootID
+	})
+
+	err = dev.saveState()
+	if err != nil {
+		lastErr = err
+	}
+
+	err = dev.restartSSDPServers()
+	if err != nil {
+		lastErr = err
+	}
+
+	dev.updateSearchNetworks()
+
+	err = dev.restartSearchServers()
+	if err != nil {
+		lastErr = err
+	}
+
+	err = dev.restartMulticastEventServers()
+	if err != nil {
+		lastErr = err
+	}
+
+	err = dev.Announce()
+	if err != nil {
+		lastErr = err
+	}
+
+	log.Tracef("device (%s, %s) is rebooted by network changes : %s", dev.DeviceType, dev.UDN, bootID)
+
+	return lastErr
+}
+
+// announceLoop re-advertises the device periodically before the advertisements expire,
+// and updates the boot ID when the network is changed.
+func (dev *Device) announceLoop(stopCh chan struct{}, interval time.Duration, networkCheckInterval time.Duration) {
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	networkTicker := time.NewTicker(max(networkCheckInterval, time.Second))
+	defer networkTicker.Stop()
+
+	networkKey := dev.getNetworkKey()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			err := dev.Announce()
+			if err != nil {
+				log.Warnf("%s", err.Error())
+			}
+		case <-networkTicker.C:
+			currentKey := dev.getNetworkKey()
+			if currentKey == networkKey {
+				continue
+			}
+			networkKey = currentKey
+			err := dev.UpdateNetwork()
+			if err != nil {
+				log.Warnf("%s", err.Error())
+			}
+		}
+	}
+}
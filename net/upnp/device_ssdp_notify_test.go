@@ -0,0 +1,326 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+)
+
+const (
+	errorTestDeviceInvalidSSDPTargetCount  = "invalid SSDP target count = %d : expected %d"
+	errorTestDeviceNotifyNotReceived       = "%s of (%s) is not received"
+	errorTestDeviceInvalidBootID           = "boot ID %d is not greater than %d"
+	errorTestDeviceInvalidNextBootID       = "next boot ID of %s = %s : expected %s"
+	errorTestDeviceInvalidAdvertisementIDs = "invalid boot ID (%s) or config ID (%s)"
+)
+
+type testNotifyListener struct {
+	sync.Mutex
+	udn      string
+	received map[string]bool
+}
+
+func newTestNotifyListener(udn string) *testNotifyListener {
+	return &testNotifyListener{udn: udn, received: map[string]bool{}}
+}
+
+func (l *testNotifyListener) DeviceNotifyReceived(ssdpReq *ssdp.Request) {
+	usn, _ := ssdpReq.GetUSN()
+	if !strings.HasPrefix(usn, l.udn) {
+		return
+	}
+	nts, _ := ssdpReq.GetNTS()
+	nt, _ := ssdpReq.GetNT()
+	l.Lock()
+	l.received[nts+nt] = true
+	l.Unlock()
+}
+
+func (l *testNotifyListener) DeviceSearchReceived(ssdpReq *ssdp.Request) {
+}
+
+func (l *testNotifyListener) isReceived(nts string, nt string) bool {
+	l.Lock()
+	defer l.Unlock()
+	return l.received[nts+nt]
+}
+
+func TestDeviceSSDPTargets(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.SetUDN("test")
+
+	// 3 + 2d + k (no embedded devices and a service type)
+	expectedCnt := 3 + 1
+	targets := dev.getSSDPTargets()
+	if len(targets) != expectedCnt {
+		t.Errorf(errorTestDeviceInvalidSSDPTargetCount, len(targets), expectedCnt)
+	}
+
+	if targets[0].nt != ssdp.RootDevice || targets[0].usn != dev.UDN+usnDelim+ssdp.RootDevice {
+		t.Errorf("%v", targets[0])
+	}
+}
+
+func TestDeviceAnnounce(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.SetUDN("announce-test")
+
+	listener := newTestNotifyListener(dev.UDN)
+	servers := ssdp.NewMulticastServerList()
+	servers.Listener = listener
+	err = servers.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer servers.Stop()
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitReceived := func(nts string, nt string) bool {
+		for range 20 {
+			if listener.isReceived(nts, nt) {
+				return true
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		return false
+	}
+
+	for _, nt := range []string{ssdp.RootDevice, dev.UDN, dev.DeviceType} {
+		if !waitReceived(ssdp.NTSAlive, nt) {
+			t.Skipf(errorTestDeviceNotifyNotReceived, ssdp.NTSAlive, nt)
+		}
+	}
+
+	err = dev.Stop()
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, nt := range []string{ssdp.RootDevice, dev.UDN, dev.DeviceType} {
+		if !waitReceived(ssdp.NTSByeBye, nt) {
+			t.Errorf(errorTestDeviceNotifyNotReceived, ssdp.NTSByeBye, nt)
+		}
+	}
+}
+
+func TestNextBootID(t *testing.T) {
+	bootIDs := []struct {
+		bootID   string
+		expected string
+	}{
+		{"", "1"},
+		{"invalid", "1"},
+		{"-1", "1"},
+		{"1", "2"},
+		{"1700000000", "1700000001"},
+		{strconv.Itoa(maxBootID - 1), strconv.Itoa(maxBootID)},
+		{strconv.Itoa(maxBootID), "0"},
+		{"0", "1"},
+	}
+	for _, r := range bootIDs {
+		nextID := nextBootID(r.bootID)
+		if nextID != r.expected {
+			t.Errorf(errorTestDeviceInvalidNextBootID, r.bootID, nextID, r.expected)
+		}
+	}
+}
+
+func TestDeviceBootIDAndConfigID(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bootID := dev.GetBootID()
+	configID := dev.GetConfigID()
+	if len(bootID) == 0 || len(configID) == 0 {
+		t.Fatalf(errorTestDeviceInvalidAdvertisementIDs, bootID, configID)
+	}
+
+	desc, err := dev.DescriptionString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(desc, "configId=\""+configID+"\"") {
+		t.Errorf(errorTestDeviceInvalidAdvertisementIDs, bootID, configID)
+	}
+
+	// search responses
+
+	ssdpRes, err := ssdp.NewSearchResponse(ssdp.RootDevice, dev.UDN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.setAdvertisementHeaders(ssdpRes.Packet)
+	resBootID, _ := ssdpRes.GetBootIDUPnPOrg()
+	resConfigID, _ := ssdpRes.GetConfigIDUPnPOrg()
+	if resBootID != bootID || resConfigID != configID {
+		t.Errorf(errorTestDeviceInvalidAdvertisementIDs, resBootID, resConfigID)
+	}
+
+	// the config ID is changed by the description
+
+	dev.FriendlyName += " (updated)"
+	err = dev.Announce()
+	if err != nil {
+		t.Error(err)
+	}
+	if dev.GetConfigID() == configID {
+		t.Errorf(errorTestDeviceInvalidAdvertisementIDs, dev.GetBootID(), dev.GetConfigID())
+	}
+
+	// the boot ID is increased by the restart
+
+	err = dev.Stop()
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	prevID, _ := strconv.ParseInt(bootID, 10, 64)
+	nextID, _ := strconv.ParseInt(dev.GetBootID(), 10, 64)
+	if nextID <= prevID {
+		t.Errorf(errorTestDeviceInvalidBootID, nextID, prevID)
+	}
+}
+
+type testUpdateListener struct {
+	sync.Mutex
+	udn         string
+	nextBootIDs []string
+}
+
+func (l *testUpdateListener) DeviceNotifyReceived(ssdpReq *ssdp.Request) {
+	usn, _ := ssdpReq.GetUSN()
+	if !strings.HasPrefix(usn, l.udn) || !ssdpReq.IsUpdate() || !ssdpReq.IsRootDevice() {
+		return
+	}
+	nextBootID, _ := ssdpReq.GetNextBootIDUPnPOrg()
+	l.Lock()
+	l.nextBootIDs = append(l.nextBootIDs, nextBootID)
+	l.Unlock()
+}
+
+func (l *testUpdateListener) DeviceSearchReceived(ssdpReq *ssdp.Request) {
+}
+
+func (l *testUpdateListener) getNextBootIDs() []string {
+	l.Lock()
+	defer l.Unlock()
+	return slices.Clone(l.nextBootIDs)
+}
+
+func TestDeviceUpdateNetwork(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.SetUDN("update-test")
+
+	listener := &testUpdateListener{udn: dev.UDN}
+	servers := ssdp.NewMulticastServerList()
+	servers.Listener = listener
+	err = servers.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer servers.Stop()
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	bootID := dev.GetBootID()
+
+	err = dev.UpdateNetwork()
+	if err != nil {
+		t.Error(err)
+	}
+
+	prevID, _ := strconv.ParseInt(bootID, 10, 64)
+	nextID, _ := strconv.ParseInt(dev.GetBootID(), 10, 64)
+	if nextID <= prevID {
+		t.Errorf(errorTestDeviceInvalidBootID, nextID, prevID)
+	}
+
+	for range 20 {
+		nextBootIDs := listener.getNextBootIDs()
+		if 0 < len(nextBootIDs) {
+			if nextBootIDs[0] != dev.GetBootID() {
+				t.Errorf(errorTestDeviceInvalidAdvertisementIDs, nextBootIDs[0], dev.GetConfigID())
+			}
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Skipf(errorTestDeviceNotifyNotReceived, ssdp.NTSUpdate, ssdp.RootDevice)
+}
+
+func TestDeviceUpdateNetworkWhileAnnouncing(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.SearchPort = ControlPointDefaultPortBase + ControlPointDefaultPortRange + 5
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Stop()
+
+	// UpdateNetwork replaces the SSDP servers while Announce sends on them. Run with -race to check it.
+
+	const loopCount = 5
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for range loopCount {
+			err := dev.Announce()
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	for range loopCount {
+		err := dev.UpdateNetwork()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	wg.Wait()
+}
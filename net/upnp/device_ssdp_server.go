@@ -0,0 +1,125 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+)
+
+// newSSDPServers returns new SSDP multicast servers of the device which are started on the current interfaces.
+func (dev *Device) newSSDPServers() (*ssdp.MulticastServerList, error) {
+	servers := ssdp.NewMulticastServerList()
+	servers.Listener = dev
+	servers.Selector = dev.InterfaceSelector
+	err := servers.Start()
+	return servers, err
+}
+
+// swapSSDPServers replaces the SSDP multicast servers with the specified servers, and returns the previous servers.
+func (dev *Device) swapSSDPServers(servers *ssdp.MulticastServerList) *ssdp.MulticastServerList {
+	if dev.ssdpLock == nil {
+		return nil
+	}
+
+	dev.ssdpLock.Lock()
+	defer dev.ssdpLock.Unlock()
+
+	prevServers := dev.ssdpMcastServerList
+	dev.ssdpMcastServerList = servers
+
+	return prevServers
+}
+
+// hasSSDPServers returns true when the SSDP multicast servers are started.
+func (dev *Device) hasSSDPServers() bool {
+	if dev.ssdpLock == nil {
+		return false
+	}
+
+	dev.ssdpLock.Lock()
+	defer dev.ssdpLock.Unlock()
+
+	return dev.ssdpMcastServerList != nil
+}
+
+// startSSDPServers joins the SSDP multicast groups on the current interfaces.
+func (dev *Device) startSSDPServers() error {
+	servers, err := dev.newSSDPServers()
+	dev.swapSSDPServers(servers)
+	return err
+}
+
+// stopSSDPServers leaves the SSDP multicast groups.
+func (dev *Device) stopSSDPServers() error {
+	servers := dev.swapSSDPServers(nil)
+	if servers == nil {
+		return nil
+	}
+	return servers.Stop()
+}
+
+// restartSSDPServers joins the SSDP multicast groups on the current interfaces, and leaves the previous groups.
+// The new servers are swapped in before the previous servers are stopped not to miss the requests.
+func (dev *Device) restartSSDPServers() error {
+	servers, err := dev.newSSDPServers()
+
+	prevServers := dev.swapSSDPServers(servers)
+	if prevServers != nil {
+		stopErr := prevServers.Stop()
+		if err == nil {
+			err = stopErr
+		}
+	}
+
+	return err
+}
+
+// startSearchServers binds SearchPort to receive the unicast M-SEARCH requests when it is not the SSDP port.
+// The unicast requests to the SSDP port are received by the multicast servers.
+func (dev *Device) startSearchServers() error {
+	searchPort := dev.GetSearchPort()
+	if searchPort == ssdp.Port {
+		return nil
+	}
+
+	servers := ssdp.NewUnicastServerList()
+	servers.SearchListener = dev
+	servers.Selector = dev.InterfaceSelector
+	err := servers.Start(searchPort)
+
+	dev.ssdpLock.Lock()
+	dev.ssdpUcastServerList = servers
+	dev.ssdpLock.Unlock()
+
+	return err
+}
+
+// stopSearchServers closes SearchPort.
+func (dev *Device) stopSearchServers() error {
+	if dev.ssdpLock == nil {
+		return nil
+	}
+
+	dev.ssdpLock.Lock()
+	servers := dev.ssdpUcastServerList
+	dev.ssdpUcastServerList = nil
+	dev.ssdpLock.Unlock()
+
+	if servers == nil {
+		return nil
+	}
+
+	return servers.Stop()
+}
+
+// restartSearchServers binds SearchPort on the current interfaces. The previous servers are stopped first
+// because the same addresses and port can't be bound twice.
+func (dev *Device) restartSearchServers() error {
+	err := dev.stopSearchServers()
+	if err != nil {
+		return err
+	}
+	return dev.startSearchServers()
+}
@@ -0,0 +1,111 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// A DeviceState represents the state of a device which is kept across the restarts.
+type DeviceState struct {
+	UDN      string `json:"udn"`
+	BootID   string `json:"bootId"`
+	ConfigID string `json:"configId"`
+}
+
+// A DeviceStateStore represents a store to persist the state of a device.
+type DeviceStateStore interface {
+	// LoadDeviceState returns the saved state. An empty state is returned when no state is saved.
+	LoadDeviceState() (*DeviceState, error)
+	// SaveDeviceState saves the specified state.
+	SaveDeviceState(state *DeviceState) error
+}
+
+// A FileDeviceStateStore represents a store which saves the device state to a JSON file.
+type FileDeviceStateStore struct {
+	Path string
+}
+
+// NewFileDeviceStateStore returns a new store of the specified file path.
+func NewFileDeviceStateStore(path string) *FileDeviceStateStore {
+	store := &FileDeviceStateStore{
+		Path: path,
+	}
+	return store
+}
+
+// LoadDeviceState reads the state from the file. An empty state is returned when the file doesn't exist.
+func (store *FileDeviceStateStore) LoadDeviceState() (*DeviceState, error) {
+	stateBytes, err := os.ReadFile(store.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &DeviceState{}, nil
+		}
+		return nil, err
+	}
+
+	state := &DeviceState{}
+	err = json.Unmarshal(stateBytes, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// SaveDeviceState writes the specified state to the file atomically.
+func (store *FileDeviceStateStore) SaveDeviceState(state *DeviceState) error {
+	stateBytes, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(store.Path, append(stateBytes, '\n'))
+}
+
+// loadState restores the UDN, the boot ID and the config ID from the state store.
+// The UDN is restored only when it is not specified.
+func (dev *Device) loadState() error {
+	if dev.StateStore == nil {
+		return nil
+	}
+
+	state, err := dev.StateStore.LoadDeviceState()
+	if err != nil {
+		return err
+	}
+
+	if len(dev.UDN) == 0 {
+		dev.UDN = state.UDN
+	}
+
+	dev.modifyAdvertisement(func(adv *deviceAdvertisement) {
+		if len(adv.bootID) == 0 {
+			adv.bootID = state.BootID
+		}
+		if len(adv.configID) == 0 {
+			adv.configID = state.ConfigID
+		}
+	})
+
+	return nil
+}
+
+// saveState saves the UDN, the boot ID and the config ID to the state store.
+func (dev *Device) saveState() error {
+	if dev.StateStore == nil {
+		return nil
+	}
+
+	state := &DeviceState{
+		UDN:      dev.UDN,
+		BootID:   dev.GetBootID(),
+		ConfigID: dev.GetConfigID(),
+	}
+
+	return dev.StateStore.SaveDeviceState(state)
+}
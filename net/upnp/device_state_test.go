@@ -0,0 +1,86 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+const (
+	errorTestDeviceInvalidState = "state = %+v : expected %+v"
+)
+
+func TestFileDeviceStateStore(t *testing.T) {
+	store := NewFileDeviceStateStore(filepath.Join(t.TempDir(), "state.json"))
+
+	state, err := store.LoadDeviceState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *state != (DeviceState{}) {
+		t.Errorf(errorTestDeviceInvalidState, *state, DeviceState{})
+	}
+
+	savedState := DeviceState{UDN: "uuid:test", BootID: "10", ConfigID: "20"}
+	err = store.SaveDeviceState(&savedState)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = store.LoadDeviceState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *state != savedState {
+		t.Errorf(errorTestDeviceInvalidState, *state, savedState)
+	}
+}
+
+func TestDeviceStateStore(t *testing.T) {
+	store := NewFileDeviceStateStore(filepath.Join(t.TempDir(), "state.json"))
+
+	startDevice := func() *TestDevice {
+		dev, err := NewTestDevice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dev.StateStore = store
+		err = dev.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = dev.Stop()
+		if err != nil {
+			t.Error(err)
+		}
+		return dev
+	}
+
+	dev := startDevice()
+
+	state, err := store.LoadDeviceState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedState := DeviceState{UDN: dev.UDN, BootID: dev.GetBootID(), ConfigID: dev.GetConfigID()}
+	if *state != expectedState {
+		t.Errorf(errorTestDeviceInvalidState, *state, expectedState)
+	}
+
+	// the identity is kept, and the boot ID is increased by the restart
+
+	restartedDev := startDevice()
+	if restartedDev.UDN != dev.UDN || restartedDev.GetConfigID() != dev.GetConfigID() {
+		t.Errorf(errorTestDeviceInvalidState, restartedDev.UDN, dev.UDN)
+	}
+
+	prevID, _ := strconv.ParseInt(dev.GetBootID(), 10, 64)
+	nextID, _ := strconv.ParseInt(restartedDev.GetBootID(), 10, 64)
+	if nextID <= prevID {
+		t.Errorf(errorTestDeviceInvalidBootID, nextID, prevID)
+	}
+}
@@ -85,3 +85,105 @@ func TestSampleDevice(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestEmbeddedDevice(t *testing.T) {
+	// root device -> light device -> light device
+
+	leafDev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	midDev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	midDev.DeviceList.Devices = []Device{*leafDev.Device}
+
+	dev := NewDevice()
+	dev.DeviceType = "urn:schemas-upnp-org:device:InternetGatewayDevice:1"
+	dev.DeviceList.Devices = []Device{*midDev.Device}
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err := dev.Stop()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	// check parent objects
+
+	embeddedDevs := []*Device{&dev.DeviceList.Devices[0], &dev.DeviceList.Devices[0].DeviceList.Devices[0]}
+	parentDevs := []*Device{dev, embeddedDevs[0]}
+	for n, embeddedDev := range embeddedDevs {
+		if embeddedDev.ParentDevice != parentDevs[n] {
+			t.Errorf(errorTestDeviceInvalidParentObject, embeddedDev, embeddedDev.ParentDevice, parentDevs[n])
+		}
+		if embeddedDev.GetRootDevice() != dev {
+			t.Errorf(errorTestDeviceInvalidParentObject, embeddedDev, embeddedDev.GetRootDevice(), dev)
+		}
+	}
+
+	// check SSDP targets : rootdevice, UDN and device type of each device, and service type of each embedded device
+
+	targets := dev.getSSDPTargets()
+	if len(targets) != 9 {
+		t.Errorf(errorTestDeviceInvalidSSDPTargetCount, len(targets), 9)
+	}
+
+	// check UDNs and service URLs
+
+	udns := map[string]bool{dev.UDN: true}
+	for _, embeddedDev := range embeddedDevs {
+		if len(embeddedDev.UDN) == 0 || udns[embeddedDev.UDN] {
+			t.Errorf(errorTestDeviceDuplicatedValue, "UDN", embeddedDev.UDN)
+		}
+		udns[embeddedDev.UDN] = true
+	}
+
+	services := dev.getAllServices()
+	if len(services) != 2 {
+		t.Fatalf(errorTestDeviceInvalidServiceCount, len(services), 2)
+	}
+
+	urls := map[string]bool{}
+	for _, service := range services {
+		for _, url := range []string{service.SCPDURL, service.ControlURL, service.EventSubURL} {
+			if urls[url] {
+				t.Errorf(errorTestDeviceDuplicatedValue, "URL", url)
+			}
+			urls[url] = true
+		}
+
+		ctrlService, err := dev.GetServiceByControlURL(service.ControlURL)
+		if err != nil || ctrlService != service {
+			t.Errorf(errorTestDeviceInvalidURL, "ControlURL", service.ControlURL, service.ControlURL)
+		}
+
+		eventService, err := dev.GetServiceByEventSubURL(service.EventSubURL)
+		if err != nil || eventService != service {
+			t.Errorf(errorTestDeviceInvalidURL, "EventSubURL", service.EventSubURL, service.EventSubURL)
+		}
+
+		// check service descriptions
+
+		scpdURL := fmt.Sprintf("http://localhost:%d%s", dev.Port, service.SCPDURL)
+		res, err := http.Get(scpdURL)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if res.StatusCode != http.StatusOK {
+			t.Errorf(errorTestDeviceInvalidStatusCode, scpdURL, res.StatusCode, http.StatusOK)
+		}
+		err = res.Body.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
+)
+
+const (
+	// DeviceDefaultUDNNamespace is the namespace UUID of the name-based UDNs of the devices.
+	DeviceDefaultUDNNamespace = "2c8e3f0a-6d57-4b1e-9f3c-5a7d1e2b4c60"
+)
+
+const (
+	errorUDNStrategyEmptyName   = "name of the UDN is empty"
+	errorUDNStrategyInvalidUUID = "UUID (%s) in %s is invalid"
+)
+
+// A UDNStrategy represents a strategy to create the UUID of the root device when the UDN is not specified.
+// The UDNs of the embedded devices are derived from the UDN of the root device.
+type UDNStrategy interface {
+	// CreateUUID returns a UUID of the specified root device without the "uuid:" prefix.
+	CreateUUID(dev *Device) (string, error)
+}
+
+// A UDNStrategyFunc is an adapter to use an ordinary function as UDNStrategy.
+type UDNStrategyFunc func(dev *Device) (string, error)
+
+// CreateUUID calls the function.
+func (f UDNStrategyFunc) CreateUUID(dev *Device) (string, error) {
+	return f(dev)
+}
+
+// NewRandomUDNStrategy returns a strategy which creates a random UUID on each start. It is the default strategy.
+func NewRandomUDNStrategy() UDNStrategy {
+	return UDNStrategyFunc(func(dev *Device) (string, error) {
+		return util.CreateUUID(), nil
+	})
+}
+
+// NewNameUDNStrategy returns a strategy which creates a name-based UUID (version 5) of the specified namespace UUID and name.
+// DeviceDefaultUDNNamespace is used when the namespace is empty. The device type is added to the name
+// to create the different UDNs for the different devices with the same name.
+func NewNameUDNStrategy(namespace string, name string) UDNStrategy {
+	return newNameUDNStrategy(namespace, func(dev *Device) (string, error) {
+		return name, nil
+	})
+}
+
+// NewSerialNumberUDNStrategy returns a name-based UUID strategy with the serial number of the device description.
+func NewSerialNumberUDNStrategy(namespace string) UDNStrategy {
+	return newNameUDNStrategy(namespace, func(dev *Device) (string, error) {
+		return dev.SerialNumber, nil
+	})
+}
+
+// NewHardwareAddressUDNStrategy returns a name-based UUID strategy with the hardware address of the host.
+func NewHardwareAddressUDNStrategy(namespace string) UDNStrategy {
+	return newNameUDNStrategy(namespace, func(dev *Device) (string, error) {
+		return util.GetHardwareAddress()
+	})
+}
+
+// NewHostnameUDNStrategy returns a name-based UUID strategy with the host name.
+func NewHostnameUDNStrategy(namespace string) UDNStrategy {
+	return newNameUDNStrategy(namespace, func(dev *Device) (string, error) {
+		return os.Hostname()
+	})
+}
+
+func newNameUDNStrategy(namespace string, nameFunc func(dev *Device) (string, error)) UDNStrategy {
+	if len(namespace) == 0 {
+		namespace = DeviceDefaultUDNNamespace
+	}
+	return UDNStrategyFunc(func(dev *Device) (string, error) {
+		name, err := nameFunc(dev)
+		if err != nil {
+			return "", err
+		}
+		if len(name) == 0 {
+			return "", errors.New(errorUDNStrategyEmptyName)
+		}
+		return util.CreateNameUUID(namespace, name+"/"+dev.DeviceType)
+	})
+}
+
+// NewFileUDNStrategy returns a strategy which loads the UUID from the specified file.
+// When the file doesn't exist, the UUID is created by the specified strategy and saved to the file.
+// A random UUID is created when the strategy is nil.
+func NewFileUDNStrategy(path string, strategy UDNStrategy) UDNStrategy {
+	if strategy == nil {
+		strategy = NewRandomUDNStrategy()
+	}
+	return UDNStrategyFunc(func(dev *Device) (string, error) {
+		uuidBytes, err := os.ReadFile(path)
+		if err == nil {
+			uuid := strings.TrimPrefix(strings.TrimSpace(string(uuidBytes)), DeviceUUIDPrefix)
+			if !util.IsUUID(uuid) {
+				return "", fmt.Errorf(errorUDNStrategyInvalidUUID, uuid, path)
+			}
+			return uuid, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+
+		uuid, err := strategy.CreateUUID(dev)
+		if err != nil {
+			return "", err
+		}
+
+		err = writeFileAtomically(path, []byte(uuid+"\n"))
+		if err != nil {
+			return "", err
+		}
+
+		return uuid, nil
+	})
+}
+
+// writeFileAtomically writes the specified data to a temporary file, and renames it to the specified path.
+func writeFileAtomically(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+// createEmbeddedUDN returns a name-based UDN of the embedded device from the UDN of the root device
+// and the position in the device tree to keep the UDN stable as well as the root device.
+func (dev *Device) createEmbeddedUDN() (string, error) {
+	path := ""
+	child := dev
+	for parent := dev.ParentDevice; parent != nil; parent = parent.ParentDevice {
+		for n := range len(parent.DeviceList.Devices) {
+			if &parent.DeviceList.Devices[n] == child {
+				path = "/" + strconv.Itoa(n) + path
+				break
+			}
+		}
+		child = parent
+	}
+
+	return util.CreateNameUUID(DeviceDefaultUDNNamespace, child.UDN+path+"/"+dev.DeviceType)
+}
@@ -0,0 +1,126 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	errorTestDeviceUDNNotEqual = "UDN = %s : expected %s"
+	errorTestDeviceUDNEqual    = "UDN = %s : expected a different UDN"
+)
+
+// newTestDeviceTree returns a revised device tree of root device -> light device -> light device with the specified strategy.
+func newTestDeviceTree(t *testing.T, strategy UDNStrategy) *Device {
+	t.Helper()
+
+	leafDev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	midDev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	midDev.DeviceList.Devices = []Device{*leafDev.Device}
+
+	dev := NewDevice()
+	dev.DeviceType = "urn:schemas-upnp-org:device:InternetGatewayDevice:1"
+	dev.DeviceList.Devices = []Device{*midDev.Device}
+	dev.UDNStrategy = strategy
+
+	err = dev.reviseParentObject()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dev.reviseDescription()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dev
+}
+
+func getTestDeviceTreeUDNs(dev *Device) []string {
+	midDev := &dev.DeviceList.Devices[0]
+	leafDev := &midDev.DeviceList.Devices[0]
+	return []string{dev.UDN, midDev.UDN, leafDev.UDN}
+}
+
+func TestNameUDNStrategy(t *testing.T) {
+	udns := getTestDeviceTreeUDNs(newTestDeviceTree(t, NewNameUDNStrategy("", "test")))
+
+	// all UDNs in the tree are unique
+
+	for n, udn := range udns {
+		for _, otherUDN := range udns[n+1:] {
+			if udn == otherUDN {
+				t.Errorf(errorTestDeviceUDNEqual, udn)
+			}
+		}
+	}
+
+	// the same UDNs for the same name
+
+	for n, udn := range getTestDeviceTreeUDNs(newTestDeviceTree(t, NewNameUDNStrategy("", "test"))) {
+		if udn != udns[n] {
+			t.Errorf(errorTestDeviceUDNNotEqual, udn, udns[n])
+		}
+	}
+
+	// the different UDNs for the different names
+
+	for n, udn := range getTestDeviceTreeUDNs(newTestDeviceTree(t, NewNameUDNStrategy("", "other"))) {
+		if udn == udns[n] {
+			t.Errorf(errorTestDeviceUDNEqual, udn)
+		}
+	}
+
+	// the name is required
+
+	dev := NewDevice()
+	dev.UDNStrategy = NewSerialNumberUDNStrategy("")
+	if err := dev.reviseDescription(); err == nil {
+		t.Errorf(errorTestDeviceUDNEqual, dev.UDN)
+	}
+}
+
+func TestFileUDNStrategy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "udn", "uuid")
+
+	udns := getTestDeviceTreeUDNs(newTestDeviceTree(t, NewFileUDNStrategy(path, nil)))
+
+	uuidBytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if DeviceUUIDPrefix+string(uuidBytes[:len(uuidBytes)-1]) != udns[0] {
+		t.Errorf(errorTestDeviceUDNNotEqual, string(uuidBytes), udns[0])
+	}
+
+	for n, udn := range getTestDeviceTreeUDNs(newTestDeviceTree(t, NewFileUDNStrategy(path, nil))) {
+		if udn != udns[n] {
+			t.Errorf(errorTestDeviceUDNNotEqual, udn, udns[n])
+		}
+	}
+
+	// invalid UUID
+
+	err = os.WriteFile(path, []byte("invalid"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dev := NewDevice()
+	dev.UDNStrategy = NewFileUDNStrategy(path, nil)
+	if err := dev.reviseDescription(); err == nil {
+		t.Errorf(errorTestDeviceUDNEqual, dev.UDN)
+	}
+}
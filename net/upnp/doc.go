@@ -18,6 +18,13 @@ The package supports UPnP control functions. The control point can search UPnP d
 		...
 	}
 
+The multicast M-SEARCH requests don't reach the devices in the other subnets. The control point can search the devices of a known host
+with a unicast M-SEARCH request, or get the device from the description URL directly:
+
+	devs, err := cp.SearchHost(ctx, "192.168.1.10", ssdp.RootDevice)
+	...
+	dev, err := cp.GetDevice(ctx, "http://192.168.1.10:6004/description.xml")
+
 The control point can post actions in the service, and get the action response:
 
 	service, err := dev.GetServiceByType("xxxx")
@@ -29,6 +36,36 @@ The control point can post actions in the service, and get the action response:
 	...
 	resArg = action.GetArgumentString("xxxx")
 
+Post sets the arguments of the shared action in the service. To post the same action from multiple goroutines, use Invoke which posts a copy of the action:
+
+	outArgs, err := action.Invoke(ctx, map[string]string{"xxxx": "xxxx"})
+	...
+	resArg = outArgs["xxxx"]
+
+All network operations have the context variants such as PostContext, SubscribeContext and LoadServiceDescriptionsContext to cancel them.
+The HTTP client of the control point is used for all requests to the found devices, and it can be configured before starting the control point:
+
+	config := http.NewClientConfig()
+	config.Timeout = 5 * time.Second
+	config.UserAgent = "xxxx"
+	cp.HTTPClient = http.NewClientWithConfig(config)
+
+The control point can also get the current value of a state variable with the QueryStateVariable action for the UPnP 1.0 devices which have no getter actions:
+
+	statVar, err := service.GetStateVariableByName("xxxx")
+	...
+	value, err := statVar.Query()
+
+The control point can also receive the multicast events of the state variables which have the multicast attribute by joining the multicast eventing groups. The events are notified to EventListener with the USN, SVCID and LVL headers:
+
+	err = cp.JoinMulticastEvents()
+	...
+	func (self *SampleListener) EventNotifyReceived(e *upnp.Event) {
+		if e.Multicast {
+			...
+		}
+	}
+
 In addition to the control point functions, the package supports UPnP device functions to implement any UPnP devices using Go.
 
 To implement UPnP devices, prepare the UPnP device and service descriptions as the following:
@@ -71,5 +108,39 @@ Next, implement the control actions in the service descriptions using upnp.Actio
 		}
 		return upnp.NewErrorFromCode(upnp.ErrorOptionalActionNotImplemented)
 	}
+
+Alternatively, set upnp.ActionHandler for each service type or ID, or for each action, including the services of the embedded devices. The handlers are called through the middlewares which are added by Use as the following:
+
+	sampleDev.Use(upnp.NewLoggingActionMiddleware(), upnp.NewRecoveryActionMiddleware())
+	...
+	err = sampleDev.SetActionHandler("urn:schemas-upnp-org:service:xxxx:x", SetTarget, upnp.ActionHandlerFunc(func(req *upnp.ActionRequest) upnp.Error {
+		xxxx, err := req.Action.GetArgumentString(xxxx)
+		...
+		return nil
+	}))
+
+The started device advertises BOOTID.UPNP.ORG and CONFIGID.UPNP.ORG of UPnP 1.1. The boot ID is increased on each start and network change,
+and the config ID is changed when the descriptions are changed. The control point updates the found devices, and renews the subscriptions of the rebooted devices.
+
+The started device responds to the M-SEARCH requests from the directly attached subnets only, and limits the requests per source address
+and the responses per request not to reflect the traffic to spoofed addresses. The dropped requests are counted in GetSearchStats.
+To respond to the control points in the routed networks, add their networks to SearchPolicy:
+
+	sampleDev.SearchPolicy = upnp.NewSearchPolicy()
+	sampleDev.SearchPolicy.AllowedNetworks = []string{"192.168.2.0/24"}
+
+The device creates a random UDN on each start when the UDN is not specified. To keep the UDN stable across restarts, set UDNStrategy,
+and set StateStore to keep the UDN, the boot ID and the config ID. The UDNs of the embedded devices are derived from the UDN of the root device:
+
+	sampleDev.UDNStrategy = upnp.NewFileUDNStrategy("/var/lib/xxxx/uuid", upnp.NewHardwareAddressUDNStrategy(""))
+	sampleDev.StateStore = upnp.NewFileDeviceStateStore("/var/lib/xxxx/state.json")
+
+The methods of a Go value can be also bound to the actions with Device.BindService. The in-arguments and the out-arguments are converted by the data types of the related state variables as the following:
+
+	func (self *SampleDevice) SetTarget(ctx context.Context, newTargetValue bool) error {
+		...
+	}
+	...
+	err = sampleDev.BindService("urn:schemas-upnp-org:service:xxxx:x", sampleDev)
 */
 package upnp
@@ -5,6 +5,8 @@
 package upnp
 
 import (
+	"errors"
+
 	"github.com/cybergarage/go-net-upnp/net/upnp/control"
 )
 
@@ -18,6 +20,7 @@ type Error interface {
 const (
 	ErrorInvalidAction                = control.ErrorInvalidAction
 	ErrorInvalidArgs                  = control.ErrorInvalidArgs
+	ErrorInvalidVar                   = control.ErrorInvalidVar
 	ErrorActionFailed                 = control.ErrorActionFailed
 	ErrorArgumentValueInvalid         = control.ErrorArgumentValueInvalid
 	ErrorArgumentValueOutOfRange      = control.ErrorArgumentValueOutOfRange
@@ -25,9 +28,19 @@ const (
 	ErrorOutOfMemory                  = control.ErrorOutOfMemory
 	ErrorHumanInterventionRequired    = control.ErrorHumanInterventionRequired
 	ErrorStringArgumentTooLong        = control.ErrorStringArgumentTooLong
+	ErrorActionNotAuthorized          = control.ErrorActionNotAuthorized
 )
 
 // NewErrorFromCode returns a new Error from the specified code.
 func NewErrorFromCode(code int) Error {
 	return control.NewUPnPErrorFromCode(code)
 }
+
+// NewErrorFromError returns the UPnP error in the specified error chain, or an ErrorActionFailed error when no UPnP error is found.
+func NewErrorFromError(err error) Error {
+	var upnpErr Error
+	if errors.As(err, &upnpErr) {
+		return upnpErr
+	}
+	return NewErrorFromCode(ErrorActionFailed)
+}
@@ -0,0 +1,25 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package event
+
+import (
+	"encoding/xml"
+)
+
+const (
+	NT  = "upnp:event"
+	NTS = "upnp:propchange"
+
+	SIDPrefix       = "uuid:"
+	TimeoutPrefix   = "Second-"
+	TimeoutInfinite = "infinite"
+	DefaultTimeout  = 1800
+	MinTimeout      = 60
+
+	MaxSEQ = 4294967295
+
+	xmlMarshallIndent = " "
+	xmlHeader         = xml.Header
+)
@@ -0,0 +1,11 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package event
+
+const (
+	errorInvalidTimeout  = "invalid timeout (%s)"
+	errorInvalidCallback = "invalid callback (%s)"
+	errorInvalidSEQ      = "invalid SEQ (%s)"
+)
@@ -0,0 +1,103 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package event
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
+)
+
+// CreateSID returns a new subscription identifier.
+func CreateSID() string {
+	return SIDPrefix + util.CreateUUID()
+}
+
+// ParseTimeout returns the seconds of the specified TIMEOUT header value, or zero when it is infinite.
+func ParseTimeout(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, TimeoutInfinite) {
+		return 0, nil
+	}
+
+	if len(value) < len(TimeoutPrefix) || !strings.EqualFold(value[:len(TimeoutPrefix)], TimeoutPrefix) {
+		return 0, fmt.Errorf(errorInvalidTimeout, value)
+	}
+
+	secValue := value[len(TimeoutPrefix):]
+	if strings.EqualFold(secValue, TimeoutInfinite) {
+		return 0, nil
+	}
+
+	sec, err := strconv.Atoi(secValue)
+	if err != nil || sec < 0 {
+		return 0, fmt.Errorf(errorInvalidTimeout, value)
+	}
+
+	return sec, nil
+}
+
+// FormatTimeout returns a TIMEOUT header value of the specified seconds.
+func FormatTimeout(sec int) string {
+	if sec <= 0 {
+		return TimeoutPrefix + TimeoutInfinite
+	}
+	return TimeoutPrefix + strconv.Itoa(sec)
+}
+
+// ParseCallback returns the delivery URLs of the specified CALLBACK header value.
+func ParseCallback(value string) ([]*url.URL, error) {
+	urls := make([]*url.URL, 0)
+
+	rest := strings.TrimSpace(value)
+	for 0 < len(rest) {
+		begin := strings.Index(rest, "<")
+		end := strings.Index(rest, ">")
+		if begin != 0 || end < begin {
+			return nil, fmt.Errorf(errorInvalidCallback, value)
+		}
+		deliveryURL, err := url.Parse(rest[begin+1 : end])
+		if err != nil || deliveryURL.Scheme != "http" || len(deliveryURL.Host) == 0 {
+			return nil, fmt.Errorf(errorInvalidCallback, value)
+		}
+		urls = append(urls, deliveryURL)
+		rest = strings.TrimSpace(rest[end+1:])
+	}
+
+	if len(urls) == 0 {
+		return nil, fmt.Errorf(errorInvalidCallback, value)
+	}
+
+	return urls, nil
+}
+
+// FormatCallback returns a CALLBACK header value of the specified delivery URLs.
+func FormatCallback(urls ...string) string {
+	var callback strings.Builder
+	for _, deliveryURL := range urls {
+		callback.WriteString("<" + deliveryURL + ">")
+	}
+	return callback.String()
+}
+
+// ParseSEQ returns the event key of the specified SEQ header value.
+func ParseSEQ(value string) (uint32, error) {
+	seq, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf(errorInvalidSEQ, value)
+	}
+	return uint32(seq), nil
+}
+
+// NextSEQ returns the event key following the specified key, which wraps to 1 after the maximum.
+func NextSEQ(seq uint32) uint32 {
+	if seq == MaxSEQ {
+		return 1
+	}
+	return seq + 1
+}
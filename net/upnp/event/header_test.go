@@ -0,0 +1,78 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package event
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTimeout(t *testing.T) {
+	timeouts := map[string]int{
+		"Second-1800":     1800,
+		"second-300":      300,
+		"Second-infinite": 0,
+		"infinite":        0,
+	}
+
+	for value, expected := range timeouts {
+		sec, err := ParseTimeout(value)
+		if err != nil {
+			t.Error(err)
+		}
+		if sec != expected {
+			t.Errorf("%s = %d : expected %d", value, sec, expected)
+		}
+	}
+
+	for _, value := range []string{"", "1800", "Second-", "Second-abc", "Minute-1"} {
+		_, err := ParseTimeout(value)
+		if err == nil {
+			t.Errorf("%s is parsed", value)
+		}
+	}
+
+	if FormatTimeout(1800) != "Second-1800" {
+		t.Error(FormatTimeout(1800))
+	}
+}
+
+func TestCallback(t *testing.T) {
+	urls, err := ParseCallback("<http://192.168.1.2:5004/event/1> <http://[fe80::1%25eth0]:5004/event/1>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 2 {
+		t.Errorf("%v", urls)
+	}
+
+	for _, value := range []string{"", "http://192.168.1.2/", "<http://192.168.1.2/", "<ftp://192.168.1.2/>"} {
+		_, err := ParseCallback(value)
+		if err == nil {
+			t.Errorf("%s is parsed", value)
+		}
+	}
+
+	callback := FormatCallback("http://192.168.1.2/a", "http://192.168.1.3/b")
+	if callback != "<http://192.168.1.2/a><http://192.168.1.3/b>" {
+		t.Error(callback)
+	}
+}
+
+func TestSEQ(t *testing.T) {
+	seq, err := ParseSEQ("4294967295")
+	if err != nil {
+		t.Error(err)
+	}
+	if NextSEQ(seq) != 1 {
+		t.Errorf("%d", NextSEQ(seq))
+	}
+	if NextSEQ(0) != 1 {
+		t.Errorf("%d", NextSEQ(0))
+	}
+	if !strings.HasPrefix(CreateSID(), SIDPrefix) {
+		t.Error(CreateSID())
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package event
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+const (
+	propertySetNamespace = "urn:schemas-upnp-org:event-1-0"
+	propertySetSpace     = "e"
+	propertySetPrefix    = propertySetSpace + ":"
+	propertySetSpaceAttr = "xmlns:" + propertySetSpace
+	propertySet          = "propertyset"
+	property             = "property"
+)
+
+// A Property represents a changed state variable in a property set.
+type Property struct {
+	Name  string
+	Value string
+}
+
+// A PropertySet represents an event message body of GENA.
+type PropertySet struct {
+	Properties []*Property
+}
+
+// NewPropertySet returns a new empty property set.
+func NewPropertySet() *PropertySet {
+	set := &PropertySet{}
+	set.Properties = make([]*Property, 0)
+	return set
+}
+
+// NewPropertySetFromBytes parses a property set from the specified XML bytes.
+func NewPropertySetFromBytes(propSetBytes []byte) (*PropertySet, error) {
+	set := NewPropertySet()
+	err := set.decodeXMLBytes(propSetBytes)
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// AddProperty adds a property of the specified name and value.
+func (set *PropertySet) AddProperty(name string, value string) {
+	set.Properties = append(set.Properties, &Property{Name: name, Value: value})
+}
+
+// decodeXMLBytes parses the variables in each property element.
+func (set *PropertySet) decodeXMLBytes(propSetBytes []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(propSetBytes))
+
+	inProperty := false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch elem := token.(type) {
+		case xml.StartElement:
+			if elem.Name.Local == property {
+				inProperty = true
+				continue
+			}
+			if !inProperty {
+				continue
+			}
+			var value string
+			if err := decoder.DecodeElement(&value, &elem); err != nil {
+				return err
+			}
+			set.AddProperty(elem.Name.Local, value)
+		case xml.EndElement:
+			if elem.Name.Local == property {
+				inProperty = false
+			}
+		}
+	}
+
+	return nil
+}
+
+func (set *PropertySet) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name.Local = propertySetPrefix + propertySet
+	start.Attr = []xml.Attr{
+		{Name: xml.Name{Local: propertySetSpaceAttr}, Value: propertySetNamespace},
+	}
+
+	e.EncodeToken(start)
+	for _, prop := range set.Properties {
+		propElem := xml.StartElement{Name: xml.Name{Local: propertySetPrefix + property}}
+		e.EncodeToken(propElem)
+		e.EncodeElement(prop.Value, xml.StartElement{Name: xml.Name{Local: prop.Name}})
+		e.EncodeToken(propElem.End())
+	}
+	e.EncodeToken(start.End())
+
+	return nil
+}
+
+// XMLContentString returns the property set as an XML string.
+func (set *PropertySet) XMLContentString() (string, error) {
+	buf, err := xml.MarshalIndent(set, "", xmlMarshallIndent)
+	if err != nil {
+		return "", err
+	}
+	return xmlHeader + string(buf), nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package event
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+const (
+	errorPropertySetInvalidCount    = "invalid property count = %d : expected %d"
+	errorPropertySetInvalidProperty = "invalid property (%s) = '%s' : expected (%s) = '%s'"
+)
+
+func TestNewPropertySetFromBytes(t *testing.T) {
+	const testPropertySet = xml.Header +
+		"<e:propertyset xmlns:e=\"urn:schemas-upnp-org:event-1-0\">" +
+		"  <e:property>" +
+		"    <Status>1</Status>" +
+		"  </e:property>" +
+		"  <e:property>" +
+		"    <LastChange>&lt;Event&gt;&lt;/Event&gt;</LastChange>" +
+		"  </e:property>" +
+		"</e:propertyset>"
+
+	set, err := NewPropertySetFromBytes([]byte(testPropertySet))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkPropertySet(t, set, []string{"Status", "LastChange"}, []string{"1", "<Event></Event>"})
+}
+
+func TestPropertySetXMLContentString(t *testing.T) {
+	names := []string{"Target", "Status", "LastChange"}
+	values := []string{"1", "", "<Event val=\"1\"/>"}
+
+	set := NewPropertySet()
+	for n := range names {
+		set.AddProperty(names[n], values[n])
+	}
+
+	xmlStr, err := set.XMLContentString()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set, err = NewPropertySetFromBytes([]byte(xmlStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkPropertySet(t, set, names, values)
+}
+
+func checkPropertySet(t *testing.T, set *PropertySet, names []string, values []string) {
+	t.Helper()
+
+	if len(set.Properties) != len(names) {
+		t.Fatalf(errorPropertySetInvalidCount, len(set.Properties), len(names))
+	}
+
+	for n, prop := range set.Properties {
+		if prop.Name != names[n] || prop.Value != values[n] {
+			t.Errorf(errorPropertySetInvalidProperty, prop.Name, prop.Value, names[n], values[n])
+		}
+	}
+}
@@ -5,26 +5,132 @@
 package http
 
 import (
+	"context"
+	"crypto/tls"
+	"net"
 	gohttp "net/http"
+	"sync"
+	"time"
 
 	"github.com/cybergarage/go-net-upnp/net/upnp/util"
 )
 
+const (
+	ClientDefaultTimeout               = 30 * time.Second
+	ClientDefaultDialTimeout           = 5 * time.Second
+	ClientDefaultResponseHeaderTimeout = 10 * time.Second
+	ClientDefaultIdleConnTimeout       = 90 * time.Second
+	ClientDefaultMaxIdleConns          = 32
+	ClientDefaultMaxIdleConnsPerHost   = 2
+)
+
+// A ClientConfig represents a configuration of Client.
+type ClientConfig struct {
+	// Timeout is the time limit of each request including reading the response body. No timeout when it is zero.
+	Timeout time.Duration
+	// DialTimeout is the time limit to connect to the server.
+	DialTimeout time.Duration
+	// ResponseHeaderTimeout is the time limit to wait for the response headers after writing the request.
+	ResponseHeaderTimeout time.Duration
+	// IdleConnTimeout is the time limit of the idle keep-alive connections in the pool.
+	IdleConnTimeout time.Duration
+	// MaxIdleConns is the maximum number of the idle keep-alive connections in the pool.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost is the maximum number of the idle keep-alive connections to each host.
+	MaxIdleConnsPerHost int
+	// DisableKeepAlives disables the keep-alive connections.
+	DisableKeepAlives bool
+	// TLSConfig is the TLS configuration for HTTPS URLs. The default configuration is used when it is nil.
+	TLSConfig *tls.Config
+	// UserAgent is the User-Agent header of the requests. util.GetUserAgent() is used when it is empty.
+	UserAgent string
+}
+
+// NewClientConfig returns a default client configuration.
+func NewClientConfig() *ClientConfig {
+	config := &ClientConfig{
+		Timeout:               ClientDefaultTimeout,
+		DialTimeout:           ClientDefaultDialTimeout,
+		ResponseHeaderTimeout: ClientDefaultResponseHeaderTimeout,
+		IdleConnTimeout:       ClientDefaultIdleConnTimeout,
+		MaxIdleConns:          ClientDefaultMaxIdleConns,
+		MaxIdleConnsPerHost:   ClientDefaultMaxIdleConnsPerHost,
+		DisableKeepAlives:     false,
+		TLSConfig:             nil,
+		UserAgent:             "",
+	}
+	return config
+}
+
 // A Client represents a Client.
 type Client struct {
 	*gohttp.Client
+	UserAgent string
 }
 
-// NewClient returns a new Client.
+var defaultClient *Client
+var defaultClientOnce sync.Once
+
+// GetDefaultClient returns the shared client which is created with the default configuration.
+func GetDefaultClient() *Client {
+	defaultClientOnce.Do(func() {
+		defaultClient = NewClientWithConfig(NewClientConfig())
+	})
+	return defaultClient
+}
+
+// NewClient returns a new Client with the default configuration.
 func NewClient() (*Client, error) {
-	client := &Client{}
-	client.Client = &gohttp.Client{}
-	return client, nil
+	return NewClientWithConfig(NewClientConfig()), nil
+}
+
+// NewClientWithConfig returns a new Client with the specified configuration.
+// The transport doesn't use any proxies because UPnP devices are in the local network.
+func NewClientWithConfig(config *ClientConfig) *Client {
+	dialer := &net.Dialer{
+		Timeout: config.DialTimeout,
+	}
+
+	transport := &gohttp.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       config.TLSConfig,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		DisableKeepAlives:     config.DisableKeepAlives,
+	}
+
+	client := &Client{
+		Client: &gohttp.Client{
+			Transport: transport,
+			Timeout:   config.Timeout,
+		},
+		UserAgent: config.UserAgent,
+	}
+
+	return client
 }
 
+// WithTimeout returns a shallow copy of the client with the specified timeout. The copy shares the connection pool.
+func (client *Client) WithTimeout(timeout time.Duration) *Client {
+	goClient := *client.Client
+	goClient.Timeout = timeout
+	return &Client{
+		Client:    &goClient,
+		UserAgent: client.UserAgent,
+	}
+}
+
+// Do sends the specified request, and returns the response.
 func (client *Client) Do(req *Request) (*Response, error) {
 	if ua := req.Header.Get(UserAgent); ua == "" {
-		req.Header.Set(UserAgent, util.GetUserAgent())
+		ua = client.UserAgent
+		if len(ua) == 0 {
+			ua = util.GetUserAgent()
+		}
+		req.Header.Set(UserAgent, ua)
 	}
 	res, err := client.Client.Do(req.Request)
 	if err != nil {
@@ -32,3 +138,12 @@ func (client *Client) Do(req *Request) (*Response, error) {
 	}
 	return NewResponseFromResponse(res), nil
 }
+
+// GetContext sends a GET request of the specified URL with the specified context.
+func (client *Client) GetContext(ctx context.Context, url string) (*Response, error) {
+	req, err := NewRequestWithContext(ctx, GET, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
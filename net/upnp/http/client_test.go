@@ -9,6 +9,7 @@ import (
 	"io"
 	gohttp "net/http"
 	"testing"
+	"time"
 
 	"github.com/cybergarage/go-net-upnp/net/upnp/util"
 )
@@ -25,7 +26,7 @@ func (f RoundTripFunc) RoundTrip(req *gohttp.Request) (*gohttp.Response, error)
 
 func NewTestClient(fn RoundTripFunc) *Client {
 	return &Client{
-		&gohttp.Client{
+		Client: &gohttp.Client{
 			Transport: RoundTripFunc(fn),
 		},
 	}
@@ -99,3 +100,59 @@ func TestDoWithUserAgentDoesntOverwrite(t *testing.T) {
 
 	client.Do(testRequest)
 }
+
+func TestNewClientWithConfig(t *testing.T) {
+	config := NewClientConfig()
+	config.Timeout = time.Second
+	config.MaxIdleConnsPerHost = 4
+	config.UserAgent = "foo/bar"
+
+	client := NewClientWithConfig(config)
+	if client.Timeout != config.Timeout {
+		t.Fatalf(assertMessageValuesNotEqual, config.Timeout, client.Timeout)
+	}
+
+	transport, ok := client.Transport.(*gohttp.Transport)
+	if !ok {
+		t.Fatalf(assertMessageValuesNotEqual, "*http.Transport", client.Transport)
+	}
+	if transport.Proxy != nil {
+		t.Fatal("Transport has unexpected proxy")
+	}
+	if transport.MaxIdleConnsPerHost != config.MaxIdleConnsPerHost {
+		t.Fatalf(assertMessageValuesNotEqual, config.MaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	}
+
+	// WithTimeout shares the transport
+
+	timeoutClient := client.WithTimeout(2 * time.Second)
+	if timeoutClient.Timeout != 2*time.Second || client.Timeout != config.Timeout {
+		t.Fatalf(assertMessageValuesNotEqual, 2*time.Second, timeoutClient.Timeout)
+	}
+	if timeoutClient.Transport != client.Transport || timeoutClient.UserAgent != client.UserAgent {
+		t.Fatal("Client copy does not share the transport")
+	}
+}
+
+func TestDoWithClientUserAgent(t *testing.T) {
+	testUA := "foo/bar"
+
+	testRequest, err := NewRequest(gohttp.MethodGet, "http://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewTestClient(func(req *gohttp.Request) *gohttp.Response {
+		if testUA != req.UserAgent() {
+			t.Fatalf(assertMessageStringsNotEqual, testUA, req.UserAgent())
+		}
+		return &gohttp.Response{
+			StatusCode: gohttp.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`OK`)),
+			Header:     make(gohttp.Header),
+		}
+	})
+	client.UserAgent = testUA
+
+	client.Do(testRequest)
+}
@@ -9,10 +9,20 @@ const (
 	POST        = "POST"
 	SUBSCRIBE   = "SUBSCRIBE"
 	UNSUBSCRIBE = "UNSUBSCRIBE"
+	NOTIFY      = "NOTIFY"
 
-	UserAgent    = "User-Agent"
-	ContentType  = "Content-Type"
-	ServerHeader = "Server"
+	UserAgent     = "User-Agent"
+	ContentType   = "Content-Type"
+	ContentLength = "Content-Length"
+	ServerHeader  = "Server"
+	DateHeader    = "Date"
+
+	NT       = "NT"
+	NTS      = "NTS"
+	SID      = "SID"
+	SEQ      = "SEQ"
+	Callback = "CALLBACK"
+	Timeout  = "TIMEOUT"
 
 	SOAPAction      = "SOAPACTION"
 	SOAPActionDelim = "#"
@@ -6,4 +6,5 @@ package http
 
 const (
 	assertMessageStringsNotEqual = "Expected %s is not equal to actual %s"
+	assertMessageValuesNotEqual  = "Expected %v is not equal to actual %v"
 )
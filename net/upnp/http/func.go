@@ -5,13 +5,15 @@
 package http
 
 import (
-	gohttp "net/http"
+	"context"
 )
 
+// Get sends a GET request of the specified URL using the default client.
 func Get(url string) (*Response, error) {
-	res, err := gohttp.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	return NewResponseFromResponse(res), nil
+	return GetContext(context.Background(), url)
+}
+
+// GetContext sends a GET request of the specified URL with the specified context using the default client.
+func GetContext(ctx context.Context, url string) (*Response, error) {
+	return GetDefaultClient().GetContext(ctx, url)
 }
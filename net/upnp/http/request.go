@@ -5,6 +5,7 @@
 package http
 
 import (
+	"context"
 	"io"
 	gohttp "net/http"
 	"net/url"
@@ -31,9 +32,24 @@ func NewRequest(method, urlStr string, body io.Reader) (*Request, error) {
 	return httpReq, nil
 }
 
+// NewRequestWithContext returns a new Request with the specified context.
+func NewRequestWithContext(ctx context.Context, method, urlStr string, body io.Reader) (*Request, error) {
+	req, err := gohttp.NewRequestWithContext(ctx, method, urlStr, body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq := NewRequestFromRequest(req)
+	return httpReq, nil
+}
+
 // NewSOAPRequest returns a new Request.
 func NewSOAPRequest(url *url.URL, soapAction string, body io.Reader) (*Request, error) {
-	httpReq, err := NewRequest(POST, url.String(), body)
+	return NewSOAPRequestWithContext(context.Background(), url, soapAction, body)
+}
+
+// NewSOAPRequestWithContext returns a new SOAP request with the specified context.
+func NewSOAPRequestWithContext(ctx context.Context, url *url.URL, soapAction string, body io.Reader) (*Request, error) {
+	httpReq, err := NewRequestWithContext(ctx, POST, url.String(), body)
 	if err != nil {
 		return nil, err
 	}
@@ -44,13 +60,31 @@ func NewSOAPRequest(url *url.URL, soapAction string, body io.Reader) (*Request,
 	return httpReq, nil
 }
 
+// NewNotifyRequest returns a new event notification request.
+func NewNotifyRequest(url *url.URL, body io.Reader) (*Request, error) {
+	httpReq, err := NewRequest(NOTIFY, url.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Add(ContentType, ContentTypeXML)
+
+	return httpReq, nil
+}
+
+// SetRawHeader sets the specified header without canonicalizing the name such as SID or SEQ.
+func SetRawHeader(header gohttp.Header, name string, value string) {
+	header[name] = []string{value}
+}
+
 func (req *Request) IsSOAPRequest() bool {
 	_, ok := req.Header[SOAPAction]
 	return ok
 }
 
 func (req *Request) GetSOAPServiceActionName() (string, bool) {
-	soapAction := req.Header.Get(SOAPAction)
+	// The SOAPACTION header is a quoted string such as "urn:schemas-upnp-org:service:serviceType:v#actionName".
+	soapAction := strings.Trim(req.Header.Get(SOAPAction), "\"")
 	if len(soapAction) == 0 {
 		return "", false
 	}
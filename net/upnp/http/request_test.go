@@ -43,3 +43,20 @@ func TestNewRequestFromRequest(t *testing.T) {
 		t.Fatal("Request has not embedded \"net/http\".Request")
 	}
 }
+
+func TestGetSOAPServiceActionName(t *testing.T) {
+	for _, soapAction := range []string{
+		"urn:schemas-upnp-org:control-1-0#QueryStateVariable",
+		"\"urn:schemas-upnp-org:control-1-0#QueryStateVariable\"",
+	} {
+		req, err := NewRequest(POST, "http://example.com", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add(SOAPAction, soapAction)
+		actionName, ok := req.GetSOAPServiceActionName()
+		if !ok || actionName != "QueryStateVariable" {
+			t.Fatalf(assertMessageStringsNotEqual, "QueryStateVariable", actionName)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net"
 	gohttp "net/http"
+	"strconv"
 	"time"
 
 	"github.com/cybergarage/go-net-upnp/net/upnp/util"
@@ -31,17 +32,20 @@ type RequestListener interface {
 type Server struct {
 	*gohttp.Server
 
-	Conn     net.Listener
-	Listener RequestListener
+	Conns     []net.Listener
+	Listener  RequestListener
+	Addresses []string
 }
 
 // NewServer returns a new Server.
 func NewServer() *Server {
 	Server := &Server{}
+	Server.Conns = make([]net.Listener, 0)
+	Server.Addresses = make([]string, 0)
 	return Server
 }
 
-// Start starts this server.
+// Start starts this server on the specified port of the addresses, or all addresses when no address is specified.
 func (server *Server) Start(port int) error {
 	server.Server = &gohttp.Server{
 		Addr:           fmt.Sprintf(":%d", port),
@@ -51,23 +55,37 @@ func (server *Server) Start(port int) error {
 		MaxHeaderBytes: MaxHeaderBytes,
 	}
 
-	var err error
-	server.Conn, err = net.Listen("tcp", server.Addr)
-	if err != nil {
-		return err
+	bindAddrs := []string{server.Addr}
+	if 0 < len(server.Addresses) {
+		bindAddrs = make([]string, len(server.Addresses))
+		for n, addr := range server.Addresses {
+			bindAddrs[n] = net.JoinHostPort(addr, strconv.Itoa(port))
+		}
 	}
 
-	go server.Server.Serve(server.Conn.(*net.TCPListener))
+	server.Conns = make([]net.Listener, 0, len(bindAddrs))
+	for _, bindAddr := range bindAddrs {
+		conn, err := net.Listen("tcp", bindAddr)
+		if err != nil {
+			server.Stop()
+			return err
+		}
+		server.Conns = append(server.Conns, conn)
+	}
+
+	for _, conn := range server.Conns {
+		go server.Server.Serve(conn)
+	}
 
 	return nil
 }
 
 // Stop stops this server.
 func (server *Server) Stop() error {
-	if server.Conn != nil {
-		server.Conn.Close()
-		server.Conn = nil
+	for _, conn := range server.Conns {
+		conn.Close()
 	}
+	server.Conns = make([]net.Listener, 0)
 	return nil
 }
 
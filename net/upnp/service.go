@@ -5,12 +5,16 @@
 package upnp
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"net/url"
 	"strings"
+	"sync"
 
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/event"
 	"github.com/cybergarage/go-net-upnp/net/upnp/http"
 )
 
@@ -18,14 +22,17 @@ const (
 	defaultServiceScpdURL    = "/service/scpd/%s.xml"
 	defaultServiceControlURL = "/service/control/%s"
 	defaultServiceEventURL   = "/service/event/%s"
+
+	defaultServiceURLIDFormat = "%s-%d"
 )
 
 const (
-	errorServiceDescriptionNotFound = "service (%s) description is not found"
-	errorServiceHasNoActions        = "service (%s) has no actions"
-	errorServiceActionNotFound      = "action (%s) is not found in the service (%s)"
-	errorServiceHasNoParentDevice   = "service (%s) has no parent device"
-	errorServiceBadSCPDURL          = "SCPDURL (%s) is bad response (%d)"
+	errorServiceDescriptionNotFound   = "service (%s) description is not found"
+	errorServiceHasNoActions          = "service (%s) has no actions"
+	errorServiceActionNotFound        = "action (%s) is not found in the service (%s)"
+	errorServiceHasNoParentDevice     = "service (%s) has no parent device"
+	errorServiceBadSCPDURL            = "SCPDURL (%s) is bad response (%d)"
+	errorServiceStateVariableNotFound = "state variable (%s) is not found in the service (%s)"
 )
 
 // A Service represents a UPnP service.
@@ -41,6 +48,12 @@ type Service struct {
 	ServiceStateTable *ServiceStateTable  `xml:"-"`
 	ActionList        *ActionList         `xml:"-"`
 	ParentDevice      *Device             `xml:"-"`
+
+	stateLock      *sync.RWMutex            `xml:"-"`
+	mcastSEQ       uint32                   `xml:"-"`
+	subscribers    *SubscriberList          `xml:"-"`
+	actionHandler  ActionHandler            `xml:"-"`
+	actionHandlers map[string]ActionHandler `xml:"-"`
 }
 
 // NewService returns a new Service.
@@ -88,6 +101,16 @@ func (service *Service) LoadDescriptionBytes(descBytes []byte) error {
 
 // LoadDescriptionFromSCPDURL loads and parses the SCPD from the service's SCPDURL.
 func (service *Service) LoadDescriptionFromSCPDURL() error {
+	return service.LoadDescriptionFromSCPDURLContext(context.Background())
+}
+
+// LoadDescriptionFromSCPDURLContext loads and parses the SCPD from the service's SCPDURL with the specified context.
+func (service *Service) LoadDescriptionFromSCPDURLContext(ctx context.Context) error {
+	return service.loadDescriptionFromSCPDURLWithClient(ctx, service.getHTTPClient())
+}
+
+// loadDescriptionFromSCPDURLWithClient loads and parses the SCPD using the specified client.
+func (service *Service) loadDescriptionFromSCPDURLWithClient(ctx context.Context, client *http.Client) error {
 	// Some services has no SCPDURL such as Panasonic AiSEG001
 	if len(service.SCPDURL) == 0 {
 		return nil
@@ -98,10 +121,11 @@ func (service *Service) LoadDescriptionFromSCPDURL() error {
 		return err
 	}
 
-	res, err := http.Get(scpdURL.String())
+	res, err := client.GetContext(ctx, scpdURL.String())
 	if err != nil {
 		return fmt.Errorf("%w (%s)", err, scpdURL)
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
 		return fmt.Errorf(errorServiceBadSCPDURL, scpdURL.String(), res.StatusCode)
@@ -117,7 +141,7 @@ func (service *Service) LoadDescriptionFromSCPDURL() error {
 		return fmt.Errorf("%w (%s)", err, scpdURL)
 	}
 
-	return res.Body.Close()
+	return nil
 }
 
 // DescriptionString returns a descrition string.
@@ -154,24 +178,50 @@ func (service *Service) reviseParentObject() error {
 		}
 	}
 
+	if service.stateLock == nil {
+		service.stateLock = &sync.RWMutex{}
+	}
+
 	return nil
 }
 
-func (service *Service) reviseDescription() error {
+// newUniqueServiceURL returns a default URL which is not included in the used URLs, and adds it to the used URLs.
+func newUniqueServiceURL(format string, shortServiceID string, usedURLs map[string]bool) string {
+	url := fmt.Sprintf(format, shortServiceID)
+	for n := 2; usedURLs[url]; n++ {
+		url = fmt.Sprintf(format, fmt.Sprintf(defaultServiceURLIDFormat, shortServiceID, n))
+	}
+	usedURLs[url] = true
+	return url
+}
+
+func (service *Service) reviseDescription(usedURLs map[string]bool) error {
 	shortServiceID := service.getShortServiceType()
 
 	// check description URLs
 
 	if len(service.SCPDURL) == 0 {
-		service.SCPDURL = fmt.Sprintf(defaultServiceScpdURL, shortServiceID)
+		service.SCPDURL = newUniqueServiceURL(defaultServiceScpdURL, shortServiceID, usedURLs)
 	}
 
 	if len(service.ControlURL) == 0 {
-		service.ControlURL = fmt.Sprintf(defaultServiceControlURL, shortServiceID)
+		service.ControlURL = newUniqueServiceURL(defaultServiceControlURL, shortServiceID, usedURLs)
 	}
 
 	if len(service.EventSubURL) == 0 {
-		service.EventSubURL = fmt.Sprintf(defaultServiceEventURL, shortServiceID)
+		service.EventSubURL = newUniqueServiceURL(defaultServiceEventURL, shortServiceID, usedURLs)
+	}
+
+	// initialize state variables
+
+	for _, statVar := range service.GetStateVariables() {
+		if len(statVar.Value) == 0 {
+			statVar.Value = statVar.DefaultValue
+		}
+	}
+
+	if service.subscribers == nil {
+		service.subscribers = NewSubscriberList()
 	}
 
 	return nil
@@ -189,6 +239,14 @@ func (service *Service) isEventSubURL(path string) bool {
 	return path == service.EventSubURL
 }
 
+// getHTTPClient returns the HTTP client of the parent device, or the default client when it has no parent device.
+func (service *Service) getHTTPClient() *http.Client {
+	if service.ParentDevice == nil {
+		return http.GetDefaultClient()
+	}
+	return service.ParentDevice.GetHTTPClient()
+}
+
 func (service *Service) getAbsoluteURL(path string) (*url.URL, error) {
 	if service.ParentDevice == nil {
 		return nil, fmt.Errorf(errorServiceHasNoParentDevice, service.ServiceType)
@@ -210,6 +268,9 @@ func (service *Service) GetAbsoluteEventSubURL() (*url.URL, error) {
 
 // GetActions returns all actions.
 func (service *Service) GetActions() []*Action {
+	if service.ActionList == nil {
+		return []*Action{}
+	}
 	actionCnt := len(service.ActionList.Actions)
 	actions := make([]*Action, actionCnt)
 	for n := range actionCnt {
@@ -237,3 +298,74 @@ func (service *Service) GetActionByName(name string) (*Action, error) {
 
 	return nil, fmt.Errorf(errorServiceActionNotFound, name, service.ServiceType)
 }
+
+// GetStateVariables returns all state variables.
+func (service *Service) GetStateVariables() []*StateVariable {
+	if service.ServiceStateTable == nil {
+		return []*StateVariable{}
+	}
+	statVarCnt := len(service.ServiceStateTable.StateVariables)
+	statVars := make([]*StateVariable, statVarCnt)
+	for n := range statVarCnt {
+		statVars[n] = &service.ServiceStateTable.StateVariables[n]
+	}
+	return statVars
+}
+
+// GetStateVariableByName returns a state variable by the specified name.
+func (service *Service) GetStateVariableByName(name string) (*StateVariable, error) {
+	for _, statVar := range service.GetStateVariables() {
+		if statVar.Name == name {
+			return statVar, nil
+		}
+	}
+	return nil, fmt.Errorf(errorServiceStateVariableNotFound, name, service.ServiceType)
+}
+
+// GetSubscribers returns all active subscribers of the service.
+func (service *Service) GetSubscribers() []*Subscriber {
+	if service.subscribers == nil {
+		return []*Subscriber{}
+	}
+	return service.subscribers.GetSubscribers()
+}
+
+// newInitialPropertySet returns a property set of all evented state variables. It is called with the state lock of the service.
+func (service *Service) newInitialPropertySet() *event.PropertySet {
+	set := event.NewPropertySet()
+	for _, statVar := range service.GetStateVariables() {
+		if !statVar.IsEvented() {
+			continue
+		}
+		set.AddProperty(statVar.Name, statVar.Value)
+	}
+	return set
+}
+
+// addSubscriber queues the initial event of the current values to the specified subscriber, and adds it.
+// The subscriber is added with the state lock, so that the changed values are queued after the initial event.
+func (service *Service) addSubscriber(sub *Subscriber) error {
+	service.stateLock.Lock()
+	defer service.stateLock.Unlock()
+
+	err := sub.notify(service.newInitialPropertySet())
+	if err != nil {
+		return err
+	}
+
+	service.subscribers.AddSubscriber(sub)
+
+	return nil
+}
+
+// notifyPropertySet queues the specified property set to all subscribers. It is called with the state lock of the service.
+// The subscribers which have too many undelivered events are dropped, and they have to subscribe again.
+func (service *Service) notifyPropertySet(set *event.PropertySet) {
+	for _, sub := range service.GetSubscribers() {
+		err := sub.notify(set)
+		if err != nil {
+			service.subscribers.RemoveSubscriber(sub.SID)
+			log.Warnf("%s", err.Error())
+		}
+	}
+}
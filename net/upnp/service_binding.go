@@ -0,0 +1,454 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/datatype"
+)
+
+const (
+	serviceBindingTag = "upnp"
+)
+
+const (
+	errorServiceBindingNoMethods        = "%s has no methods for the actions of the service (%s)"
+	errorServiceBindingPromotedMethods  = "%s has the promoted methods for the actions (%s) of the service (%s)"
+	errorServiceBindingPromotedMethod   = "%s (promoted from %s)"
+	errorServiceBindingBadMethod        = "method (%s) is invalid for the action : %w"
+	errorServiceBindingNoContext        = "the first parameter is not context.Context"
+	errorServiceBindingBadInCount       = "the number of parameters is %d : expected %d in-arguments"
+	errorServiceBindingBadOutCount      = "the number of results is %d : expected %d out-arguments and error"
+	errorServiceBindingNoError          = "the last result is not error"
+	errorServiceBindingOutFieldNotFound = "the field for the out-argument (%s) is not found"
+	errorServiceBindingTypeMismatch     = "the type %s of the argument (%s) is not compatible with the data type (%s)"
+	errorServiceBindingUnsupportedType  = "the type %s is not supported"
+	errorServiceBindingValueOutOfRange  = "value (%s) overflows %s"
+	errorServiceBindingNoActions        = "service (%s) has no actions"
+)
+
+var (
+	contextType = reflect.TypeFor[context.Context]()
+	errorType   = reflect.TypeFor[error]()
+	timeType    = reflect.TypeFor[time.Time]()
+	bytesType   = reflect.TypeFor[[]byte]()
+)
+
+// A serviceMethod represents a method which is bound to an action.
+type serviceMethod struct {
+	method    reflect.Value
+	inArgs    []string
+	outArgs   []string
+	outFields [][]int
+}
+
+// A serviceBinding represents an action handler which calls the bound methods.
+type serviceBinding struct {
+	methods map[string]*serviceMethod
+}
+
+// isBindableType returns true when the specified Go type is compatible with the data type.
+func isBindableType(t reflect.Type, dataType string) bool {
+	switch t {
+	case timeType:
+		return datatype.IsTime(dataType)
+	case bytesType:
+		return datatype.IsBinary(dataType)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return true
+	case reflect.Bool:
+		return dataType == datatype.Boolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return datatype.IsInteger(dataType)
+	case reflect.Float32, reflect.Float64:
+		return datatype.IsNumeric(dataType)
+	}
+
+	return false
+}
+
+// checkBindableArgument returns an error when the specified Go type isn't compatible with the argument.
+func checkBindableArgument(t reflect.Type, arg *Argument) error {
+	statVar, err := arg.GetStateVariable()
+	if err != nil {
+		return err
+	}
+	if !isBindableType(t, statVar.DataType) {
+		return fmt.Errorf(errorServiceBindingTypeMismatch, t, arg.Name, statVar.DataType)
+	}
+	return nil
+}
+
+// isOutStruct returns true when the specified type is a struct or a pointer to a struct for the out-arguments.
+func isOutStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// getOutStructField returns the field index for the specified out-argument name.
+// The field is matched by the upnp tag, or by the field name case-insensitively.
+func getOutStructField(t reflect.Type, name string) ([]int, bool) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() {
+			continue
+		}
+		if tag, ok := field.Tag.Lookup(serviceBindingTag); ok {
+			if tag == name {
+				return field.Index, true
+			}
+			continue
+		}
+		if strings.EqualFold(field.Name, name) {
+			return field.Index, true
+		}
+	}
+	return nil, false
+}
+
+// getPromotingField returns the embedded field which has a method of the specified name.
+// The method of the type is promoted from the field, or shadows the method of the field.
+func getPromotingField(t reflect.Type, name string) (reflect.StructField, bool) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	for n := range t.NumField() {
+		field := t.Field(n)
+		if !field.Anonymous {
+			continue
+		}
+		if _, ok := field.Type.MethodByName(name); ok {
+			return field, true
+		}
+		if field.Type.Kind() != reflect.Pointer && field.Type.Kind() != reflect.Interface {
+			if _, ok := reflect.PointerTo(field.Type).MethodByName(name); ok {
+				return field, true
+			}
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// newServiceMethod returns a serviceMethod after checking the signature of the specified method with the action.
+func newServiceMethod(method reflect.Value, action *Action) (*serviceMethod, error) {
+	methodType := method.Type()
+	inArgs := action.GetInputArguments()
+	outArgs := action.GetOutputArguments()
+
+	sm := &serviceMethod{
+		method:  method,
+		inArgs:  make([]string, len(inArgs)),
+		outArgs: make([]string, len(outArgs)),
+	}
+
+	// parameters
+
+	if methodType.NumIn() == 0 || methodType.In(0) != contextType {
+		return nil, errors.New(errorServiceBindingNoContext)
+	}
+	if methodType.IsVariadic() || methodType.NumIn() != len(inArgs)+1 {
+		return nil, fmt.Errorf(errorServiceBindingBadInCount, methodType.NumIn()-1, len(inArgs))
+	}
+	for n, arg := range inArgs {
+		if err := checkBindableArgument(methodType.In(n+1), arg); err != nil {
+			return nil, err
+		}
+		sm.inArgs[n] = arg.Name
+	}
+
+	// results
+
+	numOut := methodType.NumOut()
+	if numOut == 0 || methodType.Out(numOut-1) != errorType {
+		return nil, errors.New(errorServiceBindingNoError)
+	}
+
+	for n, arg := range outArgs {
+		sm.outArgs[n] = arg.Name
+	}
+
+	if numOut == 2 && isOutStruct(methodType.Out(0)) {
+		structType := methodType.Out(0)
+		sm.outFields = make([][]int, len(outArgs))
+		for n, arg := range outArgs {
+			index, ok := getOutStructField(structType, arg.Name)
+			if !ok {
+				return nil, fmt.Errorf(errorServiceBindingOutFieldNotFound, arg.Name)
+			}
+			fieldType := structType
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if err := checkBindableArgument(fieldType.FieldByIndex(index).Type, arg); err != nil {
+				return nil, err
+			}
+			sm.outFields[n] = index
+		}
+		return sm, nil
+	}
+
+	if numOut != len(outArgs)+1 {
+		return nil, fmt.Errorf(errorServiceBindingBadOutCount, numOut, len(outArgs))
+	}
+	for n, arg := range outArgs {
+		if err := checkBindableArgument(methodType.Out(n), arg); err != nil {
+			return nil, err
+		}
+	}
+
+	return sm, nil
+}
+
+// NewServiceActionHandler returns an action handler which calls the methods of the specified value for the actions of the service.
+// The methods are matched by the action names, and have the signatures as the following:
+//
+//	func (s *SwitchPower) SetTarget(ctx context.Context, newTargetValue bool) error
+//	func (s *SwitchPower) GetTarget(ctx context.Context) (bool, error)
+//	func (s *SwitchPower) GetStatus(ctx context.Context) (*GetStatusResult, error)
+//
+// The in-arguments are passed after the context in order, and the out-arguments are returned in order or as the fields of a struct.
+// The struct fields are matched by the upnp tag or by the out-argument names case-insensitively.
+// The Go types are checked with the data types of the related state variables in the loaded service description.
+// The actions which have no methods return ErrorOptionalActionNotImplemented, but at least one action must have the method.
+// Only the methods which are declared on the type of the value are bound. The methods which have the same names as the methods
+// of the embedded fields, such as Start and Stop of an embedded Device, are rejected so that they are never called as the actions.
+func NewServiceActionHandler(service *Service, v any) (ActionHandler, error) {
+	actions := service.GetActions()
+	if len(actions) == 0 {
+		return nil, fmt.Errorf(errorServiceBindingNoActions, service.ServiceType)
+	}
+
+	rv := reflect.ValueOf(v)
+	binding := &serviceBinding{
+		methods: map[string]*serviceMethod{},
+	}
+
+	promotedActions := make([]string, 0)
+	for _, action := range actions {
+		method := rv.MethodByName(action.Name)
+		if !method.IsValid() {
+			continue
+		}
+		if field, ok := getPromotingField(rv.Type(), action.Name); ok {
+			promotedActions = append(promotedActions, fmt.Sprintf(errorServiceBindingPromotedMethod, action.Name, field.Type))
+			continue
+		}
+		sm, err := newServiceMethod(method, action)
+		if err != nil {
+			return nil, fmt.Errorf(errorServiceBindingBadMethod, action.Name, err)
+		}
+		binding.methods[action.Name] = sm
+	}
+
+	if 0 < len(promotedActions) {
+		return nil, fmt.Errorf(errorServiceBindingPromotedMethods, rv.Type(), strings.Join(promotedActions, ", "), service.ServiceType)
+	}
+
+	if len(binding.methods) == 0 {
+		return nil, fmt.Errorf(errorServiceBindingNoMethods, rv.Type(), service.ServiceType)
+	}
+
+	return binding, nil
+}
+
+// BindService binds the methods of the specified value to the actions of the specified service type or ID.
+// The services of the embedded devices are included. See NewServiceActionHandler for the method signatures.
+func (dev *Device) BindService(service string, v any) error {
+	services := dev.getServicesByTypeOrID(service)
+	if len(services) == 0 {
+		return fmt.Errorf(errorDeviceActionHandlerServiceNotFound, service)
+	}
+
+	handlers := make([]ActionHandler, len(services))
+	for n, s := range services {
+		handler, err := NewServiceActionHandler(s, v)
+		if err != nil {
+			return err
+		}
+		handlers[n] = handler
+	}
+
+	for n, s := range services {
+		s.actionHandler = handlers[n]
+	}
+
+	return nil
+}
+
+// decodeArgumentValue returns a value of the specified Go type from the argument.
+func decodeArgumentValue(t reflect.Type, arg *Argument) (reflect.Value, error) {
+	dataType := arg.GetDataType()
+	v := reflect.New(t).Elem()
+
+	switch t {
+	case timeType:
+		tv, err := datatype.ParseTime(dataType, arg.Value)
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.ValueOf(tv))
+		return v, nil
+	case bytesType:
+		b, err := datatype.ParseBytes(dataType, arg.Value)
+		if err != nil {
+			return v, err
+		}
+		v.SetBytes(b)
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(arg.Value)
+	case reflect.Bool:
+		b, err := datatype.ParseBool(arg.Value)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := datatype.ParseInt(dataType, arg.Value)
+		if err != nil {
+			return v, err
+		}
+		if v.OverflowInt(i) {
+			return v, fmt.Errorf(errorServiceBindingValueOutOfRange, arg.Value, t)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := datatype.ParseUint(dataType, arg.Value)
+		if err != nil {
+			return v, err
+		}
+		if v.OverflowUint(u) {
+			return v, fmt.Errorf(errorServiceBindingValueOutOfRange, arg.Value, t)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := datatype.ParseFloat(dataType, arg.Value)
+		if err != nil {
+			return v, err
+		}
+		if v.OverflowFloat(f) {
+			return v, fmt.Errorf(errorServiceBindingValueOutOfRange, arg.Value, t)
+		}
+		v.SetFloat(f)
+	default:
+		return v, fmt.Errorf(errorServiceBindingUnsupportedType, t)
+	}
+
+	return v, nil
+}
+
+// encodeArgumentValue sets the specified value into the argument.
+func encodeArgumentValue(v reflect.Value, arg *Argument) error {
+	switch v.Type() {
+	case timeType:
+		return arg.SetTime(v.Interface().(time.Time))
+	case bytesType:
+		return arg.SetBytes(v.Bytes())
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return arg.SetString(v.String())
+	case reflect.Bool:
+		return arg.SetBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		str, err := datatype.FormatInt(arg.GetDataType(), v.Int())
+		if err != nil {
+			return err
+		}
+		return arg.SetString(str)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return arg.SetUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return arg.SetFloat(v.Float())
+	}
+
+	return fmt.Errorf(errorServiceBindingUnsupportedType, v.Type())
+}
+
+// HandleAction decodes the in-arguments, calls the bound method and encodes the results into the out-arguments.
+func (binding *serviceBinding) HandleAction(req *ActionRequest) Error {
+	action := req.Action
+
+	sm, ok := binding.methods[action.Name]
+	if !ok {
+		return NewErrorFromCode(ErrorOptionalActionNotImplemented)
+	}
+
+	methodType := sm.method.Type()
+	params := make([]reflect.Value, len(sm.inArgs)+1)
+	params[0] = reflect.ValueOf(req.Context())
+	for n, name := range sm.inArgs {
+		arg, err := action.GetArgumentByName(name)
+		if err != nil {
+			return NewErrorFromCode(ErrorInvalidArgs)
+		}
+		v, err := decodeArgumentValue(methodType.In(n+1), arg)
+		if err != nil {
+			return NewErrorFromCode(ErrorArgumentValueInvalid)
+		}
+		params[n+1] = v
+	}
+
+	results := sm.method.Call(params)
+
+	if err, ok := results[len(results)-1].Interface().(error); ok && err != nil {
+		log.Tracef("action %s failed : %s", action.Name, err.Error())
+		return NewErrorFromError(err)
+	}
+
+	outValues := results[:len(results)-1]
+	if sm.outFields != nil {
+		outStruct := results[0]
+		if outStruct.Kind() == reflect.Pointer {
+			if outStruct.IsNil() {
+				return NewErrorFromCode(ErrorActionFailed)
+			}
+			outStruct = outStruct.Elem()
+		}
+		outValues = make([]reflect.Value, len(sm.outFields))
+		for n, index := range sm.outFields {
+			field, err := outStruct.FieldByIndexErr(index)
+			if err != nil {
+				return NewErrorFromCode(ErrorActionFailed)
+			}
+			outValues[n] = field
+		}
+	}
+
+	for n, name := range sm.outArgs {
+		arg, err := action.GetArgumentByName(name)
+		if err != nil {
+			return NewErrorFromCode(ErrorActionFailed)
+		}
+		if err := encodeArgumentValue(outValues[n], arg); err != nil {
+			log.Warnf("action %s failed : %s", action.Name, err.Error())
+			return NewErrorFromCode(ErrorActionFailed)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	errorServiceBindingAccepted     = "binding of %T is accepted : expected error"
+	errorServiceBindingInvalidValue = "action (%s) value = %v : expected %v"
+	errorServiceBindingInvalidCode  = "action (%s) error code = %d : expected %d"
+	errorServiceBindingNotNamed     = "error (%s) doesn't name the unbound action (%s)"
+)
+
+type testSwitchPower struct {
+	target bool
+}
+
+type testSwitchPowerStatus struct {
+	Status bool `upnp:"ResultStatus"`
+}
+
+func (sp *testSwitchPower) SetTarget(ctx context.Context, newTargetValue bool) error {
+	sp.target = newTargetValue
+	return nil
+}
+
+func (sp *testSwitchPower) GetTarget(ctx context.Context) (bool, error) {
+	return sp.target, nil
+}
+
+func (sp *testSwitchPower) GetStatus(ctx context.Context) (*testSwitchPowerStatus, error) {
+	if !sp.target {
+		return nil, NewErrorFromCode(ErrorHumanInterventionRequired)
+	}
+	return &testSwitchPowerStatus{Status: sp.target}, nil
+}
+
+type testBadSwitchPower struct{}
+
+func (sp *testBadSwitchPower) SetTarget(ctx context.Context, newTargetValue int) error {
+	return nil
+}
+
+type testNoContextSwitchPower struct{}
+
+func (sp *testNoContextSwitchPower) GetTarget() (bool, error) {
+	return false, nil
+}
+
+type testPartialSwitchPower struct{}
+
+func (sp *testPartialSwitchPower) GetTarget(ctx context.Context) (bool, error) {
+	return false, nil
+}
+
+type testEmbeddedSwitchPower struct {
+	*testSwitchPower
+}
+
+type testFailedSwitchPower struct{}
+
+func (sp *testFailedSwitchPower) SetTarget(ctx context.Context, newTargetValue bool) error {
+	return NewErrorFromCode(ErrorOptionalActionNotImplemented)
+}
+
+func (sp *testFailedSwitchPower) GetTarget(ctx context.Context) (string, error) {
+	return "", errors.New("failed")
+}
+
+func (sp *testFailedSwitchPower) GetStatus(ctx context.Context) (bool, error) {
+	return false, nil
+}
+
+func TestServiceBinding(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.reviseParentObject()
+
+	service, _ := dev.GetSwitchPowerService()
+
+	// bad signatures
+
+	for _, v := range []any{&testBadSwitchPower{}, &testNoContextSwitchPower{}, &struct{}{}} {
+		if err := dev.BindService(service.ServiceType, v); err == nil {
+			t.Errorf(errorServiceBindingAccepted, v)
+		}
+	}
+
+	// promoted methods
+
+	err = dev.BindService(service.ServiceType, &testEmbeddedSwitchPower{testSwitchPower: &testSwitchPower{}})
+	if err == nil {
+		t.Errorf(errorServiceBindingAccepted, &testEmbeddedSwitchPower{})
+	} else if !strings.Contains(err.Error(), SetTarget) {
+		t.Errorf(errorServiceBindingNotNamed, err.Error(), SetTarget)
+	}
+
+	// binding
+
+	sp := &testSwitchPower{}
+	err = dev.BindService(service.ServiceType, sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handle := func(name string) (*Action, int) {
+		action, _ := service.GetActionByName(name)
+		code := 0
+		if upnpErr := dev.getActionHandler(action).HandleAction(newActionRequest(nil, action)); upnpErr != nil {
+			code = upnpErr.GetCode()
+		}
+		return action, code
+	}
+
+	setAction, _ := service.GetActionByName(SetTarget)
+	setAction.SetArgumentString(NewTargetValue, "yes")
+	if _, code := handle(SetTarget); code != 0 || !sp.target {
+		t.Errorf(errorServiceBindingInvalidValue, SetTarget, sp.target, true)
+	}
+
+	getAction, code := handle(GetTarget)
+	if v, _ := getAction.GetArgumentString(RetTargetValue); code != 0 || v != "1" {
+		t.Errorf(errorServiceBindingInvalidValue, GetTarget, v, "1")
+	}
+
+	statusAction, code := handle(GetStatus)
+	if v, _ := statusAction.GetArgumentString("ResultStatus"); code != 0 || v != "1" {
+		t.Errorf(errorServiceBindingInvalidValue, GetStatus, v, "1")
+	}
+
+	// errors
+
+	setAction.SetArgumentString(NewTargetValue, "unknown")
+	if _, code := handle(SetTarget); code != ErrorArgumentValueInvalid {
+		t.Errorf(errorServiceBindingInvalidCode, SetTarget, code, ErrorArgumentValueInvalid)
+	}
+
+	sp.target = false
+	if _, code := handle(GetStatus); code != ErrorHumanInterventionRequired {
+		t.Errorf(errorServiceBindingInvalidCode, GetStatus, code, ErrorHumanInterventionRequired)
+	}
+
+	err = dev.BindService(service.ServiceID, &testFailedSwitchPower{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, code := handle(GetTarget); code != ErrorActionFailed {
+		t.Errorf(errorServiceBindingInvalidCode, GetTarget, code, ErrorActionFailed)
+	}
+	setAction.SetArgumentString(NewTargetValue, "1")
+	if _, code := handle(SetTarget); code != ErrorOptionalActionNotImplemented {
+		t.Errorf(errorServiceBindingInvalidCode, SetTarget, code, ErrorOptionalActionNotImplemented)
+	}
+
+	// unbound actions
+
+	err = dev.BindService(service.ServiceType, &testPartialSwitchPower{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, code := handle(GetTarget); code != 0 {
+		t.Errorf(errorServiceBindingInvalidCode, GetTarget, code, 0)
+	}
+	for _, name := range []string{SetTarget, GetStatus} {
+		if _, code := handle(name); code != ErrorOptionalActionNotImplemented {
+			t.Errorf(errorServiceBindingInvalidCode, name, code, ErrorOptionalActionNotImplemented)
+		}
+	}
+}
@@ -18,8 +18,24 @@ const (
 	IPv6SiteLocalAddress      = "FF05::C"
 	IPv6GlobalAddress         = "FF0E::C"
 
+	EventPort                 = 7900
+	EventAddress              = "239.255.255.246"
+	EventMulticastAddress     = "239.255.255.246:7900"
+	IPv6EventLinkLocalAddress = "FF02::130"
+	IPv6EventSiteLocalAddress = "FF05::130"
+	EventContentType          = "text/xml; charset=\"utf-8\""
+	EventNT                   = "upnp:event"
+	EventLevelEmergency       = "upnp:/emergency"
+	EventLevelFault           = "upnp:/fault"
+	EventLevelWarning         = "upnp:/warning"
+	EventLevelInfo            = "upnp:/info"
+	EventLevelDebug           = "upnp:/debug"
+	EventLevelGeneral         = "upnp:/general"
+
 	DefaultMSearchMX     = 3
+	MaxMSearchMX         = 5
 	DefaultAnnounceCount = 3
+	DefaultMaxAge        = 1800
 
 	MaxPacketSize     = 8192
 	HeaderLineMaxSize = 128
@@ -31,25 +47,32 @@ const (
 	Notify      = "NOTIFY"
 	MSearch     = "M-SEARCH"
 
-	Host          = "HOST"
-	Date          = "DATE"
-	UserAgent     = "USER-AGENT"
-	Location      = "LOCATION"
-	Server        = "SERVER"
-	ST            = "ST"
-	MX            = "MX"
-	MAN           = "MAN"
-	NT            = "NT"
-	NTS           = "NTS"
-	NTSPropChange = "upnp:propchange"
-	USN           = "USN"
-	EXT           = "EXT"
-	SID           = "SID"
-	SEQ           = "SEQ"
-	Callback      = "CALLBACK"
-	CacheControl  = "CACHE-CONTROL"
-	Timeout       = "TIMEOUT"
-	BootIDUPnPOrg = "BOOTID.UPNP.ORG"
+	Host              = "HOST"
+	Date              = "DATE"
+	UserAgent         = "USER-AGENT"
+	Location          = "LOCATION"
+	Server            = "SERVER"
+	ST                = "ST"
+	MX                = "MX"
+	MAN               = "MAN"
+	NT                = "NT"
+	NTS               = "NTS"
+	NTSPropChange     = "upnp:propchange"
+	USN               = "USN"
+	EXT               = "EXT"
+	SID               = "SID"
+	SEQ               = "SEQ"
+	Callback          = "CALLBACK"
+	CacheControl      = "CACHE-CONTROL"
+	Timeout           = "TIMEOUT"
+	BootIDUPnPOrg     = "BOOTID.UPNP.ORG"
+	ConfigIDUPnPOrg   = "CONFIGID.UPNP.ORG"
+	NextBootIDUPnPOrg = "NEXTBOOTID.UPNP.ORG"
+	SearchPortUPnPOrg = "SEARCHPORT.UPNP.ORG"
+	SVCID             = "SVCID"
+	LVL               = "LVL"
+	ContentType       = "CONTENT-TYPE"
+	ContentLength     = "CONTENT-LENGTH"
 
 	RootDevice = "upnp:rootdevice"
 	All        = "ssdp:all"
@@ -58,4 +81,5 @@ const (
 	NTSByeBye  = "ssdp:byebye"
 	NTSUpdate  = "ssdp:update"
 	MaxAge     = "max-age"
+	USNDelim   = "::"
 )
@@ -5,7 +5,19 @@
 package ssdp
 
 const (
-	errorZeroPacket              = "packet length is zero"
-	errorPacketFirstLineNotFound = "first line is not found\n%s"
-	errorPacketHeadersNotFound   = "headers is not found (%d:%d)\n%s"
+	errorZeroPacket                 = "packet length is zero"
+	errorPacketFirstLineNotFound    = "first line is not found\n%s"
+	errorPacketLineNotTerminated    = "line is not terminated\n%s"
+	errorPacketBadLineEnding        = "line ending is not CRLF\n%s"
+	errorPacketBadFirstLine         = "first line is invalid\n%s"
+	errorPacketBadHeaderLine        = "header line is invalid\n%s"
+	errorPacketFoldedHeader         = "folded header line is not allowed\n%s"
+	errorPacketHeadersNotTerminated = "headers are not terminated by an empty line"
+	errorPacketBadContentLength     = "content length doesn't match the body length (%d)"
+)
+
+const (
+	errorServerStartFailed        = "server (%s) couldn't be started on %s : %w"
+	errorServerNotStarted         = "no server is started"
+	errorServerNoAvailableAddress = "no server is available to send to %s"
 )
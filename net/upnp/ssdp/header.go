@@ -0,0 +1,107 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssdp
+
+import (
+	"strings"
+)
+
+// A Header represents a header line of SSDP packets.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Headers represents the header lines of SSDP packets in the received or added order.
+// The header names are case-insensitive, and a header name may have multiple values.
+type Headers []Header
+
+// Len returns the number of the header lines.
+func (headers Headers) Len() int {
+	return len(headers)
+}
+
+// Get returns the first value of the specified header.
+func (headers Headers) Get(name string) (string, bool) {
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value, true
+		}
+	}
+	return "", false
+}
+
+// Values returns all values of the specified header in order.
+func (headers Headers) Values(name string) []string {
+	values := make([]string, 0)
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, name) {
+			values = append(values, header.Value)
+		}
+	}
+	return values
+}
+
+// Has returns true when the specified header is included, otherwise false.
+func (headers Headers) Has(name string) bool {
+	_, ok := headers.Get(name)
+	return ok
+}
+
+// Add appends a value of the specified header.
+func (headers *Headers) Add(name string, value string) {
+	*headers = append(*headers, Header{Name: name, Value: value})
+}
+
+// Set replaces the values of the specified header with the value.
+// The value is set at the position of the first header line, or appended when the header is not included.
+func (headers *Headers) Set(name string, value string) {
+	idx := -1
+	revised := (*headers)[:0]
+	for _, header := range *headers {
+		if !strings.EqualFold(header.Name, name) {
+			revised = append(revised, header)
+			continue
+		}
+		if idx < 0 {
+			idx = len(revised)
+			revised = append(revised, Header{Name: name, Value: value})
+		}
+	}
+	if idx < 0 {
+		revised = append(revised, Header{Name: name, Value: value})
+	}
+	*headers = revised
+}
+
+// Del removes all values of the specified header.
+func (headers *Headers) Del(name string) {
+	revised := (*headers)[:0]
+	for _, header := range *headers {
+		if strings.EqualFold(header.Name, name) {
+			continue
+		}
+		revised = append(revised, header)
+	}
+	*headers = revised
+}
+
+// isHeaderToken returns true when the specified name is a token of RFC 7230.
+func isHeaderToken(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for n := range len(name) {
+		c := name[n]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+			continue
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) != -1:
+			continue
+		}
+		return false
+	}
+	return true
+}
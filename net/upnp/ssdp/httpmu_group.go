@@ -0,0 +1,42 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix || windows
+
+package ssdp
+
+import (
+	"net"
+	"syscall"
+)
+
+// joinGroup joins the specified multicast group on the interface with the opened socket.
+// The address of the interface is used to join the IPv4 groups.
+func joinGroup(conn *net.UDPConn, ifi net.Interface, ifAddr net.IP, group net.IP) error {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var joinErr error
+	err = rawConn.Control(func(fd uintptr) {
+		if group4 := group.To4(); group4 != nil {
+			mreq := &syscall.IPMreq{}
+			copy(mreq.Multiaddr[:], group4)
+			if ifAddr4 := ifAddr.To4(); ifAddr4 != nil {
+				copy(mreq.Interface[:], ifAddr4)
+			}
+			joinErr = setsockoptIPMreq(fd, mreq)
+			return
+		}
+		mreq := &syscall.IPv6Mreq{Interface: uint32(ifi.Index)}
+		copy(mreq.Multiaddr[:], group.To16())
+		joinErr = setsockoptIPv6Mreq(fd, mreq)
+	})
+	if err != nil {
+		return err
+	}
+
+	return joinErr
+}
@@ -0,0 +1,17 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix && !windows
+
+package ssdp
+
+import (
+	"errors"
+	"net"
+)
+
+// joinGroup returns an error because the additional groups can't be joined on the platform.
+func joinGroup(conn *net.UDPConn, ifi net.Interface, ifAddr net.IP, group net.IP) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,19 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package ssdp
+
+import (
+	"syscall"
+)
+
+func setsockoptIPMreq(fd uintptr, mreq *syscall.IPMreq) error {
+	return syscall.SetsockoptIPMreq(syscall.Handle(fd), syscall.IPPROTO_IP, syscall.IP_ADD_MEMBERSHIP, mreq)
+}
+
+func setsockoptIPv6Mreq(fd uintptr, mreq *syscall.IPv6Mreq) error {
+	return syscall.SetsockoptIPv6Mreq(syscall.Handle(fd), syscall.IPPROTO_IPV6, syscall.IPV6_JOIN_GROUP, mreq)
+}
@@ -8,11 +8,20 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
 )
 
 // A HTTPMUSocket represents a socket for HTTPMU.
 type HTTPMUSocket struct {
 	*UDPSocket
+	// Group is the multicast group to send.
+	Group string
+	// Groups is the joined multicast groups to receive.
+	Groups []string
+	Port   int
 }
 
 // NewHTTPMUSocket returns a new HTTPMUSocket.
@@ -22,40 +31,93 @@ func NewHTTPMUSocket() *HTTPMUSocket {
 	return ssdpSock
 }
 
-// Bind binds to SSDP multicast address.
+// Bind binds to SSDP multicast address, and sends from the first address of the specified interface.
 func (socket *HTTPMUSocket) Bind(ifi net.Interface) error {
+	addr, _ := util.GetInterfaceAddress(ifi)
+	return socket.BindAddress(ifi, addr)
+}
+
+// BindAddress binds to the default SSDP multicast group of the specified address, and sends from the address of the interface.
+func (socket *HTTPMUSocket) BindAddress(ifi net.Interface, addr string) error {
+	return socket.BindGroup(ifi, addr, GetMulticastGroupAddresses(addr)[0])
+}
+
+// BindGroup binds to the specified SSDP multicast group, and sends from the specified address of the interface.
+func (socket *HTTPMUSocket) BindGroup(ifi net.Interface, addr string, group string) error {
+	return socket.BindGroupPort(ifi, addr, group, Port)
+}
+
+// BindGroupPort binds to the specified multicast group and port, and sends from the specified address of the interface.
+func (socket *HTTPMUSocket) BindGroupPort(ifi net.Interface, addr string, group string, port int) error {
+	return socket.BindGroupsPort(ifi, addr, []string{group}, port)
+}
+
+// BindGroupsPort binds to the specified multicast groups and port, and sends from the specified address of the interface.
+// All groups are joined on one socket because the sockets of the same port receive the packets of all joined groups.
+// The first group is used to send, and the other groups which couldn't be joined are skipped.
+func (socket *HTTPMUSocket) BindGroupsPort(ifi net.Interface, addr string, groups []string, port int) error {
 	err := socket.Close()
 	if err != nil {
 		return err
 	}
 
-	mcastAddr, err := net.ResolveUDPAddr("udp", MulticastAddress)
-	if err != nil {
-		return err
+	if len(groups) == 0 {
+		return fmt.Errorf(errorSocketBadGroupAddress, "")
+	}
+
+	mcastAddr := newUDPAddr(groups[0], port, ifi)
+	if mcastAddr.IP == nil {
+		return fmt.Errorf(errorSocketBadGroupAddress, groups[0])
 	}
 
-	socket.Conn, err = net.ListenMulticastUDP("udp", &ifi, mcastAddr)
+	conn, err := net.ListenMulticastUDP("udp", &ifi, mcastAddr)
 	if err != nil {
 		return fmt.Errorf("%w (%s)", err, ifi.Name)
 	}
 
-	socket.Interface = ifi
+	joinedGroups := []string{groups[0]}
+	for _, group := range groups[1:] {
+		groupAddr := newUDPAddr(group, port, ifi)
+		if groupAddr.IP == nil {
+			log.Warnf("%s", fmt.Errorf(errorSocketBadGroupAddress, group).Error())
+			continue
+		}
+		err := joinGroup(conn, ifi, net.ParseIP(addr), groupAddr.IP)
+		if err != nil {
+			log.Warnf("%s", fmt.Errorf(errorSocketGroupNotJoined, group, ifi.Name, err).Error())
+			continue
+		}
+		joinedGroups = append(joinedGroups, group)
+	}
+
+	socket.setConn(conn, ifi, addr)
+	socket.Group = groups[0]
+	socket.Groups = joinedGroups
+	socket.Port = port
 
 	return nil
 }
 
-// Write sends the specified bytes.
+// GetHost returns a HOST header value of the bound multicast group and port.
+func (socket *HTTPMUSocket) GetHost() string {
+	return net.JoinHostPort(socket.Group, strconv.Itoa(socket.Port))
+}
+
+// Write sends the specified bytes from the bound interface.
 func (socket *HTTPMUSocket) Write(b []byte) (int, error) {
-	if socket.Conn == nil {
+	conn, ifi, addr := socket.getConn()
+	if conn == nil {
 		return 0, errors.New(errorSocketIsClosed)
 	}
 
-	ssdpAddr, err := net.ResolveUDPAddr("udp", MulticastAddress)
-	if err != nil {
-		return 0, err
+	ssdpAddr := newUDPAddr(socket.Group, socket.Port, ifi)
+
+	var ifAddr *net.UDPAddr
+	if 0 < len(addr) {
+		ifAddr = newUDPAddr(addr, 0, ifi)
 	}
 
-	conn, err := net.DialUDP("udp", nil, ssdpAddr)
+	conn, err := net.DialUDP("udp", ifAddr, ssdpAddr)
 	if err != nil {
 		return 0, err
 	}
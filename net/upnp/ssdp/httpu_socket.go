@@ -7,6 +7,7 @@ package ssdp
 import (
 	"fmt"
 	"net"
+	"strconv"
 
 	"github.com/cybergarage/go-net-upnp/net/upnp/util"
 )
@@ -23,48 +24,58 @@ func NewHTTPUSocket() *HTTPUSocket {
 	return ssdpSock
 }
 
-// Bind binds to SSDP multicast address.
+// Bind binds to the first address of the specified interface.
 func (socket *HTTPUSocket) Bind(ifi net.Interface, port int) error {
-	err := socket.Close()
+	addr, err := util.GetInterfaceAddress(ifi)
 	if err != nil {
 		return err
 	}
+	return socket.BindAddress(ifi, addr, port)
+}
 
-	addr, err := util.GetInterfaceAddress(ifi)
+// BindAddress binds to the specified address of the interface.
+func (socket *HTTPUSocket) BindAddress(ifi net.Interface, addr string, port int) error {
+	err := socket.Close()
 	if err != nil {
 		return err
 	}
 
-	bindAddr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", addr, port))
-	if err != nil {
-		return err
+	bindAddr := newUDPAddr(addr, port, ifi)
+	if bindAddr.IP == nil {
+		return fmt.Errorf(errorSocketBadAddress, addr)
 	}
 
-	socket.Conn, err = net.ListenUDP("udp", bindAddr)
+	conn, err := net.ListenUDP("udp", bindAddr)
 	if err != nil {
 		return err
 	}
 
-	socket.Interface = ifi
+	socket.setConn(conn, ifi, addr)
 
 	return nil
 }
 
 // Write sends the specified bytes.
 func (socket *HTTPUSocket) Write(addr string, port int, b []byte) (int, error) {
-	toAddr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", addr, port))
+	toAddr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(addr, strconv.Itoa(port)))
 	if err != nil {
 		return 0, err
 	}
 
-	if socket.Conn != nil {
-		return socket.Conn.WriteToUDP(b, toAddr)
+	return socket.writeToUDPAddr(toAddr, b)
+}
+
+// writeToUDPAddr sends the specified bytes to the specified address.
+func (socket *HTTPUSocket) writeToUDPAddr(toAddr *net.UDPAddr, b []byte) (int, error) {
+	if conn, _, _ := socket.getConn(); conn != nil {
+		return conn.WriteToUDP(b, toAddr)
 	}
 
 	conn, err := net.DialUDP("udp", nil, toAddr)
 	if err != nil {
 		return 0, err
 	}
+	defer conn.Close()
 
 	return conn.Write(b)
 }
@@ -0,0 +1,49 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssdp
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
+)
+
+// GetMulticastGroupAddresses returns the SSDP multicast group addresses for the specified interface address.
+// The first address is the default group to send.
+func GetMulticastGroupAddresses(ifAddr string) []string {
+	if util.IsIPv6Address(ifAddr) {
+		return []string{IPv6LinkLocalAddress, IPv6SiteLocalAddress}
+	}
+	return []string{ADDRESS}
+}
+
+// GetEventMulticastGroupAddresses returns the multicast eventing group addresses for the specified interface address.
+// The first address is the default group to send.
+func GetEventMulticastGroupAddresses(ifAddr string) []string {
+	if util.IsIPv6Address(ifAddr) {
+		return []string{IPv6EventLinkLocalAddress, IPv6EventSiteLocalAddress}
+	}
+	return []string{EventAddress}
+}
+
+// GetMulticastHost returns a HOST header value of the specified multicast group address.
+func GetMulticastHost(group string) string {
+	return net.JoinHostPort(group, strconv.Itoa(Port))
+}
+
+// newUDPAddr returns a UDP address of the specified address and port. The zone is set to the IPv6 multicast and link-local addresses.
+func newUDPAddr(addr string, port int, ifi net.Interface) *net.UDPAddr {
+	udpAddr := &net.UDPAddr{
+		IP:   net.ParseIP(addr),
+		Port: port,
+	}
+	if udpAddr.IP != nil && udpAddr.IP.To4() == nil {
+		if udpAddr.IP.IsMulticast() || udpAddr.IP.IsLinkLocalUnicast() {
+			udpAddr.Zone = ifi.Name
+		}
+	}
+	return udpAddr
+}
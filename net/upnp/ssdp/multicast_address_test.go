@@ -0,0 +1,49 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssdp
+
+import (
+	"testing"
+)
+
+const (
+	errorInvalidMulticastGroups = "invalid multicast groups of %s : %v"
+	errorInvalidMulticastHost   = "invalid multicast host '%s' : expected '%s'"
+)
+
+func TestMulticastGroupAddresses(t *testing.T) {
+	groups := GetMulticastGroupAddresses("192.168.0.1")
+	if len(groups) != 1 || groups[0] != ADDRESS {
+		t.Errorf(errorInvalidMulticastGroups, "192.168.0.1", groups)
+	}
+
+	groups = GetMulticastGroupAddresses("fe80::1")
+	if len(groups) != 2 || groups[0] != IPv6LinkLocalAddress || groups[1] != IPv6SiteLocalAddress {
+		t.Errorf(errorInvalidMulticastGroups, "fe80::1", groups)
+	}
+
+	hosts := map[string]string{
+		ADDRESS:              MulticastAddress,
+		IPv6LinkLocalAddress: "[FF02::C]:1900",
+	}
+	for group, expected := range hosts {
+		host := GetMulticastHost(group)
+		if host != expected {
+			t.Errorf(errorInvalidMulticastHost, host, expected)
+		}
+	}
+}
+
+func TestEventMulticastGroupAddresses(t *testing.T) {
+	groups := GetEventMulticastGroupAddresses("192.168.0.1")
+	if len(groups) != 1 || groups[0] != EventAddress {
+		t.Errorf(errorInvalidMulticastGroups, "192.168.0.1", groups)
+	}
+
+	groups = GetEventMulticastGroupAddresses("fe80::1")
+	if len(groups) != 2 || groups[0] != IPv6EventLinkLocalAddress || groups[1] != IPv6EventSiteLocalAddress {
+		t.Errorf(errorInvalidMulticastGroups, "fe80::1", groups)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"net"
 
 	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
 )
 
 // A MulticastListener represents a listener for MulticastServer.
@@ -22,6 +23,7 @@ type MulticastServer struct {
 	Socket    *HTTPMUSocket
 	Listener  MulticastListener
 	Interface net.Interface
+	Address   string
 }
 
 // NewMulticastServer returns a new MulticastServer.
@@ -34,11 +36,33 @@ func NewMulticastServer() *MulticastServer {
 
 // Start starts this server.
 func (server *MulticastServer) Start(ifi net.Interface) error {
-	err := server.Socket.Bind(ifi)
+	addr, _ := util.GetInterfaceAddress(ifi)
+	return server.StartWithAddress(ifi, addr)
+}
+
+// StartWithAddress starts this server on the specified interface, and sends from the specified address.
+func (server *MulticastServer) StartWithAddress(ifi net.Interface, addr string) error {
+	return server.StartWithGroup(ifi, addr, GetMulticastGroupAddresses(addr)[0])
+}
+
+// StartWithGroup starts this server for the specified multicast group on the interface, and sends from the specified address.
+func (server *MulticastServer) StartWithGroup(ifi net.Interface, addr string, group string) error {
+	return server.StartWithGroupPort(ifi, addr, group, Port)
+}
+
+// StartWithGroupPort starts this server for the specified multicast group and port on the interface, and sends from the specified address.
+func (server *MulticastServer) StartWithGroupPort(ifi net.Interface, addr string, group string, port int) error {
+	return server.StartWithGroupsPort(ifi, addr, []string{group}, port)
+}
+
+// StartWithGroupsPort starts this server for the specified multicast groups and port on the interface, and sends to the first group from the specified address.
+func (server *MulticastServer) StartWithGroupsPort(ifi net.Interface, addr string, groups []string, port int) error {
+	err := server.Socket.BindGroupsPort(ifi, addr, groups, port)
 	if err != nil {
 		return err
 	}
 	server.Interface = ifi
+	server.Address = addr
 	go handleMulticastConnection(server)
 	return nil
 }
@@ -52,6 +76,13 @@ func (server *MulticastServer) Stop() error {
 	return nil
 }
 
+// Write sends the specified request to the multicast group from the bound interface.
+// The HOST header of the request is replaced with the multicast group.
+func (server *MulticastServer) Write(req *Request) (int, error) {
+	req.SetHost(server.Socket.GetHost())
+	return server.Socket.Write(req.Bytes())
+}
+
 func handleMulticastConnection(server *MulticastServer) {
 	for {
 		ssdpPkt, err := server.Socket.Read()
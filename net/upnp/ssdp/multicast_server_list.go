@@ -5,6 +5,11 @@
 package ssdp
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cybergarage/go-logger/log"
 	"github.com/cybergarage/go-net-upnp/net/upnp/util"
 )
 
@@ -12,13 +17,29 @@ import (
 type MulticastServerList struct {
 	Listener MulticastListener
 	Servers  []*MulticastServer
+	Selector *util.InterfaceSelector
+	// Port is the port of the multicast groups.
+	Port int
+	// GroupAddresses returns the multicast groups to join for each interface address.
+	GroupAddresses func(ifAddr string) []string
 }
 
-// NewMulticastServerList returns a new MulticastServerList.
+// NewMulticastServerList returns a new MulticastServerList for the SSDP multicast groups.
 func NewMulticastServerList() *MulticastServerList {
 	server := &MulticastServerList{}
 	server.Servers = make([]*MulticastServer, 0)
 	server.Listener = nil
+	server.Selector = util.NewInterfaceSelector()
+	server.Port = Port
+	server.GroupAddresses = GetMulticastGroupAddresses
+	return server
+}
+
+// NewEventMulticastServerList returns a new MulticastServerList for the multicast eventing groups.
+func NewEventMulticastServerList() *MulticastServerList {
+	server := NewMulticastServerList()
+	server.Port = EventPort
+	server.GroupAddresses = GetEventMulticastGroupAddresses
 	return server
 }
 
@@ -29,25 +50,46 @@ func (servers *MulticastServerList) Start() error {
 		return err
 	}
 
-	ifis, err := util.GetAvailableInterfaces()
+	ifis, err := servers.Selector.GetAvailableInterfaces()
 	if err != nil {
 		return err
 	}
 
 	var lastErr error
 
-	servers.Servers = make([]*MulticastServer, len(ifis))
-	for n, ifi := range ifis {
-		server := NewMulticastServer()
-		server.Listener = servers.Listener
-		err := server.Start(ifi)
+	// All groups of an interface address are joined on one server because the servers of the same port receive
+	// the packets of all joined groups. The groups which couldn't be joined, such as the site-local IPv6 group
+	// on an interface without the site scope, are skipped. An error is returned only when no server is started.
+
+	servers.Servers = make([]*MulticastServer, 0, len(ifis))
+	for _, ifi := range ifis {
+		addrs, err := servers.Selector.GetInterfaceAddresses(ifi)
 		if err != nil {
 			lastErr = err
+			continue
+		}
+		for _, addr := range addrs {
+			groups := servers.GroupAddresses(addr)
+			server := NewMulticastServer()
+			server.Listener = servers.Listener
+			err := server.StartWithGroupsPort(ifi, addr, groups, servers.Port)
+			if err != nil {
+				log.Warnf("%s", fmt.Errorf(errorServerStartFailed, strings.Join(groups, ", "), addr, err).Error())
+				lastErr = err
+				continue
+			}
+			servers.Servers = append(servers.Servers, server)
 		}
-		servers.Servers[n] = server
 	}
 
-	return lastErr
+	if len(servers.Servers) == 0 {
+		if lastErr == nil {
+			lastErr = errors.New(errorServerNotStarted)
+		}
+		return lastErr
+	}
+
+	return nil
 }
 
 // Stop stops this server.
@@ -5,9 +5,106 @@
 package ssdp
 
 import (
+	"fmt"
+	"net"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
+)
+
+const (
+	errorTestMulticastServerListInvalidCount = "M-SEARCH (%s) is received %d times on %s : expected once"
 )
 
 func TestNewMulticastServerList(t *testing.T) {
 	NewMulticastServerList()
 }
+
+type testSearchCounter struct {
+	sync.Mutex
+	counts map[string]int
+}
+
+func (counter *testSearchCounter) DeviceNotifyReceived(ssdpReq *Request) {
+}
+
+func (counter *testSearchCounter) DeviceSearchReceived(ssdpReq *Request) {
+	st, _ := ssdpReq.GetST()
+	counter.Lock()
+	counter.counts[st+"/"+ssdpReq.Interface.Name]++
+	counter.Unlock()
+}
+
+func (counter *testSearchCounter) getCount(st string, ifname string) int {
+	counter.Lock()
+	defer counter.Unlock()
+	return counter.counts[st+"/"+ifname]
+}
+
+func TestMulticastServerListIPv6Groups(t *testing.T) {
+	counter := &testSearchCounter{counts: map[string]int{}}
+
+	servers := NewMulticastServerList()
+	servers.Listener = counter
+	err := servers.Start()
+	if err != nil {
+		t.Skip(err)
+	}
+	defer servers.Stop()
+
+	// Each M-SEARCH to any group of the interface is received only once by the server list.
+
+	type testSearch struct {
+		st  string
+		ifi net.Interface
+	}
+
+	searches := make([]testSearch, 0)
+	for i, server := range servers.Servers {
+		if !util.IsIPv6Address(server.Address) {
+			continue
+		}
+		for n, group := range GetMulticastGroupAddresses(server.Address) {
+			st := fmt.Sprintf("urn:go-net-upnp:test:%s:%d:%d", server.Interface.Name, i, n)
+			req, err := NewSearchRequest(st, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn, err := net.DialUDP("udp", newUDPAddr(server.Address, 0, server.Interface), newUDPAddr(group, Port, server.Interface))
+			if err != nil {
+				continue
+			}
+			req.SetHost(GetMulticastHost(group))
+			_, err = conn.Write(req.Bytes())
+			conn.Close()
+			if err != nil {
+				continue
+			}
+			searches = append(searches, testSearch{st: st, ifi: server.Interface})
+		}
+	}
+
+	if len(searches) == 0 {
+		t.Skip("no IPv6 multicast groups are available")
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	received := false
+	for _, search := range searches {
+		cnt := counter.getCount(search.st, search.ifi.Name)
+		if cnt == 0 {
+			continue
+		}
+		received = true
+		if cnt != 1 {
+			t.Errorf(errorTestMulticastServerListInvalidCount, search.st, cnt, search.ifi.Name)
+		}
+	}
+
+	if !received {
+		t.Skip("no M-SEARCH is received")
+	}
+}
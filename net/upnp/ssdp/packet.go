@@ -19,10 +19,22 @@ const (
 	errorPacketHeaderNotFound = "header (%s) is not found"
 )
 
+// A ParseMode represents how strictly SSDP packets are parsed.
+type ParseMode int
+
+const (
+	// ParseLenient accepts the packets of the various stacks in the field, such as LF line endings, no spaces after the colons,
+	// folded header lines and no terminating empty line. Malformed header lines are skipped.
+	ParseLenient ParseMode = iota
+	// ParseStrict accepts only the well-formed HTTP/1.x messages of RFC 7230 with CRLF line endings.
+	ParseStrict
+)
+
 // A Packet represents a ssdpPkt of SSDP.
 type Packet struct {
 	FirstLines []string
-	Headers    map[string]string
+	Headers    Headers
+	Body       []byte
 	From       net.UDPAddr
 	Interface  net.Interface
 }
@@ -31,66 +43,200 @@ type Packet struct {
 func NewPacket() *Packet {
 	ssdpPkt := &Packet{}
 	ssdpPkt.FirstLines = make([]string, 0)
-	ssdpPkt.Headers = make(map[string]string)
+	ssdpPkt.Headers = make(Headers, 0)
 	return ssdpPkt
 }
 
-// NewPacketFromBytes parses a Packet from raw SSDP bytes.
+// NewPacketFromBytes parses a Packet from raw SSDP bytes leniently.
 func NewPacketFromBytes(bytes []byte) (*Packet, error) {
+	return NewPacketFromBytesWithMode(bytes, ParseLenient)
+}
+
+// NewPacketFromBytesWithMode parses a Packet from raw SSDP bytes with the specified mode.
+func NewPacketFromBytesWithMode(bytes []byte, mode ParseMode) (*Packet, error) {
 	ssdpPkt := NewPacket()
-	err := ssdpPkt.parse(bytes)
+	err := ssdpPkt.parse(bytes, mode)
 	if err != nil {
 		return nil, err
 	}
 	return ssdpPkt, nil
 }
 
-func (pkt *Packet) parse(pktBytes []byte) error {
+// readPacketLine returns the first line without the line ending and the remaining bytes.
+// The line ending must be CRLF in the strict mode, and LF or CRLF in the lenient mode which accepts the last line without the line ending.
+func readPacketLine(pktBytes []byte, mode ParseMode) (string, []byte, error) {
+	lineEndIdx := bytes.IndexByte(pktBytes, '\n')
+	if lineEndIdx == -1 {
+		if mode == ParseStrict {
+			return "", nil, fmt.Errorf(errorPacketLineNotTerminated, string(pktBytes))
+		}
+		return strings.TrimSuffix(string(pktBytes), "\r"), nil, nil
+	}
+
+	line := string(pktBytes[:lineEndIdx])
+	if mode == ParseStrict {
+		if !strings.HasSuffix(line, "\r") || strings.Count(line, "\r") != 1 {
+			return "", nil, fmt.Errorf(errorPacketBadLineEnding, line)
+		}
+	}
+
+	return strings.TrimSuffix(line, "\r"), pktBytes[lineEndIdx+1:], nil
+}
+
+// isHTTPVersion returns true when the specified string is a HTTP version such as "HTTP/1.1".
+func isHTTPVersion(version string) bool {
+	digits, ok := strings.CutPrefix(version, "HTTP/")
+	if !ok || len(digits) != 3 || digits[1] != '.' {
+		return false
+	}
+	return ('0' <= digits[0] && digits[0] <= '9') && ('0' <= digits[2] && digits[2] <= '9')
+}
+
+// parseFirstLine parses the request line or the status line.
+// The lenient mode splits the line by any whitespaces unless the line is well-formed.
+func parseFirstLine(line string, mode ParseMode) ([]string, error) {
+	if mode != ParseStrict {
+		if firstLines, err := parseFirstLine(line, ParseStrict); err == nil {
+			return firstLines, nil
+		}
+		firstLines := strings.Fields(line)
+		if len(firstLines) == 0 {
+			return nil, fmt.Errorf(errorPacketFirstLineNotFound, line)
+		}
+		if 3 < len(firstLines) {
+			firstLines = []string{firstLines[0], firstLines[1], strings.Join(firstLines[2:], SP)}
+		}
+		return firstLines, nil
+	}
+
+	firstLines := strings.SplitN(line, SP, 3)
+	if len(firstLines) != 3 {
+		return nil, fmt.Errorf(errorPacketBadFirstLine, line)
+	}
+
+	if strings.HasPrefix(firstLines[0], "HTTP/") {
+		code := firstLines[1]
+		if !isHTTPVersion(firstLines[0]) || len(code) != 3 || strings.Trim(code, "0123456789") != "" {
+			return nil, fmt.Errorf(errorPacketBadFirstLine, line)
+		}
+		return firstLines, nil
+	}
+
+	if !isHeaderToken(firstLines[0]) || len(firstLines[1]) == 0 || !isHTTPVersion(firstLines[2]) {
+		return nil, fmt.Errorf(errorPacketBadFirstLine, line)
+	}
+
+	return firstLines, nil
+}
+
+func (pkt *Packet) parse(pktBytes []byte, mode ParseMode) error {
 	if len(pktBytes) == 0 {
 		return errors.New(errorZeroPacket)
 	}
 
 	// Read first line
 
-	pktFirstLineSep := []byte(CRLF)
-	pktFirstLineIdx := bytes.Index(pktBytes, pktFirstLineSep)
-	if pktFirstLineIdx == -1 {
-		return fmt.Errorf(errorPacketFirstLineNotFound, string(pktBytes))
+	var line string
+	var err error
+	for {
+		if pktBytes == nil {
+			return fmt.Errorf(errorPacketFirstLineNotFound, line)
+		}
+		line, pktBytes, err = readPacketLine(pktBytes, mode)
+		if err != nil {
+			return err
+		}
+		// Some stacks send empty lines before the first line.
+		if mode == ParseStrict || 0 < len(strings.TrimSpace(line)) {
+			break
+		}
+	}
+
+	pkt.FirstLines, err = parseFirstLine(line, mode)
+	if err != nil {
+		return err
 	}
-	pktFirstLine := string(pktBytes[0:pktFirstLineIdx])
-	pkt.FirstLines = strings.Split(pktFirstLine, SP)
 
 	// Read Headers
 
-	pktBodySep := []byte(CRLF + CRLF)
-	pktBodyIdx := bytes.Index(pktBytes, pktBodySep)
-	if pktBodyIdx == -1 {
-		pktBodyIdx = len(pktBytes) - 1
-	} else {
-		pktBodyIdx += len(pktBodySep)
+	for {
+		if pktBytes == nil {
+			if mode == ParseStrict {
+				return errors.New(errorPacketHeadersNotTerminated)
+			}
+			return nil
+		}
+
+		line, pktBytes, err = readPacketLine(pktBytes, mode)
+		if err != nil {
+			return err
+		}
+
+		if len(line) == 0 {
+			break
+		}
+
+		// Folded header line (obs-fold)
+
+		if line[0] == ' ' || line[0] == '\t' {
+			if mode == ParseStrict {
+				return fmt.Errorf(errorPacketFoldedHeader, line)
+			}
+			if len(pkt.Headers) == 0 {
+				continue
+			}
+			lastHeader := &pkt.Headers[len(pkt.Headers)-1]
+			value := strings.Trim(line, " \t")
+			if len(value) == 0 {
+				continue
+			}
+			if len(lastHeader.Value) == 0 {
+				lastHeader.Value = value
+			} else {
+				lastHeader.Value += SP + value
+			}
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if mode != ParseStrict {
+			name = strings.TrimSpace(name)
+		}
+		if !ok || !isHeaderToken(name) {
+			if mode == ParseStrict {
+				return fmt.Errorf(errorPacketBadHeaderLine, line)
+			}
+			continue
+		}
+
+		pkt.Headers.Add(name, strings.Trim(value, " \t"))
 	}
 
-	pktBeginIdx := pktFirstLineIdx + len(CRLF)
-	pktEndIdx := pktBodyIdx - 1
+	// Read Body
 
-	if (pktBeginIdx < 0) || (pktEndIdx < 0) || (pktEndIdx < pktBeginIdx) || (len(pktBytes)-1) < pktBeginIdx || (len(pktBytes)-1) < pktEndIdx {
-		return fmt.Errorf(errorPacketHeadersNotFound, pktBeginIdx, pktEndIdx, string(pktBytes))
+	if 0 < len(pktBytes) {
+		pkt.Body = bytes.Clone(pktBytes)
 	}
 
-	pktHeaderStrings := string(pktBytes[pktBeginIdx:pktEndIdx])
-	for headerLine := range strings.SplitSeq(pktHeaderStrings, CRLF) {
-		headerStrings := strings.Split(headerLine, ": ")
-		if len(headerStrings) < 2 {
-			continue
+	if mode == ParseStrict && pkt.Headers.Has(ContentLength) {
+		contentLen, err := pkt.GetHeaderInt(ContentLength)
+		if err != nil || contentLen != len(pkt.Body) {
+			return fmt.Errorf(errorPacketBadContentLength, len(pkt.Body))
 		}
-		key := strings.ToUpper(headerStrings[0])
-		value := headerStrings[1]
-		pkt.Headers[key] = value
 	}
 
 	return nil
 }
 
+// GetFromAddress returns the source address of the packet. The IPv6 link-local address has the zone.
+func (pkt *Packet) GetFromAddress() string {
+	addr := pkt.From.IP.String()
+	if 0 < len(pkt.From.Zone) {
+		addr += "%" + pkt.From.Zone
+	}
+	return addr
+}
+
 func (pkt *Packet) isMethod(name string) bool {
 	if len(pkt.FirstLines) < 1 {
 		return false
@@ -133,13 +279,21 @@ func (pkt *Packet) GetStatusCode() int {
 	return code
 }
 
+// SetHeaderString replaces the values of the specified header with the value.
 func (pkt *Packet) SetHeaderString(name string, value string) error {
-	pkt.Headers[name] = value
+	pkt.Headers.Set(name, value)
 	return nil
 }
 
+// AddHeaderString appends a value of the specified header.
+func (pkt *Packet) AddHeaderString(name string, value string) error {
+	pkt.Headers.Add(name, value)
+	return nil
+}
+
+// GetHeaderString returns the first value of the specified header. The header name is case-insensitive.
 func (pkt *Packet) GetHeaderString(name string) (string, error) {
-	value, ok := pkt.Headers[name]
+	value, ok := pkt.Headers.Get(name)
 	if !ok {
 		return "", fmt.Errorf(errorPacketHeaderNotFound, name)
 	}
@@ -147,6 +301,11 @@ func (pkt *Packet) GetHeaderString(name string) (string, error) {
 	return value, nil
 }
 
+// GetHeaderValues returns all values of the specified header in order.
+func (pkt *Packet) GetHeaderValues(name string) []string {
+	return pkt.Headers.Values(name)
+}
+
 func (pkt *Packet) IsHeaderString(name string, value string) bool {
 	headerValue, err := pkt.GetHeaderString(name)
 	if err != nil {
@@ -203,6 +362,25 @@ func (pkt *Packet) GetCacheControl() (string, error) {
 	return pkt.GetHeaderString(CacheControl)
 }
 
+func (pkt *Packet) SetMaxAge(value int) error {
+	return pkt.SetCacheControl(fmt.Sprintf("%s=%d", MaxAge, value))
+}
+
+func (pkt *Packet) GetMaxAge() (int, error) {
+	value, err := pkt.GetCacheControl()
+	if err != nil {
+		return 0, err
+	}
+	for directive := range strings.SplitSeq(value, ",") {
+		name, maxAge, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), MaxAge) {
+			continue
+		}
+		return strconv.Atoi(strings.TrimSpace(maxAge))
+	}
+	return 0, fmt.Errorf(errorPacketHeaderNotFound, MaxAge)
+}
+
 func (pkt *Packet) SetST(value string) error {
 	return pkt.SetHeaderString(ST, value)
 }
@@ -251,6 +429,16 @@ func (pkt *Packet) GetUSN() (string, error) {
 	return pkt.GetHeaderString(USN)
 }
 
+// GetUDN returns the UDN of the device from the USN header.
+func (pkt *Packet) GetUDN() (string, error) {
+	usn, err := pkt.GetUSN()
+	if err != nil {
+		return "", err
+	}
+	udn, _, _ := strings.Cut(usn, USNDelim)
+	return udn, nil
+}
+
 func (pkt *Packet) SetEXT(value string) error {
 	return pkt.SetHeaderString(EXT, value)
 }
@@ -307,6 +495,71 @@ func (pkt *Packet) GetBootIDUPnPOrg() (string, error) {
 	return pkt.GetHeaderString(BootIDUPnPOrg)
 }
 
+func (pkt *Packet) SetConfigIDUPnPOrg(value string) error {
+	return pkt.SetHeaderString(ConfigIDUPnPOrg, value)
+}
+
+func (pkt *Packet) GetConfigIDUPnPOrg() (string, error) {
+	return pkt.GetHeaderString(ConfigIDUPnPOrg)
+}
+
+func (pkt *Packet) SetNextBootIDUPnPOrg(value string) error {
+	return pkt.SetHeaderString(NextBootIDUPnPOrg, value)
+}
+
+func (pkt *Packet) GetNextBootIDUPnPOrg() (string, error) {
+	return pkt.GetHeaderString(NextBootIDUPnPOrg)
+}
+
+func (pkt *Packet) SetSearchPortUPnPOrg(value int) error {
+	return pkt.SetHeaderInt(SearchPortUPnPOrg, value)
+}
+
+func (pkt *Packet) GetSearchPortUPnPOrg() (int, error) {
+	return pkt.GetHeaderInt(SearchPortUPnPOrg)
+}
+
+func (pkt *Packet) SetSVCID(value string) error {
+	return pkt.SetHeaderString(SVCID, value)
+}
+
+func (pkt *Packet) GetSVCID() (string, error) {
+	return pkt.GetHeaderString(SVCID)
+}
+
+func (pkt *Packet) SetLVL(value string) error {
+	return pkt.SetHeaderString(LVL, value)
+}
+
+func (pkt *Packet) GetLVL() (string, error) {
+	return pkt.GetHeaderString(LVL)
+}
+
+func (pkt *Packet) SetContentType(value string) error {
+	return pkt.SetHeaderString(ContentType, value)
+}
+
+func (pkt *Packet) GetContentType() (string, error) {
+	return pkt.GetHeaderString(ContentType)
+}
+
+// SetBody sets the specified body, and updates the CONTENT-LENGTH header.
+func (pkt *Packet) SetBody(body []byte) error {
+	pkt.Body = body
+	return pkt.SetHeaderInt(ContentLength, len(body))
+}
+
+// GetBody returns the body. The body is truncated by the CONTENT-LENGTH header if it is specified.
+func (pkt *Packet) GetBody() []byte {
+	contentLen, err := pkt.GetHeaderInt(ContentLength)
+	if err == nil && 0 <= contentLen && contentLen < len(pkt.Body) {
+		return pkt.Body[:contentLen]
+	}
+	return pkt.Body
+}
+
+// String returns the canonical form of the packet. The header names are written in upper case,
+// and the headers are written in order with CRLF line endings.
 func (pkt *Packet) String() string {
 	var pktBuf bytes.Buffer
 
@@ -318,12 +571,19 @@ func (pkt *Packet) String() string {
 
 	// Write Headers
 
-	for name, value := range pkt.Headers {
-		pktBuf.WriteString(fmt.Sprintf("%s: %s%s", name, value, CRLF))
+	for _, header := range pkt.Headers {
+		pktBuf.WriteString(strings.ToUpper(header.Name))
+		pktBuf.WriteString(": ")
+		pktBuf.WriteString(header.Value)
+		pktBuf.WriteString(CRLF)
 	}
 
 	pktBuf.WriteString(CRLF)
 
+	// Write Body
+
+	pktBuf.Write(pkt.Body)
+
 	return pktBuf.String()
 }
 
@@ -14,3 +14,222 @@ type PacketGetHeaderFunc func(*Packet) func(string) (string, bool)
 func TestNewPacket(t *testing.T) {
 	NewPacket()
 }
+
+const (
+	errorTestPacketInvalidHeader  = "header (%s) = %v : expected %v"
+	errorTestPacketInvalidString  = "packet string = %q : expected %q"
+	errorTestPacketParseSucceeded = "%s packet is parsed in %s mode"
+	errorTestPacketNotIdempotent  = "canonical packet is changed by parsing\n%q\n%q"
+)
+
+// Packets of some real-world stacks to test the parser and seed the fuzz tests.
+var testRealWorldPackets = []string{
+	// Windows
+	"NOTIFY * HTTP/1.1\r\n" +
+		"Host:239.255.255.250:1900\r\n" +
+		"NT:urn:schemas-upnp-org:device:MediaRenderer:1\r\n" +
+		"NTS:ssdp:alive\r\n" +
+		"Location:http://192.168.0.10:2869/upnphost/udhisapi.dll?content=uuid:1234\r\n" +
+		"USN:uuid:1234::urn:schemas-upnp-org:device:MediaRenderer:1\r\n" +
+		"Cache-Control:max-age=900\r\n" +
+		"Server:Microsoft-Windows/10.0 UPnP/1.0 UPnP-Device-Host/1.0\r\n" +
+		"OPT:\"http://schemas.upnp.org/upnp/1/0/\"; ns=01\r\n" +
+		"01-NLS:6c4f8ed8b0e3c3f1\r\n" +
+		"\r\n",
+	// Embedded routers
+	"HTTP/1.1 200 OK\r\n" +
+		"CACHE-CONTROL: max-age=120\r\n" +
+		"ST: urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\n" +
+		"USN: uuid:abcd::urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\n" +
+		"EXT:\r\n" +
+		"SERVER: Linux/3.14 UPnP/1.1 MiniUPnPd/2.1\r\n" +
+		"LOCATION: http://192.168.0.1:5000/rootDesc.xml\r\n" +
+		"OPT: \"http://schemas.upnp.org/upnp/1/0/\"; ns=01\r\n" +
+		"01-NLS: 1\r\n" +
+		"BOOTID.UPNP.ORG: 1\r\n" +
+		"CONFIGID.UPNP.ORG: 1337\r\n" +
+		"\r\n",
+	// Media players with LF line endings
+	"HTTP/1.1 200 OK\n" +
+		"Cache-Control: max-age=3600\n" +
+		"ST: roku:ecp\n" +
+		"USN: uuid:roku:ecp:1234\n" +
+		"Ext: \n" +
+		"Server: Roku/9.4.0 UPnP/1.0 Roku/9.4.0\n" +
+		"LOCATION: http://192.168.0.20:8060/\n" +
+		"\n",
+	// Speakers with a multi-word reason phrase and a date
+	"HTTP/1.1 200 OK\r\n" +
+		"CACHE-CONTROL: max-age = 1800\r\n" +
+		"DATE: Mon, 01 Jan 2024 00:00:00 GMT\r\n" +
+		"EXT:\r\n" +
+		"LOCATION: http://192.168.0.30:1400/xml/device_description.xml\r\n" +
+		"SERVER: Linux UPnP/1.0 Sonos/70.3-35220 (ZPS1)\r\n" +
+		"ST: urn:schemas-upnp-org:device:ZonePlayer:1\r\n" +
+		"USN: uuid:RINCON_1234::urn:schemas-upnp-org:device:ZonePlayer:1\r\n" +
+		"X-RINCON-HOUSEHOLD: Sonos_abcd\r\n" +
+		"\r\n",
+	// Searches without the terminating empty line
+	"M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: 239.255.255.250:1900\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 3\r\n" +
+		"ST: ssdp:all\r\n",
+	// Multicast events
+	"NOTIFY * HTTP/1.1\r\n" +
+		"HOST: 239.255.255.246:7900\r\n" +
+		"CONTENT-TYPE: text/xml; charset=\"utf-8\"\r\n" +
+		"USN: uuid:1234::urn:schemas-upnp-org:service:SwitchPower:1\r\n" +
+		"SVCID: urn:upnp-org:serviceId:SwitchPower.1\r\n" +
+		"NT: upnp:event\r\n" +
+		"NTS: upnp:propchange\r\n" +
+		"SEQ: 0\r\n" +
+		"LVL: upnp:/general\r\n" +
+		"CONTENT-LENGTH: 13\r\n" +
+		"\r\n" +
+		"<propertyset>",
+}
+
+func TestPacketHeaders(t *testing.T) {
+	pkt := NewPacket()
+	pkt.AddHeaderString("Opt", "a")
+	pkt.AddHeaderString(ST, "b")
+	pkt.AddHeaderString("OPT", "c")
+
+	if values := pkt.GetHeaderValues("opt"); len(values) != 2 || values[0] != "a" || values[1] != "c" {
+		t.Errorf(errorTestPacketInvalidHeader, "opt", values, []string{"a", "c"})
+	}
+
+	value, err := pkt.GetHeaderString("st")
+	if err != nil || value != "b" {
+		t.Errorf(errorTestPacketInvalidHeader, "st", value, "b")
+	}
+
+	pkt.SetHeaderString("opt", "d")
+	if values := pkt.GetHeaderValues("OPT"); len(values) != 1 || values[0] != "d" {
+		t.Errorf(errorTestPacketInvalidHeader, "OPT", values, []string{"d"})
+	}
+
+	pkt.SetMethod(Notify)
+	expected := "NOTIFY * HTTP/1.1\r\nOPT: d\r\nST: b\r\n\r\n"
+	if pkt.String() != expected {
+		t.Errorf(errorTestPacketInvalidString, pkt.String(), expected)
+	}
+
+	pkt.Headers.Del(ST)
+	if pkt.Headers.Has(ST) || pkt.Headers.Len() != 1 {
+		t.Errorf(errorTestPacketInvalidHeader, ST, pkt.GetHeaderValues(ST), []string{})
+	}
+}
+
+func TestPacketLenientParse(t *testing.T) {
+	const pktString = "\r\n" +
+		"HTTP/1.1 200 OK\n" +
+		"st:urn:schemas-upnp-org:device:Basic:1\r\n" +
+		"Location : http://192.168.0.1:80/desc.xml\n" +
+		"X-Note: a: b\r\n" +
+		"\tc\r\n" +
+		"bad line\r\n" +
+		"X-Note: d\r\n"
+
+	pkt, err := NewPacketFromBytes([]byte(pktString))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code := pkt.GetStatusCode(); code != 200 {
+		t.Errorf(testErrorMsgBadStatusCode, code, 200)
+	}
+
+	headers := map[string]string{
+		ST:       "urn:schemas-upnp-org:device:Basic:1",
+		Location: "http://192.168.0.1:80/desc.xml",
+	}
+	for name, expectValue := range headers {
+		headerValue, _ := pkt.GetHeaderString(name)
+		if headerValue != expectValue {
+			t.Errorf(testErrorMsgBadHeader, name, headerValue, expectValue)
+		}
+	}
+
+	if values := pkt.GetHeaderValues("x-note"); len(values) != 2 || values[0] != "a: b c" || values[1] != "d" {
+		t.Errorf(errorTestPacketInvalidHeader, "x-note", values, []string{"a: b c", "d"})
+	}
+
+	expected := "HTTP/1.1 200 OK\r\n" +
+		"ST: urn:schemas-upnp-org:device:Basic:1\r\n" +
+		"LOCATION: http://192.168.0.1:80/desc.xml\r\n" +
+		"X-NOTE: a: b c\r\n" +
+		"X-NOTE: d\r\n" +
+		"\r\n"
+	if pkt.String() != expected {
+		t.Errorf(errorTestPacketInvalidString, pkt.String(), expected)
+	}
+}
+
+func TestPacketStrictParse(t *testing.T) {
+	for _, pktString := range testRealWorldPackets {
+		pkt, err := NewPacketFromBytes([]byte(pktString))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		_, err = NewPacketFromBytesWithMode(pkt.Bytes(), ParseStrict)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	badPackets := map[string]string{
+		"LF":              "M-SEARCH * HTTP/1.1\nST: ssdp:all\n\n",
+		"unterminated":    "M-SEARCH * HTTP/1.1\r\nST: ssdp:all\r\n",
+		"bad first line":  "M-SEARCH *\r\nST: ssdp:all\r\n\r\n",
+		"bad status":      "HTTP/1.1 2000 OK\r\n\r\n",
+		"bad header":      "M-SEARCH * HTTP/1.1\r\nST ssdp:all\r\n\r\n",
+		"spaced name":     "M-SEARCH * HTTP/1.1\r\nST : ssdp:all\r\n\r\n",
+		"folded header":   "M-SEARCH * HTTP/1.1\r\nST: ssdp:all\r\n more\r\n\r\n",
+		"content length":  "NOTIFY * HTTP/1.1\r\nCONTENT-LENGTH: 3\r\n\r\nab",
+		"leading newline": "\r\nM-SEARCH * HTTP/1.1\r\n\r\n",
+	}
+	for name, pktString := range badPackets {
+		_, err := NewPacketFromBytesWithMode([]byte(pktString), ParseStrict)
+		if err == nil {
+			t.Errorf(errorTestPacketParseSucceeded, name, "strict")
+		}
+	}
+}
+
+func FuzzPacket(f *testing.F) {
+	for _, pktString := range testRealWorldPackets {
+		f.Add([]byte(pktString))
+	}
+
+	f.Fuzz(func(t *testing.T, pktBytes []byte) {
+		strictPkt, strictErr := NewPacketFromBytesWithMode(pktBytes, ParseStrict)
+
+		pkt, err := NewPacketFromBytes(pktBytes)
+		if err != nil {
+			if strictErr == nil {
+				t.Errorf(errorTestPacketParseSucceeded, err.Error(), "strict")
+			}
+			return
+		}
+
+		// The canonical form is stable.
+
+		canonical := pkt.String()
+		reparsedPkt, err := NewPacketFromBytes([]byte(canonical))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reparsedPkt.String() != canonical {
+			t.Errorf(errorTestPacketNotIdempotent, canonical, reparsedPkt.String())
+		}
+
+		// The strict mode accepts a subset of the lenient mode with the same result.
+
+		if strictErr == nil && strictPkt.String() != canonical {
+			t.Errorf(errorTestPacketInvalidString, strictPkt.String(), canonical)
+		}
+	})
+}
@@ -4,6 +4,13 @@
 
 package ssdp
 
+import (
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
 type Request struct {
 	*Packet
 }
@@ -51,11 +58,83 @@ func NewSearchRequest(st string, mx int) (*Request, error) {
 	return ssdpReq, nil
 }
 
+// NewUnicastSearchRequest returns a new unicast M-SEARCH request of the specified ST to the specified host and port.
+// The unicast request has no MX header because the device responds without the random delay.
+// The zone of the IPv6 link-local host is not added to the HOST header.
+func NewUnicastSearchRequest(st string, host string, port int) (*Request, error) {
+	ssdpReq := NewRequest()
+
+	if n := strings.IndexByte(host, '%'); 0 <= n {
+		host = host[:n]
+	}
+
+	ssdpReq.SetMethod(MSearch)
+	ssdpReq.SetHost(net.JoinHostPort(host, strconv.Itoa(port)))
+	ssdpReq.SetMAN(Discover)
+	ssdpReq.SetST(st)
+
+	return ssdpReq, nil
+}
+
+// NewNotifyRequest returns a new NOTIFY request of the specified NT, NTS and USN.
+func NewNotifyRequest(nt string, nts string, usn string) (*Request, error) {
+	ssdpReq := NewRequest()
+
+	ssdpReq.SetMethod(Notify)
+	ssdpReq.SetHost(MulticastAddress)
+	ssdpReq.SetNT(nt)
+	ssdpReq.SetNTS(nts)
+	ssdpReq.SetUSN(usn)
+
+	return ssdpReq, nil
+}
+
+// NewPropChangeRequest returns a new multicast event request of the specified USN, SVCID, LVL, SEQ and property set body.
+func NewPropChangeRequest(usn string, svcid string, lvl string, seq uint32, body []byte) (*Request, error) {
+	ssdpReq := NewRequest()
+
+	ssdpReq.SetMethod(Notify)
+	ssdpReq.SetHost(EventMulticastAddress)
+	ssdpReq.SetContentType(EventContentType)
+	ssdpReq.SetUSN(usn)
+	ssdpReq.SetSVCID(svcid)
+	ssdpReq.SetNT(EventNT)
+	ssdpReq.SetNTS(NTSPropChange)
+	ssdpReq.SetSEQ(strconv.FormatUint(uint64(seq), 10))
+	ssdpReq.SetLVL(lvl)
+	ssdpReq.SetBody(body)
+
+	return ssdpReq, nil
+}
+
 func (req *Request) IsDiscover() bool {
 	return req.IsHeaderString(MAN, Discover)
 }
 
+// IsUnicastSearch returns true if the request is a M-SEARCH request whose HOST is not a multicast group address.
+func (req *Request) IsUnicastSearch() bool {
+	if !req.IsSearchRequest() {
+		return false
+	}
+	hostPort, err := req.GetHost()
+	if err != nil {
+		return false
+	}
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		host = hostPort
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	return !addr.IsMulticast()
+}
+
 func (req *Request) IsRootDevice() bool {
+	if req.IsNotifyRequest() {
+		return req.IsHeaderString(NT, RootDevice)
+	}
 	return req.IsHeaderString(ST, RootDevice)
 }
 
@@ -70,3 +149,8 @@ func (req *Request) IsByeBye() bool {
 func (req *Request) IsUpdate() bool {
 	return req.IsHeaderString(NTS, NTSUpdate)
 }
+
+// IsPropChange returns true if the request is a multicast event.
+func (req *Request) IsPropChange() bool {
+	return req.IsNotifyRequest() && req.IsHeaderString(NT, EventNT) && req.IsHeaderString(NTS, NTSPropChange)
+}
@@ -89,3 +89,113 @@ func checkSSDPSearchRequest(t *testing.T, req *Request) {
 		t.Errorf(testErrorMsgBadHeader, ST, headerValue, expectValue)
 	}
 }
+
+func TestSSDPNotifyRequest(t *testing.T) {
+	const udn = "uuid:2fac1234-31f8-11b4-a222-08002b34c003"
+
+	req, err := NewNotifyRequest(RootDevice, NTSByeBye, udn+USNDelim+RootDevice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err = NewRequestFromString(req.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !req.IsRootDevice() {
+		nt, _ := req.GetNT()
+		t.Errorf(testErrorMsgBadHeader, NT, nt, RootDevice)
+	}
+
+	if !req.IsByeBye() {
+		nts, _ := req.GetNTS()
+		t.Errorf(testErrorMsgBadHeader, NTS, nts, NTSByeBye)
+	}
+
+	headerValue, _ := req.GetUDN()
+	if headerValue != udn {
+		t.Errorf(testErrorMsgBadHeader, USN, headerValue, udn)
+	}
+}
+
+func TestPropChangeRequest(t *testing.T) {
+	const (
+		usn   = "uuid:test::urn:schemas-upnp-org:service:SwitchPower:1"
+		svcid = "urn:upnp-org:serviceId:SwitchPower.1"
+		body  = "<e:propertyset xmlns:e=\"urn:schemas-upnp-org:event-1-0\"></e:propertyset>"
+	)
+
+	req, err := NewPropChangeRequest(usn, svcid, EventLevelGeneral, 2, []byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err = NewRequestFromBytes(req.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !req.IsPropChange() {
+		nts, _ := req.GetNTS()
+		t.Errorf(testErrorMsgBadHeader, NTS, nts, NTSPropChange)
+	}
+
+	headers := map[string]string{
+		Host:          EventMulticastAddress,
+		USN:           usn,
+		SVCID:         svcid,
+		LVL:           EventLevelGeneral,
+		SEQ:           "2",
+		ContentLength: strconv.Itoa(len(body)),
+	}
+	for name, expectValue := range headers {
+		headerValue, _ := req.GetHeaderString(name)
+		if headerValue != expectValue {
+			t.Errorf(testErrorMsgBadHeader, name, headerValue, expectValue)
+		}
+	}
+
+	if string(req.GetBody()) != body {
+		t.Errorf(testErrorMsgBadHeader, "body", string(req.GetBody()), body)
+	}
+}
+
+func TestUnicastSearchRequest(t *testing.T) {
+	hosts := map[string]string{
+		"192.0.2.1":   "192.0.2.1:1900",
+		"fe80::1%lo0": "[fe80::1]:1900",
+	}
+
+	for host, expectValue := range hosts {
+		req, err := NewUnicastSearchRequest(RootDevice, host, Port)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, err = NewRequestFromBytes(req.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		headerValue, _ := req.GetHost()
+		if headerValue != expectValue {
+			t.Errorf(testErrorMsgBadHeader, Host, headerValue, expectValue)
+		}
+
+		if !req.IsDiscover() || !req.IsUnicastSearch() {
+			t.Errorf(testErrorMsgBadHeader, Host, headerValue, expectValue)
+		}
+
+		if req.Headers.Has(MX) {
+			headerValue, _ = req.GetHeaderString(MX)
+			t.Errorf(testErrorMsgBadHeader, MX, headerValue, "")
+		}
+	}
+
+	req, _ := NewSearchRequest(RootDevice, 1)
+	if req.IsUnicastSearch() {
+		headerValue, _ := req.GetHost()
+		t.Errorf(testErrorMsgBadHeader, Host, headerValue, MulticastAddress)
+	}
+}
@@ -27,6 +27,16 @@ func NewResponse() *Response {
 	return ssdpRes
 }
 
+// NewSearchResponse returns a new search response of the specified ST and USN.
+func NewSearchResponse(st string, usn string) (*Response, error) {
+	ssdpRes := NewResponse()
+
+	ssdpRes.SetST(st)
+	ssdpRes.SetUSN(usn)
+
+	return ssdpRes, nil
+}
+
 // NewResponseFromBytes returns a new Packet from the specified bytes.
 func NewResponseFromBytes(bytes []byte) (*Response, error) {
 	ssdpPkt, err := NewPacketFromBytes(bytes)
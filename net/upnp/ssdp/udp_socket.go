@@ -7,10 +7,14 @@ package ssdp
 import (
 	"errors"
 	"net"
+	"sync"
 )
 
 const (
-	errorSocketIsClosed = "socket is closed"
+	errorSocketIsClosed        = "socket is closed"
+	errorSocketBadGroupAddress = "multicast group address (%s) is invalid"
+	errorSocketGroupNotJoined  = "multicast group (%s) couldn't be joined on %s : %w"
+	errorSocketBadAddress      = "address (%s) is invalid"
 )
 
 // A UDPSocket represents a socket for UDP.
@@ -18,17 +22,42 @@ type UDPSocket struct {
 	Conn      *net.UDPConn
 	readBuf   []byte
 	Interface net.Interface
+	Address   string
+	lock      *sync.Mutex
 }
 
 // NewUDPSocket returns a new UDPSocket.
 func NewUDPSocket() *UDPSocket {
 	uppSock := &UDPSocket{}
 	uppSock.readBuf = make([]byte, MaxPacketSize)
+	uppSock.lock = &sync.Mutex{}
 	return uppSock
 }
 
+// setConn sets the specified opened connection, and the bound interface and address.
+func (socket *UDPSocket) setConn(conn *net.UDPConn, ifi net.Interface, addr string) {
+	socket.lock.Lock()
+	defer socket.lock.Unlock()
+
+	socket.Conn = conn
+	socket.Interface = ifi
+	socket.Address = addr
+}
+
+// getConn returns the current opened connection, and the bound interface and address.
+// The reader and writer goroutines use it not to race with Close.
+func (socket *UDPSocket) getConn() (*net.UDPConn, net.Interface, string) {
+	socket.lock.Lock()
+	defer socket.lock.Unlock()
+
+	return socket.Conn, socket.Interface, socket.Address
+}
+
 // Close closes the current opened socket.
 func (socket *UDPSocket) Close() error {
+	socket.lock.Lock()
+	defer socket.lock.Unlock()
+
 	if socket.Conn == nil {
 		return nil
 	}
@@ -39,17 +68,19 @@ func (socket *UDPSocket) Close() error {
 
 	socket.Conn = nil
 	socket.Interface = net.Interface{}
+	socket.Address = ""
 
 	return nil
 }
 
 // Read reads from the current opend socket.
 func (socket *UDPSocket) Read() (*Packet, error) {
-	if socket.Conn == nil {
+	conn, ifi, _ := socket.getConn()
+	if conn == nil {
 		return nil, errors.New(errorSocketIsClosed)
 	}
 
-	n, from, err := socket.Conn.ReadFromUDP(socket.readBuf)
+	n, from, err := conn.ReadFromUDP(socket.readBuf)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +91,7 @@ func (socket *UDPSocket) Read() (*Packet, error) {
 	}
 
 	ssdpPkt.From = *from
-	ssdpPkt.Interface = socket.Interface
+	ssdpPkt.Interface = ifi
 
 	return ssdpPkt, nil
 }
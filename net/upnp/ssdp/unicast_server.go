@@ -9,6 +9,7 @@ import (
 	"net"
 
 	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
 )
 
 // A UnicastListener represents a listener for UnicastServer.
@@ -16,11 +17,18 @@ type UnicastListener interface {
 	DeviceResponseReceived(ssdpRes *Response)
 }
 
+// A UnicastSearchListener represents a listener for the unicast M-SEARCH requests of UnicastServer.
+type UnicastSearchListener interface {
+	DeviceSearchReceived(ssdpReq *Request)
+}
+
 // A UnicastServer represents a packet of SSDP.
 type UnicastServer struct {
-	Socket    *UnicastSocket
-	Listener  UnicastListener
-	Interface net.Interface
+	Socket         *UnicastSocket
+	Listener       UnicastListener
+	SearchListener UnicastSearchListener
+	Interface      net.Interface
+	Address        string
 }
 
 // NewUnicastServer returns a new UnicastServer.
@@ -28,16 +36,27 @@ func NewUnicastServer() *UnicastServer {
 	server := &UnicastServer{}
 	server.Socket = NewUnicastSocket()
 	server.Listener = nil
+	server.SearchListener = nil
 	return server
 }
 
 // Start starts this server.
 func (server *UnicastServer) Start(ifi net.Interface, port int) error {
-	err := server.Socket.Bind(ifi, port)
+	addr, err := util.GetInterfaceAddress(ifi)
+	if err != nil {
+		return err
+	}
+	return server.StartWithAddress(ifi, addr, port)
+}
+
+// StartWithAddress starts this server on the specified address of the interface.
+func (server *UnicastServer) StartWithAddress(ifi net.Interface, addr string, port int) error {
+	err := server.Socket.BindAddress(ifi, addr, port)
 	if err != nil {
 		return err
 	}
 	server.Interface = ifi
+	server.Address = addr
 	go handleSSDPUnicastConnection(server)
 	return nil
 }
@@ -63,6 +82,18 @@ func (server *UnicastServer) Search(st string, mx int) error {
 	return err
 }
 
+// SearchHost sends a unicast M-SEARCH request of the specified ST to the specified host and port.
+func (server *UnicastServer) SearchHost(host string, port int, st string) error {
+	ssdpReq, err := NewUnicastSearchRequest(st, host, port)
+	if err != nil {
+		return err
+	}
+
+	_, err = server.Socket.WriteRequestTo(host, port, ssdpReq)
+
+	return err
+}
+
 func handleSSDPUnicastConnection(server *UnicastServer) {
 	for {
 		ssdpPkt, err := server.Socket.Read()
@@ -73,7 +104,13 @@ func handleSSDPUnicastConnection(server *UnicastServer) {
 			break
 		}
 
-		if server.Listener != nil {
+		switch {
+		case ssdpPkt.IsSearchRequest():
+			if server.SearchListener != nil {
+				ssdpReq, _ := NewRequestFromPacket(ssdpPkt)
+				server.SearchListener.DeviceSearchReceived(ssdpReq)
+			}
+		case server.Listener != nil:
 			ssdpRes, _ := NewResponseFromPacket(ssdpPkt)
 			server.Listener.DeviceResponseReceived(ssdpRes)
 		}
@@ -5,13 +5,20 @@
 package ssdp
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/cybergarage/go-logger/log"
 	"github.com/cybergarage/go-net-upnp/net/upnp/util"
 )
 
 // A UnicastServerList represents a packet of SSDP.
 type UnicastServerList struct {
-	Listener UnicastListener
-	Servers  []*UnicastServer
+	Listener       UnicastListener
+	SearchListener UnicastSearchListener
+	Servers        []*UnicastServer
+	Selector       *util.InterfaceSelector
 }
 
 // NewUnicastServerList returns a new UnicastServerList.
@@ -19,6 +26,7 @@ func NewUnicastServerList() *UnicastServerList {
 	server := &UnicastServerList{}
 	server.Servers = make([]*UnicastServer, 0)
 	server.Listener = nil
+	server.Selector = util.NewInterfaceSelector()
 	return server
 }
 
@@ -29,25 +37,42 @@ func (servers *UnicastServerList) Start(port int) error {
 		return err
 	}
 
-	ifis, err := util.GetAvailableInterfaces()
+	ifis, err := servers.Selector.GetAvailableInterfaces()
 	if err != nil {
 		return err
 	}
 
 	var lastErr error
 
-	servers.Servers = make([]*UnicastServer, len(ifis))
-	for n, ifi := range ifis {
-		server := NewUnicastServer()
-		server.Listener = servers.Listener
-		err := server.Start(ifi, port)
+	servers.Servers = make([]*UnicastServer, 0, len(ifis))
+	for _, ifi := range ifis {
+		addrs, err := servers.Selector.GetInterfaceAddresses(ifi)
 		if err != nil {
 			lastErr = err
+			continue
+		}
+		for _, addr := range addrs {
+			server := NewUnicastServer()
+			server.Listener = servers.Listener
+			server.SearchListener = servers.SearchListener
+			err := server.StartWithAddress(ifi, addr, port)
+			if err != nil {
+				log.Warnf("%s", fmt.Errorf(errorServerStartFailed, strconv.Itoa(port), addr, err).Error())
+				lastErr = err
+				continue
+			}
+			servers.Servers = append(servers.Servers, server)
 		}
-		servers.Servers[n] = server
 	}
 
-	return lastErr
+	if len(servers.Servers) == 0 {
+		if lastErr == nil {
+			lastErr = errors.New(errorServerNotStarted)
+		}
+		return lastErr
+	}
+
+	return nil
 }
 
 // Stop stops this server.
@@ -79,3 +104,24 @@ func (servers *UnicastServerList) Search(st string, mx int) error {
 
 	return lastErr
 }
+
+// SearchHost sends a unicast M-SEARCH request of the specified ST to the specified host and port
+// from the servers of the same address family as the host.
+func (servers *UnicastServerList) SearchHost(host string, port int, st string) error {
+	isIPv6 := util.IsIPv6Address(host)
+
+	lastErr := fmt.Errorf(errorServerNoAvailableAddress, host)
+	for _, server := range servers.Servers {
+		if util.IsIPv6Address(server.Address) != isIPv6 {
+			continue
+		}
+		err := server.SearchHost(host, port, st)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		lastErr = nil
+	}
+
+	return lastErr
+}
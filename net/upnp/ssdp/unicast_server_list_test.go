@@ -6,8 +6,36 @@ package ssdp
 
 import (
 	"testing"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/util"
+)
+
+const (
+	errorTestUnicastServerListStarted = "server list is started on the used port (%d) : %d servers"
 )
 
 func TestNewUnicastServerList(t *testing.T) {
 	NewUnicastServerList()
 }
+
+func TestUnicastServerListStartFailed(t *testing.T) {
+	const testPort = 39400
+
+	servers := NewUnicastServerList()
+	servers.Selector = util.NewLoopbackInterfaceSelector()
+	err := servers.Start(testPort)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer servers.Stop()
+
+	// All servers fail to bind the used port, and no server is kept.
+
+	usedServers := NewUnicastServerList()
+	usedServers.Selector = util.NewLoopbackInterfaceSelector()
+	err = usedServers.Start(testPort)
+	if err == nil || len(usedServers.Servers) != 0 {
+		t.Errorf(errorTestUnicastServerListStarted, testPort, len(usedServers.Servers))
+		usedServers.Stop()
+	}
+}
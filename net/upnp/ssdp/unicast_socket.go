@@ -16,9 +16,16 @@ func NewUnicastSocket() *UnicastSocket {
 	return ssdpSock
 }
 
-// WriteRequest sends the specified request.
+// WriteRequest sends the specified request to the default SSDP multicast group of the bound address.
 func (socket *UnicastSocket) WriteRequest(req *Request) (int, error) {
-	return socket.HTTPUSocket.Write(ADDRESS, Port, req.Bytes())
+	group := GetMulticastGroupAddresses(socket.Address)[0]
+	req.SetHost(GetMulticastHost(group))
+	return socket.HTTPUSocket.writeToUDPAddr(newUDPAddr(group, Port, socket.Interface), req.Bytes())
+}
+
+// WriteRequestTo sends the specified request to the specified address and port.
+func (socket *UnicastSocket) WriteRequestTo(addr string, port int, req *Request) (int, error) {
+	return socket.HTTPUSocket.Write(addr, port, req.Bytes())
 }
 
 // WriteBytes sends the specified bytes.
@@ -5,7 +5,19 @@
 package upnp
 
 import (
+	"context"
 	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/control"
+	"github.com/cybergarage/go-net-upnp/net/upnp/datatype"
+	"github.com/cybergarage/go-net-upnp/net/upnp/event"
+	"github.com/cybergarage/go-net-upnp/net/upnp/ssdp"
+)
+
+const (
+	errorStateVariableHasNoParentService = "state variable (%s) has no parent service"
 )
 
 // A StateVariable represents a UPnP state variable.
@@ -18,7 +30,10 @@ type StateVariable struct {
 	AllowedValueRange AllowedValueRange `xml:"allowedValueRange"`
 	SendEvents        string            `xml:"sendEvents,attr"`
 	Multicast         string            `xml:"multicast,attr"`
-	ParentService     *Service          `xml:"-"`
+	Value             string            `xml:"-"`
+	// EventLevel is the LVL header of the multicast events. ssdp.EventLevelGeneral is used when it is empty.
+	EventLevel    string   `xml:"-"`
+	ParentService *Service `xml:"-"`
 }
 
 // ServiceStateTable represents a UPnP service state table.
@@ -32,3 +47,198 @@ func NewStateVariable() *StateVariable {
 	stat := &StateVariable{}
 	return stat
 }
+
+// IsEvented returns true when the state variable sends events, otherwise false.
+// The sendEvents attribute is "yes" by default.
+func (statVar *StateVariable) IsEvented() bool {
+	return statVar.SendEvents != No
+}
+
+// IsMulticast returns true when the state variable sends multicast events, otherwise false.
+func (statVar *StateVariable) IsMulticast() bool {
+	return statVar.Multicast == Yes
+}
+
+// GetEventLevel returns the LVL header of the multicast events.
+func (statVar *StateVariable) GetEventLevel() string {
+	if len(statVar.EventLevel) == 0 {
+		return ssdp.EventLevelGeneral
+	}
+	return statVar.EventLevel
+}
+
+// SetValue sets the specified value, and notifies the subscribers when the evented value is changed.
+// The multicast events are also sent when the multicast value is changed and the device is started.
+func (statVar *StateVariable) SetValue(value string) error {
+	service := statVar.ParentService
+	if service == nil || service.stateLock == nil {
+		statVar.Value = value
+		return nil
+	}
+
+	// The events are queued and the multicast SEQ is assigned with the value in the same critical section,
+	// so that the concurrent values are notified in the order they are set.
+
+	service.stateLock.Lock()
+	isChanged := statVar.Value != value
+	statVar.Value = value
+
+	var set *event.PropertySet
+	if isChanged && (statVar.IsEvented() || statVar.IsMulticast()) {
+		set = event.NewPropertySet()
+		set.AddProperty(statVar.Name, value)
+	}
+
+	if isChanged && statVar.IsEvented() {
+		service.notifyPropertySet(set)
+	}
+
+	var mcastSEQ uint32
+	if isChanged && statVar.IsMulticast() {
+		mcastSEQ = service.nextMulticastSEQ()
+	}
+	service.stateLock.Unlock()
+
+	if isChanged && statVar.IsMulticast() {
+		service.notifyMulticastPropertySet(statVar.GetEventLevel(), mcastSEQ, set)
+	}
+
+	return nil
+}
+
+// GetValue returns the current value.
+func (statVar *StateVariable) GetValue() string {
+	service := statVar.ParentService
+	if service == nil || service.stateLock == nil {
+		return statVar.Value
+	}
+
+	service.stateLock.RLock()
+	defer service.stateLock.RUnlock()
+
+	return statVar.Value
+}
+
+// Query gets the current value from the device using the QueryStateVariable action, and sets it as the value.
+// QueryStateVariable is deprecated in UPnP 2.0, but it is useful for UPnP 1.0 devices which have no getter actions.
+func (statVar *StateVariable) Query() (string, error) {
+	return statVar.QueryContext(context.Background())
+}
+
+// QueryContext gets the current value from the device with the specified context, and sets it as the value.
+func (statVar *StateVariable) QueryContext(ctx context.Context) (string, error) {
+	service := statVar.ParentService
+	if service == nil {
+		return "", fmt.Errorf(errorStateVariableHasNoParentService, statVar.Name)
+	}
+
+	queryRes, err := postControlRequest(ctx, service, control.NewQueryRequest(statVar.Name))
+	if err != nil {
+		return "", err
+	}
+
+	value, err := queryRes.GetQueryReturnValue()
+	if err != nil {
+		return "", err
+	}
+
+	return value, statVar.SetValue(value)
+}
+
+// ValidateValue returns a UPnP error when the specified value is not valid for the data type, the allowed value list or the allowed value range.
+func (statVar *StateVariable) ValidateValue(value string) Error {
+	if err := datatype.Validate(statVar.DataType, value); err != nil {
+		return NewErrorFromCode(ErrorArgumentValueInvalid)
+	}
+	if !statVar.AllowedValueList.IsAllowed(value) {
+		return NewErrorFromCode(ErrorArgumentValueInvalid)
+	}
+	if !statVar.AllowedValueRange.IsInRange(value) {
+		return NewErrorFromCode(ErrorArgumentValueOutOfRange)
+	}
+	return nil
+}
+
+// SetInt sets the specified integer value for the data type.
+func (statVar *StateVariable) SetInt(value int) error {
+	str, err := datatype.FormatInt(statVar.DataType, int64(value))
+	if err != nil {
+		return err
+	}
+	return statVar.SetValue(str)
+}
+
+// GetInt returns the current value as an integer.
+func (statVar *StateVariable) GetInt() (int, error) {
+	i, err := datatype.ParseInt(statVar.DataType, statVar.GetValue())
+	if err != nil {
+		return 0, err
+	}
+	return int(i), nil
+}
+
+// SetUint sets the specified unsigned integer value for the data type.
+func (statVar *StateVariable) SetUint(value uint64) error {
+	str, err := datatype.FormatUint(statVar.DataType, value)
+	if err != nil {
+		return err
+	}
+	return statVar.SetValue(str)
+}
+
+// GetUint returns the current value as an unsigned integer.
+func (statVar *StateVariable) GetUint() (uint64, error) {
+	return datatype.ParseUint(statVar.DataType, statVar.GetValue())
+}
+
+// SetFloat sets the specified float value for the data type.
+func (statVar *StateVariable) SetFloat(value float64) error {
+	str, err := datatype.FormatFloat(statVar.DataType, value)
+	if err != nil {
+		return err
+	}
+	return statVar.SetValue(str)
+}
+
+// GetFloat returns the current value as a float.
+func (statVar *StateVariable) GetFloat() (float64, error) {
+	return datatype.ParseFloat(statVar.DataType, statVar.GetValue())
+}
+
+// SetBool sets the specified boolean value.
+func (statVar *StateVariable) SetBool(value bool) error {
+	return statVar.SetValue(datatype.FormatBool(value))
+}
+
+// GetBool returns the current value as a boolean.
+func (statVar *StateVariable) GetBool() (bool, error) {
+	return datatype.ParseBool(statVar.GetValue())
+}
+
+// SetTime sets the specified date or time value for the data type.
+func (statVar *StateVariable) SetTime(value time.Time) error {
+	str, err := datatype.FormatTime(statVar.DataType, value)
+	if err != nil {
+		return err
+	}
+	return statVar.SetValue(str)
+}
+
+// GetTime returns the current value as a date or time.
+func (statVar *StateVariable) GetTime() (time.Time, error) {
+	return datatype.ParseTime(statVar.DataType, statVar.GetValue())
+}
+
+// SetBytes sets the specified binary value for the data type.
+func (statVar *StateVariable) SetBytes(value []byte) error {
+	str, err := datatype.FormatBytes(statVar.DataType, value)
+	if err != nil {
+		return err
+	}
+	return statVar.SetValue(str)
+}
+
+// GetBytes returns the current value as a binary.
+func (statVar *StateVariable) GetBytes() ([]byte, error) {
+	return datatype.ParseBytes(statVar.DataType, statVar.GetValue())
+}
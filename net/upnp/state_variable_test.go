@@ -5,9 +5,331 @@
 package upnp
 
 import (
+	"errors"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
+)
+
+const (
+	errorStateVariableInvalidValidation = "validation of %s (%s) = %d : expected %d"
+	errorStateVariableInvalidTypedValue = "state variable (%s) value = %v : expected %v"
+	errorStateVariableInvalidQueryError = "query of %s error = %v : expected error code %d"
+	errorStateVariableInvalidEvents     = "state variable (%s) events = %v : expected %d unique values ending with %s"
+	errorStateVariableInvalidInitial    = "subscriber (%s) events = %v : expected the initial value followed by unique values ending with %s"
+	errorStateVariableSubscriberRemains = "subscriber (%s) remains with %d queued events"
+	errorStateVariableSubscriberVisible = "subscriber (%s) is visible before the initial event is queued"
 )
 
 func TestNewStateVariable(t *testing.T) {
 	NewStateVariable()
 }
+
+func TestStateVariableValidateValue(t *testing.T) {
+	statVar := NewStateVariable()
+	statVar.DataType = "ui2"
+	statVar.AllowedValueRange = AllowedValueRange{Minimum: "0", Maximum: "100", Step: "5"}
+
+	values := map[string]int{
+		"0":   0,
+		"50":  0,
+		"100": 0,
+		"-1":  ErrorArgumentValueInvalid,
+		"abc": ErrorArgumentValueInvalid,
+		"101": ErrorArgumentValueOutOfRange,
+		"52":  ErrorArgumentValueOutOfRange,
+	}
+	for value, expectedCode := range values {
+		checkStateVariableValidateValue(t, statVar, value, expectedCode)
+	}
+
+	statVar = NewStateVariable()
+	statVar.DataType = "string"
+	statVar.AllowedValueList.AllowedValues = []AllowedValue{{Value: "OK"}, {Value: "ERROR"}}
+
+	values = map[string]int{
+		"OK":    0,
+		"ERROR": 0,
+		"ok":    ErrorArgumentValueInvalid,
+	}
+	for value, expectedCode := range values {
+		checkStateVariableValidateValue(t, statVar, value, expectedCode)
+	}
+}
+
+func checkStateVariableValidateValue(t *testing.T, statVar *StateVariable, value string, expectedCode int) {
+	t.Helper()
+
+	upnpErr := statVar.ValidateValue(value)
+	code := 0
+	if upnpErr != nil {
+		code = upnpErr.GetCode()
+	}
+	if code != expectedCode {
+		t.Errorf(errorStateVariableInvalidValidation, value, statVar.DataType, code, expectedCode)
+	}
+}
+
+func TestStateVariableTypedValues(t *testing.T) {
+	statVar := NewStateVariable()
+	statVar.Name = "Volume"
+	statVar.DataType = "i2"
+
+	if err := statVar.SetInt(-100); err != nil || statVar.GetValue() != "-100" {
+		t.Errorf(errorStateVariableInvalidTypedValue, statVar.Name, statVar.GetValue(), "-100")
+	}
+	if v, err := statVar.GetInt(); err != nil || v != -100 {
+		t.Errorf(errorStateVariableInvalidTypedValue, statVar.Name, v, -100)
+	}
+	if err := statVar.SetInt(40000); err == nil {
+		t.Errorf(errorStateVariableInvalidTypedValue, statVar.Name, 40000, "error")
+	}
+
+	statVar.DataType = "bin.hex"
+	if err := statVar.SetBytes([]byte{0xca, 0xfe}); err != nil || statVar.GetValue() != "cafe" {
+		t.Errorf(errorStateVariableInvalidTypedValue, statVar.Name, statVar.GetValue(), "cafe")
+	}
+	if v, err := statVar.GetBytes(); err != nil || string(v) != "\xca\xfe" {
+		t.Errorf(errorStateVariableInvalidTypedValue, statVar.Name, v, []byte{0xca, 0xfe})
+	}
+}
+
+func TestStateVariableQuery(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dev.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err := dev.Stop()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	service, err := dev.GetSwitchPowerService()
+	if err != nil {
+		t.Fatal(err)
+	}
+	statVar, err := service.GetStateVariableByName("Status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statVar.SetBool(true)
+
+	// load the device as a control point
+
+	cpDev, err := NewTestControlPointDevice(dev.Device)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cpService, err := cpDev.GetServiceByType(service.ServiceType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpStatVar, err := cpService.GetStateVariableByName("Status")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := cpStatVar.Query()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "1" {
+		t.Errorf(errorStateVariableInvalidTypedValue, cpStatVar.Name, value, "1")
+	}
+	if v, err := cpStatVar.GetBool(); err != nil || !v {
+		t.Errorf(errorStateVariableInvalidTypedValue, cpStatVar.Name, v, true)
+	}
+
+	// unknown state variable
+
+	unknownStatVar := NewStateVariable()
+	unknownStatVar.Name = "Unknown"
+	unknownStatVar.ParentService = cpService
+
+	_, err = unknownStatVar.Query()
+	var upnpErr Error
+	if !errors.As(err, &upnpErr) || upnpErr.GetCode() != ErrorInvalidVar {
+		t.Errorf(errorStateVariableInvalidQueryError, unknownStatVar.Name, err, ErrorInvalidVar)
+	}
+}
+
+func TestStateVariableConcurrentEvents(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.reviseParentObject()
+	err = dev.reviseDescription()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, _ := dev.GetSwitchPowerService()
+	statVar, err := service.GetStateVariableByName("Status")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The subscriber is not started to keep the queued events.
+
+	sub := NewSubscriber(nil, 0)
+	service.subscribers.AddSubscriber(sub)
+
+	const valueCount = 100
+
+	var wg sync.WaitGroup
+	for n := range valueCount {
+		wg.Go(func() {
+			statVar.SetValue(strconv.Itoa(n + 1))
+		})
+	}
+	wg.Wait()
+
+	// Each event has the value which is set by each call, and the last event has the current value.
+
+	values := make([]string, 0, valueCount)
+	uniqueValues := map[string]bool{}
+	for {
+		set, ok := sub.nextQueuedEvent()
+		if !ok {
+			break
+		}
+		for _, prop := range set.Properties {
+			values = append(values, prop.Value)
+			uniqueValues[prop.Value] = true
+		}
+	}
+
+	value := statVar.GetValue()
+	if len(uniqueValues) != valueCount || len(values) != valueCount || values[len(values)-1] != value {
+		t.Errorf(errorStateVariableInvalidEvents, statVar.Name, values, valueCount, value)
+	}
+}
+
+func TestStateVariableInitialEvents(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.reviseParentObject()
+	err = dev.reviseDescription()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, _ := dev.GetSwitchPowerService()
+	statVar, err := service.GetStateVariableByName("Status")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The subscriber is not visible to the value changes until the initial event is queued.
+
+	service.stateLock.Lock()
+	sub := NewSubscriber(nil, 0)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- service.addSubscriber(sub)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := service.subscribers.GetSubscriber(sub.SID); ok {
+		t.Errorf(errorStateVariableSubscriberVisible, sub.SID)
+	}
+	service.stateLock.Unlock()
+	err = <-errCh
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The subscribers are added while the values are set, and they are not started to keep the queued events.
+
+	const valueCount = 100
+
+	subs := make([]*Subscriber, valueCount)
+	var wg sync.WaitGroup
+	for n := range valueCount {
+		wg.Go(func() {
+			statVar.SetValue(strconv.Itoa(n + 1))
+		})
+		wg.Go(func() {
+			subs[n] = NewSubscriber(nil, 0)
+			err := service.addSubscriber(subs[n])
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	// The first event of each subscriber has the value when it is added, and the later events have the values which are set after it.
+
+	value := statVar.GetValue()
+	for _, sub := range subs {
+		values := make([]string, 0)
+		uniqueValues := map[string]bool{}
+		for {
+			set, ok := sub.nextQueuedEvent()
+			if !ok {
+				break
+			}
+			for _, prop := range set.Properties {
+				if prop.Name != statVar.Name {
+					continue
+				}
+				values = append(values, prop.Value)
+				uniqueValues[prop.Value] = true
+			}
+		}
+		if len(values) == 0 || len(uniqueValues) != len(values) || values[len(values)-1] != value {
+			t.Errorf(errorStateVariableInvalidInitial, sub.SID, values, value)
+		}
+	}
+}
+
+func TestStateVariableQueueOverflow(t *testing.T) {
+	dev, err := NewTestDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.reviseParentObject()
+	err = dev.reviseDescription()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, _ := dev.GetSwitchPowerService()
+	statVar, err := service.GetStateVariableByName("Status")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The subscriber is not started, and it is dropped when the queue exceeds the limit with the initial event.
+
+	sub := NewSubscriber(nil, 0)
+	err = service.addSubscriber(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n := range subscriberMaxQueuedEvents {
+		statVar.SetValue(strconv.Itoa(n + 1))
+	}
+
+	if _, ok := service.subscribers.GetSubscriber(sub.SID); ok {
+		t.Errorf(errorStateVariableSubscriberRemains, sub.SID, len(sub.queue))
+	}
+
+	if _, ok := sub.nextQueuedEvent(); ok {
+		t.Errorf(errorStateVariableSubscriberRemains, sub.SID, len(sub.queue)+1)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-net-upnp/net/upnp/event"
+	"github.com/cybergarage/go-net-upnp/net/upnp/http"
+)
+
+const (
+	subscriberNotifyTimeout   = 30 * time.Second
+	subscriberMaxQueuedEvents = 256
+)
+
+const (
+	errorSubscriberNoCallbackURLs = "subscriber (%s) has no callback URLs"
+	errorSubscriberBadResponse    = "subscriber (%s) callback (%s) is bad response (%d)"
+	errorSubscriberQueueOverflow  = "subscriber (%s) is dropped by too many queued events (%d)"
+)
+
+// A Subscriber represents a control point which subscribes to the events of a service.
+type Subscriber struct {
+	*sync.Mutex
+
+	SID          string
+	CallbackURLs []*url.URL
+	Timeout      int
+
+	expirationTime time.Time
+	seq            uint32
+	queue          []*event.PropertySet
+	notifyCh       chan struct{}
+	stopCh         chan struct{}
+}
+
+// NewSubscriber returns a new subscriber of the specified callback URLs and timeout seconds.
+func NewSubscriber(callbackURLs []*url.URL, timeout int) *Subscriber {
+	sub := &Subscriber{
+		Mutex:        &sync.Mutex{},
+		SID:          event.CreateSID(),
+		CallbackURLs: callbackURLs,
+		queue:        make([]*event.PropertySet, 0),
+		notifyCh:     make(chan struct{}, 1),
+		stopCh:       make(chan struct{}),
+	}
+	sub.Renew(timeout)
+	return sub
+}
+
+// Renew extends the subscription by the specified timeout seconds.
+// An infinite (zero) timeout is replaced by the default duration.
+func (sub *Subscriber) Renew(timeout int) {
+	if timeout <= 0 {
+		timeout = event.DefaultTimeout
+	}
+
+	sub.Lock()
+	defer sub.Unlock()
+
+	sub.Timeout = timeout
+	sub.expirationTime = time.Now().Add(time.Duration(timeout) * time.Second)
+}
+
+// GetTimeout returns the current timeout seconds.
+func (sub *Subscriber) GetTimeout() int {
+	sub.Lock()
+	defer sub.Unlock()
+	return sub.Timeout
+}
+
+// GetExpirationTime returns the time when the subscription expires.
+func (sub *Subscriber) GetExpirationTime() time.Time {
+	sub.Lock()
+	defer sub.Unlock()
+	return sub.expirationTime
+}
+
+// IsExpired returns true when the subscription is expired, otherwise false.
+func (sub *Subscriber) IsExpired() bool {
+	return sub.GetExpirationTime().Before(time.Now())
+}
+
+// start starts the delivery of queued events.
+func (sub *Subscriber) start() {
+	go sub.deliverEvents()
+}
+
+// stop stops the delivery of the events.
+func (sub *Subscriber) stop() {
+	sub.Lock()
+	defer sub.Unlock()
+
+	select {
+	case <-sub.stopCh:
+	default:
+		close(sub.stopCh)
+	}
+}
+
+// notify queues the specified event to deliver in order.
+// It returns an error and discards the queued events when the subscriber has too many undelivered events.
+func (sub *Subscriber) notify(set *event.PropertySet) error {
+	sub.Lock()
+	if subscriberMaxQueuedEvents <= len(sub.queue) {
+		queuedCount := len(sub.queue)
+		sub.queue = make([]*event.PropertySet, 0)
+		sub.Unlock()
+		return fmt.Errorf(errorSubscriberQueueOverflow, sub.SID, queuedCount)
+	}
+	sub.queue = append(sub.queue, set)
+	sub.Unlock()
+
+	select {
+	case sub.notifyCh <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (sub *Subscriber) nextQueuedEvent() (*event.PropertySet, bool) {
+	sub.Lock()
+	defer sub.Unlock()
+
+	if len(sub.queue) == 0 {
+		return nil, false
+	}
+
+	set := sub.queue[0]
+	sub.queue = sub.queue[1:]
+
+	return set, true
+}
+
+func (sub *Subscriber) deliverEvents() {
+	for {
+		select {
+		case <-sub.stopCh:
+			return
+		case <-sub.notifyCh:
+		}
+
+		for {
+			set, ok := sub.nextQueuedEvent()
+			if !ok {
+				break
+			}
+
+			err := sub.post(set, sub.seq)
+			if err != nil {
+				log.Warnf("%s", err.Error())
+			}
+
+			sub.seq = event.NextSEQ(sub.seq)
+		}
+	}
+}
+
+// post sends the specified event to the first callback URL which accepts it.
+func (sub *Subscriber) post(set *event.PropertySet, seq uint32) error {
+	content, err := set.XMLContentString()
+	if err != nil {
+		return err
+	}
+
+	log.Tracef("event notify (%s:%d) = \n%s", sub.SID, seq, content)
+
+	client := http.GetDefaultClient().WithTimeout(subscriberNotifyTimeout)
+
+	errs := make([]error, 0)
+	for _, callbackURL := range sub.CallbackURLs {
+		httpReq, err := http.NewNotifyRequest(callbackURL, strings.NewReader(content))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		http.SetRawHeader(httpReq.Header, http.NT, event.NT)
+		http.SetRawHeader(httpReq.Header, http.NTS, event.NTS)
+		http.SetRawHeader(httpReq.Header, http.SID, sub.SID)
+		http.SetRawHeader(httpReq.Header, http.SEQ, strconv.FormatUint(uint64(seq), 10))
+
+		httpRes, err := client.Do(httpReq)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		io.Copy(io.Discard, httpRes.Body)
+		httpRes.Body.Close()
+
+		if httpRes.StatusCode == http.StatusOK {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf(errorSubscriberBadResponse, sub.SID, callbackURL, httpRes.StatusCode))
+	}
+
+	if len(errs) == 0 {
+		return fmt.Errorf(errorSubscriberNoCallbackURLs, sub.SID)
+	}
+
+	return errors.Join(errs...)
+}
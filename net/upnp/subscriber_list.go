@@ -0,0 +1,106 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"sync"
+)
+
+// A SubscriberList represents subscribers of a service by SID.
+type SubscriberList struct {
+	*sync.Mutex
+
+	subscribers map[string]*Subscriber
+}
+
+// NewSubscriberList returns a new subscriber list.
+func NewSubscriberList() *SubscriberList {
+	list := &SubscriberList{
+		Mutex:       &sync.Mutex{},
+		subscribers: make(map[string]*Subscriber),
+	}
+	return list
+}
+
+// removeExpiredSubscribers removes and stops expired subscribers without locking.
+func (list *SubscriberList) removeExpiredSubscribers() {
+	for sid, sub := range list.subscribers {
+		if !sub.IsExpired() {
+			continue
+		}
+		sub.stop()
+		delete(list.subscribers, sid)
+	}
+}
+
+// AddSubscriber adds the specified subscriber.
+func (list *SubscriberList) AddSubscriber(sub *Subscriber) {
+	list.Lock()
+	defer list.Unlock()
+
+	list.removeExpiredSubscribers()
+	list.subscribers[sub.SID] = sub
+}
+
+// GetSubscriber returns an active subscriber of the specified SID.
+func (list *SubscriberList) GetSubscriber(sid string) (*Subscriber, bool) {
+	list.Lock()
+	defer list.Unlock()
+
+	list.removeExpiredSubscribers()
+	sub, ok := list.subscribers[sid]
+
+	return sub, ok
+}
+
+// RemoveSubscriber removes and stops a subscriber of the specified SID.
+func (list *SubscriberList) RemoveSubscriber(sid string) bool {
+	list.Lock()
+	defer list.Unlock()
+
+	sub, ok := list.subscribers[sid]
+	if !ok {
+		return false
+	}
+
+	sub.stop()
+	delete(list.subscribers, sid)
+
+	return true
+}
+
+// GetSubscribers returns all active subscribers.
+func (list *SubscriberList) GetSubscribers() []*Subscriber {
+	list.Lock()
+	defer list.Unlock()
+
+	list.removeExpiredSubscribers()
+
+	subs := make([]*Subscriber, 0, len(list.subscribers))
+	for _, sub := range list.subscribers {
+		subs = append(subs, sub)
+	}
+
+	return subs
+}
+
+// Size returns the number of the subscribers including expired ones.
+func (list *SubscriberList) Size() int {
+	list.Lock()
+	defer list.Unlock()
+	return len(list.subscribers)
+}
+
+// Clear removes and stops all subscribers.
+func (list *SubscriberList) Clear() {
+	list.Lock()
+	defer list.Unlock()
+
+	for _, sub := range list.subscribers {
+		sub.stop()
+	}
+
+	list.subscribers = make(map[string]*Subscriber)
+}
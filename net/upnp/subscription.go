@@ -0,0 +1,164 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-net-upnp/net/upnp/event"
+)
+
+// A Subscription represents an event subscription of a control point to a service.
+type Subscription struct {
+	*sync.Mutex
+
+	Service *Service
+
+	sid              string
+	requestedTimeout int
+	timeout          int
+	expirationTime   time.Time
+	callbackPath     string
+	nextSEQ          uint32
+	renewTimer       *time.Timer
+	stopped          bool
+
+	// eventLock serializes the events of the subscription from the SEQ check to the dispatch.
+	eventLock *sync.Mutex
+}
+
+func newSubscription(service *Service, timeout int) *Subscription {
+	if timeout <= 0 {
+		timeout = event.DefaultTimeout
+	}
+
+	sub := &Subscription{
+		Mutex:            &sync.Mutex{},
+		Service:          service,
+		requestedTimeout: timeout,
+		eventLock:        &sync.Mutex{},
+	}
+
+	return sub
+}
+
+// GetSID returns the subscription identifier which is assigned by the device.
+func (sub *Subscription) GetSID() string {
+	sub.Lock()
+	defer sub.Unlock()
+	return sub.sid
+}
+
+// GetTimeout returns the timeout seconds which is accepted by the device, or zero when it is infinite.
+func (sub *Subscription) GetTimeout() int {
+	sub.Lock()
+	defer sub.Unlock()
+	return sub.timeout
+}
+
+// GetExpirationTime returns the time when the subscription expires unless it is renewed.
+func (sub *Subscription) GetExpirationTime() time.Time {
+	sub.Lock()
+	defer sub.Unlock()
+	return sub.expirationTime
+}
+
+// IsExpired returns true when the subscription is expired, otherwise false.
+func (sub *Subscription) IsExpired() bool {
+	sub.Lock()
+	defer sub.Unlock()
+	if sub.timeout <= 0 {
+		return false
+	}
+	return sub.expirationTime.Before(time.Now())
+}
+
+// getCallbackPath returns the callback path of the subscription.
+func (sub *Subscription) getCallbackPath() string {
+	sub.Lock()
+	defer sub.Unlock()
+	return sub.callbackPath
+}
+
+// reset clears the SID and the event sequence to subscribe again with the specified callback path.
+func (sub *Subscription) reset(callbackPath string) {
+	sub.Lock()
+	defer sub.Unlock()
+
+	sub.sid = ""
+	sub.callbackPath = callbackPath
+	sub.nextSEQ = 0
+}
+
+// accept updates the SID and the timeout of the specified subscription response.
+func (sub *Subscription) accept(sid string, timeout int) {
+	sub.Lock()
+	defer sub.Unlock()
+
+	sub.sid = sid
+	sub.timeout = timeout
+	sub.expirationTime = time.Now().Add(time.Duration(timeout) * time.Second)
+}
+
+// acceptSID returns true when the specified SID is the subscription's one.
+// The initial event may arrive before the subscription response, so the first SID is accepted.
+func (sub *Subscription) acceptSID(sid string) bool {
+	sub.Lock()
+	defer sub.Unlock()
+
+	if len(sub.sid) == 0 {
+		sub.sid = sid
+	}
+
+	return sub.sid == sid
+}
+
+// updateSEQ records the specified event key, and returns true when previous events were missed.
+func (sub *Subscription) updateSEQ(seq uint32) bool {
+	sub.Lock()
+	defer sub.Unlock()
+
+	missed := seq != sub.nextSEQ
+	sub.nextSEQ = event.NextSEQ(seq)
+
+	return missed
+}
+
+// setRenewTimer replaces the renewal timer, and returns false when the subscription is stopped.
+func (sub *Subscription) setRenewTimer(interval time.Duration, renew func()) bool {
+	sub.Lock()
+	defer sub.Unlock()
+
+	if sub.stopped {
+		return false
+	}
+
+	if sub.renewTimer != nil {
+		sub.renewTimer.Stop()
+	}
+	sub.renewTimer = time.AfterFunc(interval, renew)
+
+	return true
+}
+
+// isStopped returns true when the subscription is stopped, otherwise false.
+func (sub *Subscription) isStopped() bool {
+	sub.Lock()
+	defer sub.Unlock()
+	return sub.stopped
+}
+
+// stop stops the renewal of the subscription.
+func (sub *Subscription) stop() {
+	sub.Lock()
+	defer sub.Unlock()
+
+	sub.stopped = true
+	if sub.renewTimer != nil {
+		sub.renewTimer.Stop()
+		sub.renewTimer = nil
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package upnp
+
+import (
+	"sync"
+)
+
+// A SubscriptionList represents subscriptions of a control point by callback path.
+type SubscriptionList struct {
+	*sync.Mutex
+
+	subscriptions map[string]*Subscription
+}
+
+// NewSubscriptionList returns a new subscription list.
+func NewSubscriptionList() *SubscriptionList {
+	list := &SubscriptionList{
+		Mutex:         &sync.Mutex{},
+		subscriptions: make(map[string]*Subscription),
+	}
+	return list
+}
+
+// AddSubscription adds the specified subscription.
+func (list *SubscriptionList) AddSubscription(sub *Subscription) {
+	list.Lock()
+	defer list.Unlock()
+	list.subscriptions[sub.getCallbackPath()] = sub
+}
+
+// GetSubscriptionByCallbackPath returns a subscription of the specified callback path.
+func (list *SubscriptionList) GetSubscriptionByCallbackPath(path string) (*Subscription, bool) {
+	list.Lock()
+	defer list.Unlock()
+	sub, ok := list.subscriptions[path]
+	return sub, ok
+}
+
+// RemoveSubscription removes the specified subscription.
+func (list *SubscriptionList) RemoveSubscription(sub *Subscription) bool {
+	list.Lock()
+	defer list.Unlock()
+
+	path := sub.getCallbackPath()
+	if list.subscriptions[path] != sub {
+		return false
+	}
+	delete(list.subscriptions, path)
+
+	return true
+}
+
+// GetSubscriptions returns all subscriptions.
+func (list *SubscriptionList) GetSubscriptions() []*Subscription {
+	list.Lock()
+	defer list.Unlock()
+
+	subs := make([]*Subscription, 0, len(list.subscriptions))
+	for _, sub := range list.subscriptions {
+		subs = append(subs, sub)
+	}
+
+	return subs
+}
+
+// Size returns the number of the subscriptions.
+func (list *SubscriptionList) Size() int {
+	list.Lock()
+	defer list.Unlock()
+	return len(list.subscriptions)
+}
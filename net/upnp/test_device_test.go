@@ -20,6 +20,8 @@ const (
 	errorTestDeviceInvalidParentObject  = "invalid parent object %p = '%p', expected : '%p'"
 	errorTestDeviceInvalidArgumentValue = "invalid argument value %s = '%s', expected : '%s'"
 	errorTestDeviceInvalidArgumentDir   = "invalid argument direction %s = %d, expected : %d"
+	errorTestDeviceDuplicatedValue      = "duplicated %s = '%s'"
+	errorTestDeviceInvalidServiceCount  = "invalid service count = %d, expected : %d"
 )
 
 const (
@@ -58,6 +60,23 @@ func NewTestDevice() (*TestDevice, error) {
 	return testDev, nil
 }
 
+// NewTestControlPointDevice returns a device which is loaded from the description URL of the specified running device.
+func NewTestControlPointDevice(dev *Device) (*Device, error) {
+	locationURL := fmt.Sprintf("http://localhost:%d%s", dev.Port, dev.DescriptionURL)
+	cpDev, err := NewDeviceFromDescriptionURL(locationURL)
+	if err != nil {
+		return nil, err
+	}
+	cpDev.LocationURL = locationURL
+
+	err = cpDev.LoadServiceDescriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return cpDev, nil
+}
+
 func (dev *TestDevice) GetSwitchPowerService() (*Service, error) {
 	return dev.GetServiceByType("urn:schemas-upnp-org:service:SwitchPower:1")
 }
@@ -5,75 +5,54 @@
 package util
 
 import (
+	"cmp"
 	"errors"
+	"math/bits"
 	"net"
+	"slices"
 	"strings"
 )
 
+const (
+	ipv6ZoneDelim = "%"
+)
+
 const (
 	errorAvailableAddressNotFound = "available address not found"
 	errorAvailableInterfaceFound  = "available interface not found"
+	errorHardwareAddressNotFound  = "hardware address not found"
 )
 
 func IsIPv6Address(addr string) bool {
 	return 0 < strings.Index(addr, ":")
 }
 
+// GetInterfaceAddress returns the first IPv4 address of the specified interface.
 func GetInterfaceAddress(ifi net.Interface) (string, error) {
-	addrs, err := ifi.Addrs()
-	if err != nil {
-		return "", err
-	}
-
-	for _, addr := range addrs {
-		saddr := strings.Split(addr.String(), "/")
-		if len(saddr) < 2 {
-			continue
-		}
-
-		// Disabled IPv6 interface
-		if IsIPv6Address(saddr[0]) {
-			continue
-		}
-
-		return saddr[0], nil
-	}
-
-	return "", errors.New(errorAvailableAddressNotFound)
+	return NewInterfaceSelector().GetInterfaceAddress(ifi)
 }
 
+// GetAvailableInterfaces returns the up, multicast and non-loopback interfaces.
 func GetAvailableInterfaces() ([]net.Interface, error) {
-	useIfs := make([]net.Interface, 0)
+	return NewInterfaceSelector().GetAvailableInterfaces()
+}
 
-	localIfs, err := net.Interfaces()
-	if err != nil {
-		return useIfs, err
+// getMatchAddressPrefixLength returns the length of the common prefix bits of the specified addresses.
+func getMatchAddressPrefixLength(ip1 net.IP, ip2 net.IP) int {
+	if ip1 == nil || ip2 == nil {
+		return -1
 	}
 
-	for _, localIf := range localIfs {
-		if (localIf.Flags & net.FlagLoopback) != 0 {
-			continue
-		}
-		if (localIf.Flags & net.FlagUp) == 0 {
-			continue
-		}
-		if (localIf.Flags & net.FlagMulticast) == 0 {
-			continue
-		}
+	ip1 = ip1.To16()
+	ip2 = ip2.To16()
 
-		_, addrErr := GetInterfaceAddress(localIf)
-		if addrErr != nil {
-			continue
+	for n := range ip1 {
+		if diff := ip1[n] ^ ip2[n]; diff != 0 {
+			return n*8 + bits.LeadingZeros8(diff)
 		}
-
-		useIfs = append(useIfs, localIf)
 	}
 
-	if len(useIfs) == 0 {
-		return useIfs, errors.New(errorAvailableInterfaceFound)
-	}
-
-	return useIfs, err
+	return len(ip1) * 8
 }
 
 func getMatchAddressBlockCount(ifAddr string, targetAddr string) int {
@@ -95,34 +74,39 @@ func getMatchAddressBlockCount(ifAddr string, targetAddr string) int {
 	return addrSize
 }
 
+// GetAvailableInterfaceForAddr returns the available interface which is the most suitable to reach the specified address.
 func GetAvailableInterfaceForAddr(fromAddr string) (net.Interface, error) {
-	ifis, err := GetAvailableInterfaces()
-	if err != nil {
-		return net.Interface{}, err
-	}
+	return NewInterfaceSelector().GetAvailableInterfaceForAddr(fromAddr)
+}
 
-	switch len(ifis) {
-	case 0:
-		return net.Interface{}, errors.New(errorAvailableInterfaceFound)
-	case 1:
-		return ifis[0], nil
+// GetHardwareAddress returns the hardware address of the non-loopback interface which has the lowest index.
+// The interfaces which are up are preferred.
+func GetHardwareAddress() (string, error) {
+	ifis, err := net.Interfaces()
+	if err != nil {
+		return "", err
 	}
 
-	ifAddrs := make([]string, len(ifis))
-	for n := range ifAddrs {
-		ifAddrs[n], _ = GetInterfaceAddress(ifis[n])
-	}
+	slices.SortFunc(ifis, func(a, b net.Interface) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
 
-	selIf := ifis[0]
-	selIfMatchBlocks := getMatchAddressBlockCount(fromAddr, ifAddrs[0])
-	for n := range ifAddrs {
-		matchBlocks := getMatchAddressBlockCount(fromAddr, ifAddrs[n])
-		if matchBlocks < selIfMatchBlocks {
+	hwAddr := ""
+	for _, ifi := range ifis {
+		if (ifi.Flags&net.FlagLoopback) != 0 || len(ifi.HardwareAddr) == 0 {
 			continue
 		}
-		selIf = ifis[n]
-		selIfMatchBlocks = matchBlocks
+		if (ifi.Flags & net.FlagUp) != 0 {
+			return ifi.HardwareAddr.String(), nil
+		}
+		if len(hwAddr) == 0 {
+			hwAddr = ifi.HardwareAddr.String()
+		}
+	}
+
+	if len(hwAddr) == 0 {
+		return "", errors.New(errorHardwareAddressNotFound)
 	}
 
-	return selIf, nil
+	return hwAddr, nil
 }
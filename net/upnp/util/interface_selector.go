@@ -0,0 +1,361 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// An InterfaceSelector represents a policy to select the network interfaces and the addresses to bind.
+// A nil or zero InterfaceSelector selects the same interfaces as GetAvailableInterfaces.
+type InterfaceSelector struct {
+	// Includes is a list of interface names, addresses or CIDRs to use. All interfaces are used when it is empty.
+	Includes []string
+	// Excludes is a list of interface names, addresses or CIDRs not to use.
+	Excludes []string
+	// AllowLoopback enables the loopback interfaces which are excluded by default.
+	AllowLoopback bool
+	// Addresses is a list of the explicit addresses to bind. Only the interfaces which have the addresses are used.
+	Addresses []string
+	// DisableIPv6 disables the IPv6 addresses of the interfaces which are used in addition to the IPv4 addresses by default.
+	DisableIPv6 bool
+}
+
+// NewInterfaceSelector returns a new default InterfaceSelector.
+func NewInterfaceSelector() *InterfaceSelector {
+	sel := &InterfaceSelector{
+		Includes:      []string{},
+		Excludes:      []string{},
+		AllowLoopback: false,
+		Addresses:     []string{},
+		DisableIPv6:   false,
+	}
+	return sel
+}
+
+// NewLoopbackInterfaceSelector returns a new InterfaceSelector which uses only the loopback interfaces.
+func NewLoopbackInterfaceSelector() *InterfaceSelector {
+	sel := NewInterfaceSelector()
+	sel.Includes = []string{"127.0.0.0/8"}
+	sel.AllowLoopback = true
+	return sel
+}
+
+// GetExplicitAddresses returns the explicit addresses to bind.
+func (sel *InterfaceSelector) GetExplicitAddresses() []string {
+	if sel == nil {
+		return []string{}
+	}
+	return sel.Addresses
+}
+
+// matchInterfaceEntry returns true when the specified entry, an interface name, an address or a CIDR, matches the interface and the address.
+func matchInterfaceEntry(entry string, ifi net.Interface, ip net.IP) bool {
+	if _, ipnet, err := net.ParseCIDR(entry); err == nil {
+		return ipnet.Contains(ip)
+	}
+	if entryIP := net.ParseIP(entry); entryIP != nil {
+		return entryIP.Equal(ip)
+	}
+	return entry == ifi.Name
+}
+
+func matchInterfaceEntries(entries []string, ifi net.Interface, ip net.IP) bool {
+	return slices.ContainsFunc(entries, func(entry string) bool {
+		return matchInterfaceEntry(entry, ifi, ip)
+	})
+}
+
+// isExplicitInterface returns true when the specified interface is selected by the name or the explicit addresses.
+func (sel *InterfaceSelector) isExplicitInterface(ifi net.Interface) bool {
+	return slices.Contains(sel.Includes, ifi.Name) || 0 < len(sel.Addresses)
+}
+
+// isInterfaceAllowed returns true when the flags and the name of the specified interface are allowed.
+func (sel *InterfaceSelector) isInterfaceAllowed(ifi net.Interface) bool {
+	if (ifi.Flags & net.FlagUp) == 0 {
+		return false
+	}
+
+	if (ifi.Flags & net.FlagLoopback) != 0 {
+		if !sel.AllowLoopback && !sel.isExplicitInterface(ifi) {
+			return false
+		}
+	} else if (ifi.Flags & net.FlagMulticast) == 0 {
+		return false
+	}
+
+	return !slices.Contains(sel.Excludes, ifi.Name)
+}
+
+// isAddressAllowed returns true when the specified address of the interface is allowed.
+func (sel *InterfaceSelector) isAddressAllowed(ifi net.Interface, ip net.IP) bool {
+	if 0 < len(sel.Addresses) && !matchInterfaceEntries(sel.Addresses, ifi, ip) {
+		return false
+	}
+	if matchInterfaceEntries(sel.Excludes, ifi, ip) {
+		return false
+	}
+	if 0 < len(sel.Includes) && !matchInterfaceEntries(sel.Includes, ifi, ip) {
+		return false
+	}
+	return true
+}
+
+// getInterfaceIPs returns the addresses of the specified interface which are allowed by the selector.
+func (sel *InterfaceSelector) getInterfaceIPs(ifi net.Interface) ([]net.IP, error) {
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if !sel.isAddressAllowed(ifi, ipnet.IP) {
+			continue
+		}
+		ips = append(ips, ipnet.IP)
+	}
+
+	return ips, nil
+}
+
+// GetInterfaceAddress returns the first IPv4 address of the specified interface which is allowed by the selector.
+func (sel *InterfaceSelector) GetInterfaceAddress(ifi net.Interface) (string, error) {
+	if sel == nil {
+		sel = NewInterfaceSelector()
+	}
+
+	ips, err := sel.getInterfaceIPs(ifi)
+	if err != nil {
+		return "", err
+	}
+
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip.String(), nil
+		}
+	}
+
+	return "", errors.New(errorAvailableAddressNotFound)
+}
+
+// GetInterfaceIPv6Address returns the IPv6 address of the specified interface which is allowed by the selector.
+// The global and the unique local addresses are preferred to the link-local addresses.
+func (sel *InterfaceSelector) GetInterfaceIPv6Address(ifi net.Interface) (string, error) {
+	if sel == nil {
+		sel = NewInterfaceSelector()
+	}
+
+	ips, err := sel.getInterfaceIPs(ifi)
+	if err != nil {
+		return "", err
+	}
+
+	var linkLocalIP net.IP
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			continue
+		}
+		if ip.IsLinkLocalUnicast() {
+			if linkLocalIP == nil {
+				linkLocalIP = ip
+			}
+			continue
+		}
+		return ip.String(), nil
+	}
+
+	if linkLocalIP != nil {
+		return linkLocalIP.String(), nil
+	}
+
+	return "", errors.New(errorAvailableAddressNotFound)
+}
+
+// GetInterfaceAddresses returns the IPv4 address and the IPv6 address, unless IPv6 is disabled, of the specified interface.
+func (sel *InterfaceSelector) GetInterfaceAddresses(ifi net.Interface) ([]string, error) {
+	if sel == nil {
+		sel = NewInterfaceSelector()
+	}
+
+	addrs := make([]string, 0, 2)
+	if addr, err := sel.GetInterfaceAddress(ifi); err == nil {
+		addrs = append(addrs, addr)
+	}
+	if !sel.DisableIPv6 {
+		if addr, err := sel.GetInterfaceIPv6Address(ifi); err == nil {
+			addrs = append(addrs, addr)
+		}
+	}
+
+	if len(addrs) == 0 {
+		return addrs, errors.New(errorAvailableAddressNotFound)
+	}
+
+	return addrs, nil
+}
+
+// GetAvailableInterfaces returns the interfaces which are allowed by the selector.
+func (sel *InterfaceSelector) GetAvailableInterfaces() ([]net.Interface, error) {
+	if sel == nil {
+		sel = NewInterfaceSelector()
+	}
+
+	useIfs := make([]net.Interface, 0)
+
+	localIfs, err := net.Interfaces()
+	if err != nil {
+		return useIfs, err
+	}
+
+	for _, localIf := range localIfs {
+		if !sel.isInterfaceAllowed(localIf) {
+			continue
+		}
+
+		_, addrErr := sel.GetInterfaceAddresses(localIf)
+		if addrErr != nil {
+			continue
+		}
+
+		useIfs = append(useIfs, localIf)
+	}
+
+	if len(useIfs) == 0 {
+		return useIfs, errors.New(errorAvailableInterfaceFound)
+	}
+
+	return useIfs, nil
+}
+
+// GetAvailableNetworks returns the networks of the allowed addresses of the available interfaces, which are the directly attached subnets.
+func (sel *InterfaceSelector) GetAvailableNetworks() ([]netip.Prefix, error) {
+	ifis, err := sel.GetAvailableInterfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	if sel == nil {
+		sel = NewInterfaceSelector()
+	}
+
+	networks := make([]netip.Prefix, 0)
+	for _, ifi := range ifis {
+		addrs, err := ifi.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok || !sel.isAddressAllowed(ifi, ipnet.IP) {
+				continue
+			}
+			ip, ok := netip.AddrFromSlice(ipnet.IP)
+			if !ok {
+				continue
+			}
+			ip = ip.Unmap()
+			bits, size := ipnet.Mask.Size()
+			if ip.Is4() && size == net.IPv6len*8 {
+				bits -= (net.IPv6len - net.IPv4len) * 8
+			}
+			networks = append(networks, netip.PrefixFrom(ip, bits).Masked())
+		}
+	}
+
+	return networks, nil
+}
+
+// GetAvailableInterfaceForAddr returns the allowed interface which is the most suitable to reach the specified address.
+func (sel *InterfaceSelector) GetAvailableInterfaceForAddr(fromAddr string) (net.Interface, error) {
+	ifis, err := sel.GetAvailableInterfaces()
+	if err != nil {
+		return net.Interface{}, err
+	}
+
+	switch len(ifis) {
+	case 0:
+		return net.Interface{}, errors.New(errorAvailableInterfaceFound)
+	case 1:
+		return ifis[0], nil
+	}
+
+	ifAddrs := make([]string, len(ifis))
+	for n := range ifAddrs {
+		ifAddrs[n], _ = sel.GetInterfaceAddress(ifis[n])
+	}
+
+	selIf := ifis[0]
+	selIfMatchBlocks := getMatchAddressBlockCount(fromAddr, ifAddrs[0])
+	for n := range ifAddrs {
+		matchBlocks := getMatchAddressBlockCount(fromAddr, ifAddrs[n])
+		if matchBlocks < selIfMatchBlocks {
+			continue
+		}
+		selIf = ifis[n]
+		selIfMatchBlocks = matchBlocks
+	}
+
+	return selIf, nil
+}
+
+// getAvailableIPv6AddressForAddr returns the allowed IPv6 address which is the most suitable to reach the specified address.
+// The interface of the zone is used when the specified address has a zone.
+func (sel *InterfaceSelector) getAvailableIPv6AddressForAddr(fromAddr string) (string, error) {
+	ifis, err := sel.GetAvailableInterfaces()
+	if err != nil {
+		return "", err
+	}
+
+	fromHost, zone, _ := strings.Cut(fromAddr, ipv6ZoneDelim)
+	fromIP := net.ParseIP(fromHost)
+
+	selAddr := ""
+	selAddrPrefixLen := -1
+	for _, ifi := range ifis {
+		addr, err := sel.GetInterfaceIPv6Address(ifi)
+		if err != nil {
+			continue
+		}
+		if 0 < len(zone) && ifi.Name == zone {
+			return addr, nil
+		}
+		prefixLen := getMatchAddressPrefixLength(fromIP, net.ParseIP(addr))
+		if prefixLen <= selAddrPrefixLen {
+			continue
+		}
+		selAddr = addr
+		selAddrPrefixLen = prefixLen
+	}
+
+	if len(selAddr) == 0 {
+		return "", errors.New(errorAvailableAddressNotFound)
+	}
+
+	return selAddr, nil
+}
+
+// GetAvailableAddressForAddr returns the allowed interface address which is the most suitable to reach the specified address.
+// An IPv6 address is returned for an IPv6 address unless IPv6 is disabled.
+func (sel *InterfaceSelector) GetAvailableAddressForAddr(fromAddr string) (string, error) {
+	if (sel == nil || !sel.DisableIPv6) && IsIPv6Address(fromAddr) {
+		return sel.getAvailableIPv6AddressForAddr(fromAddr)
+	}
+
+	ifi, err := sel.GetAvailableInterfaceForAddr(fromAddr)
+	if err != nil {
+		return "", err
+	}
+	return sel.GetInterfaceAddress(ifi)
+}
@@ -0,0 +1,140 @@
+// Copyright 2015 The go-net-upnp Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+)
+
+const (
+	errorInterfaceSelectorInvalidInterfaces = "invalid interfaces %v : expected only loopback interfaces"
+	errorInterfaceSelectorInvalidAddress    = "invalid address %s : expected %s"
+	errorInterfaceSelectorLoopbackSelected  = "loopback interface (%s) is selected"
+	errorInterfaceSelectorNetworkNotFound   = "network of %s is not found in %v"
+	errorInterfaceSelectorIPv6NotSelected   = "IPv6 address is not selected in %v : DisableIPv6 = %t"
+)
+
+func TestInterfaceSelector(t *testing.T) {
+	loopbackAddr := "127.0.0.1"
+
+	// loopback
+
+	sel := NewLoopbackInterfaceSelector()
+	ifis, err := sel.GetAvailableInterfaces()
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, ifi := range ifis {
+		if (ifi.Flags & net.FlagLoopback) == 0 {
+			t.Errorf(errorInterfaceSelectorInvalidInterfaces, ifis)
+		}
+	}
+
+	addr, err := sel.GetAvailableAddressForAddr(loopbackAddr)
+	if err != nil {
+		t.Error(err)
+	}
+	if addr != loopbackAddr {
+		t.Errorf(errorInterfaceSelectorInvalidAddress, addr, loopbackAddr)
+	}
+
+	// explicit address
+
+	sel = NewInterfaceSelector()
+	sel.Addresses = []string{loopbackAddr}
+	addr, err = sel.GetAvailableAddressForAddr(loopbackAddr)
+	if err != nil {
+		t.Error(err)
+	}
+	if addr != loopbackAddr {
+		t.Errorf(errorInterfaceSelectorInvalidAddress, addr, loopbackAddr)
+	}
+
+	// excludes
+
+	sel = NewLoopbackInterfaceSelector()
+	sel.Excludes = []string{"127.0.0.0/8"}
+	ifis, _ = sel.GetAvailableInterfaces()
+	for _, ifi := range ifis {
+		if (ifi.Flags & net.FlagLoopback) != 0 {
+			t.Errorf(errorInterfaceSelectorLoopbackSelected, ifi.Name)
+		}
+	}
+
+	// default
+
+	var nilSel *InterfaceSelector
+	ifis, _ = nilSel.GetAvailableInterfaces()
+	for _, ifi := range ifis {
+		if (ifi.Flags & net.FlagLoopback) != 0 {
+			t.Errorf(errorInterfaceSelectorLoopbackSelected, ifi.Name)
+		}
+	}
+}
+
+func TestInterfaceSelectorNetworks(t *testing.T) {
+	loopbackAddr := netip.MustParseAddr("127.0.0.1")
+
+	sel := NewInterfaceSelector()
+	sel.Addresses = []string{loopbackAddr.String()}
+	networks, err := sel.GetAvailableNetworks()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	for _, network := range networks {
+		if network.Contains(loopbackAddr) {
+			return
+		}
+	}
+
+	t.Errorf(errorInterfaceSelectorNetworkNotFound, loopbackAddr, networks)
+}
+
+func TestInterfaceSelectorDualStack(t *testing.T) {
+	newSelector := func() *InterfaceSelector {
+		sel := NewInterfaceSelector()
+		sel.Includes = []string{"127.0.0.0/8", "::1/128"}
+		sel.AllowLoopback = true
+		return sel
+	}
+
+	ifis, err := newSelector().GetAvailableInterfaces()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	hasIPv6 := func(addrs []string) bool {
+		for _, addr := range addrs {
+			if IsIPv6Address(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, ifi := range ifis {
+		// IPv6 is enabled by default
+
+		sel := newSelector()
+		addrs, err := sel.GetInterfaceAddresses(ifi)
+		if err != nil || !hasIPv6(addrs) {
+			continue
+		}
+
+		// IPv6 is disabled
+
+		sel.DisableIPv6 = true
+		addrs, _ = sel.GetInterfaceAddresses(ifi)
+		if hasIPv6(addrs) {
+			t.Errorf(errorInterfaceSelectorIPv6NotSelected, addrs, sel.DisableIPv6)
+		}
+		return
+	}
+
+	t.Skipf(errorInterfaceSelectorIPv6NotSelected, ifis, false)
+}
@@ -6,7 +6,9 @@ package util
 
 import (
 	"fmt"
+	"net"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -44,3 +46,41 @@ func GetAbsoluteURLFromBaseAndPath(base string, path string) (*url.URL, error) {
 
 	return url, nil
 }
+
+// JoinHostPort returns a host and port of URL for the specified address. The IPv6 address is bracketed, and the zone is escaped.
+func JoinHostPort(addr string, port int) string {
+	addr = strings.Replace(addr, ipv6ZoneDelim, url.PathEscape(ipv6ZoneDelim), 1)
+	return net.JoinHostPort(addr, strconv.Itoa(port))
+}
+
+// ReviseURLZone returns the specified URL with the zone when the host is an IPv6 link-local address without zone.
+func ReviseURLZone(rawURL string, zone string) string {
+	if len(zone) == 0 {
+		return rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	host := u.Hostname()
+	if strings.Contains(host, ipv6ZoneDelim) {
+		return rawURL
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.To4() != nil || !ip.IsLinkLocalUnicast() {
+		return rawURL
+	}
+
+	// The host of url.URL holds the unescaped zone.
+	zonedHost := host + ipv6ZoneDelim + zone
+	if port := u.Port(); 0 < len(port) {
+		u.Host = net.JoinHostPort(zonedHost, port)
+	} else {
+		u.Host = "[" + zonedHost + "]"
+	}
+
+	return u.String()
+}
@@ -128,3 +128,31 @@ func TestAbaPathURL(t *testing.T) {
 		}
 	}
 }
+
+func TestURLZone(t *testing.T) {
+	hostPorts := map[string]string{
+		"192.168.100.1": "192.168.100.1:80",
+		"fd00::1":       "[fd00::1]:80",
+		"fe80::1%eth0":  "[fe80::1%25eth0]:80",
+	}
+	for addr, expected := range hostPorts {
+		hostPort := JoinHostPort(addr, 80)
+		if hostPort != expected {
+			t.Errorf(errorInvalidURL, hostPort, expected)
+		}
+	}
+
+	urls := map[string]string{
+		"http://192.168.100.1:80/desc.xml": "http://192.168.100.1:80/desc.xml",
+		"http://[fd00::1]:80/desc.xml":     "http://[fd00::1]:80/desc.xml",
+		"http://[fe80::1]:80/desc.xml":     "http://[fe80::1%25eth0]:80/desc.xml",
+		"http://[fe80::1]/desc.xml":        "http://[fe80::1%25eth0]/desc.xml",
+		"http://[fe80::1%25eth1]/desc.xml": "http://[fe80::1%25eth1]/desc.xml",
+	}
+	for rawURL, expected := range urls {
+		revisedURL := ReviseURLZone(rawURL, "eth0")
+		if revisedURL != expected {
+			t.Errorf(errorInvalidURL, revisedURL, expected)
+		}
+	}
+}
@@ -12,3 +12,18 @@ import (
 func CreateUUID() string {
 	return uuid.New().String()
 }
+
+// CreateNameUUID returns a name-based UUID (version 5) of the specified namespace UUID and name.
+// The same UUID is returned for the same namespace and name.
+func CreateNameUUID(namespace string, name string) (string, error) {
+	ns, err := uuid.Parse(namespace)
+	if err != nil {
+		return "", err
+	}
+	return uuid.NewSHA1(ns, []byte(name)).String(), nil
+}
+
+// IsUUID returns true when the specified string is a UUID, otherwise false.
+func IsUUID(s string) bool {
+	return uuid.Validate(s) == nil
+}
@@ -25,3 +25,30 @@ func TestCreateUUID(t *testing.T) {
 		t.Errorf(errorInvalidUUID, uuid)
 	}
 }
+
+func TestCreateNameUUID(t *testing.T) {
+	const namespace = "6ba7b811-9dad-11d1-80b4-00c04fd430c8"
+
+	uuid, err := CreateNameUUID(namespace, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsUUID(uuid) || uuid[14] != '5' {
+		t.Errorf(errorInvalidUUID, uuid)
+	}
+
+	otherUUID, _ := CreateNameUUID(namespace, "test")
+	if uuid != otherUUID {
+		t.Errorf(errorInvalidUUID, otherUUID)
+	}
+
+	otherUUID, _ = CreateNameUUID(namespace, "other")
+	if uuid == otherUUID {
+		t.Errorf(errorInvalidUUID, otherUUID)
+	}
+
+	_, err = CreateNameUUID("invalid", "test")
+	if err == nil {
+		t.Errorf(errorInvalidUUID, "invalid")
+	}
+}